@@ -72,6 +72,33 @@ func TestAccDeploymentTrafficFilterAssociation_basic(t *testing.T) {
 	})
 }
 
+func TestAccDeploymentTrafficFilterAssociation_excludeDefaults(t *testing.T) {
+	resName := "ec_deployment_traffic_filter.tf_assoc"
+	resAssocName := "ec_deployment_traffic_filter_association.tf_assoc"
+	randomName := acctest.RandomWithPrefix(prefix)
+	startCfg := "testdata/deployment_traffic_filter_association_exclusive.tf"
+	cfg := fixtureAccDeploymentTrafficFilterResourceAssociationBasic(t, startCfg, randomName, getRegion(), defaultTemplate)
+
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:          func() { testAccPreCheck(t) },
+		ProviderFactories: testAccProviderFactory,
+		CheckDestroy:      testAccDeploymentTrafficFilterDestroy,
+		Steps: []resource.TestStep{
+			{
+				// Expects a non-empty plan since "ec_deployment.traffic_filter"
+				// will have changes due to the traffic filter association.
+				ExpectNonEmptyPlan: true,
+				Config:             cfg,
+				Check: checkBasicDeploymentTrafficFilterAssociationResource(
+					resName, resAssocName, randomName,
+					resource.TestCheckResourceAttr(resAssocName, "include_default", "false"),
+					resource.TestCheckResourceAttr(resName, "include_by_default", "false"),
+				),
+			},
+		},
+	})
+}
+
 func fixtureAccDeploymentTrafficFilterResourceAssociationBasic(t *testing.T, fileName, name, region, depTpl string) string {
 	t.Helper()
 
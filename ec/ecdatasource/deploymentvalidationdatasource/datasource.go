@@ -0,0 +1,103 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentvalidationdatasource
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/apierror"
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	"github.com/elastic/cloud-sdk-go/pkg/client/deployments"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/ecresource/deploymentresource"
+)
+
+// DataSource returns the ec_deployment_validation data source schema. It
+// validates the create request which the equivalent ec_deployment resource
+// would send, without creating the deployment.
+func DataSource() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: read,
+
+		Schema: newSchema(),
+
+		Description: "Elastic Cloud Deployment validation data source",
+
+		Timeouts: &schema.ResourceTimeout{
+			Default: schema.DefaultTimeout(5 * time.Minute),
+		},
+	}
+}
+
+func read(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client := meta.(*api.API)
+
+	req, err := deploymentresource.CreateResourceToModel(d, client)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	overrides, err := deploymentresource.OverrideVersions(d, req, deploymentapi.PayloadOverrides{
+		Name:    d.Get("name").(string),
+		Version: d.Get("version").(string),
+		Region:  d.Get("region").(string),
+	})
+	if err != nil {
+		return diag.FromErr(multierror.NewPrefixed("failed validating deployment", err))
+	}
+
+	if err := validateCreateRequest(client, req, overrides); err != nil {
+		return diag.FromErr(multierror.NewPrefixed("failed validating deployment", err))
+	}
+
+	if d.Id() == "" {
+		if b, _ := req.MarshalBinary(); len(b) > 0 {
+			d.SetId(strconv.Itoa(schema.HashString(string(b))))
+		}
+	}
+
+	return nil
+}
+
+// validateCreateRequest sends the create request with "validate_only" set, so
+// the API validates the request without creating the deployment.
+func validateCreateRequest(client *api.API, req *models.DeploymentCreateRequest, overrides *deploymentapi.PayloadOverrides) error {
+	if err := deploymentapi.OverrideCreateOrUpdateRequest(req, overrides); err != nil {
+		return err
+	}
+
+	_, _, _, err := client.V1API.Deployments.CreateDeployment(
+		deployments.NewCreateDeploymentParams().
+			WithValidateOnly(ec.Bool(true)).
+			WithBody(req),
+		client.AuthWriter,
+	)
+	if err != nil {
+		return apierror.Wrap(err)
+	}
+
+	return nil
+}
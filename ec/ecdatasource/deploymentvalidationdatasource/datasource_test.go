@@ -0,0 +1,132 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentvalidationdatasource
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataSource(t *testing.T) {
+	assert.NoError(t, DataSource().InternalValidate(nil, false))
+}
+
+func Test_read(t *testing.T) {
+	hotWarmTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "../../ecresource/deploymentresource/testdata/template-aws-hot-warm-v2.json")
+	}
+	newRD := func() *schema.ResourceData {
+		return schema.TestResourceDataRaw(t, newSchema(), map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"deployment_template_id": "aws-hot-warm-v2",
+			"region":                 "us-east-1",
+			"version":                "7.9.2",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+			"kibana":                 []interface{}{map[string]interface{}{}},
+		})
+	}
+	type args struct {
+		d    *schema.ResourceData
+		meta interface{}
+	}
+	tests := []struct {
+		name   string
+		args   args
+		want   diag.Diagnostics
+		wantID bool
+	}{
+		{
+			name: "validates the deployment create request",
+			args: args{
+				d: newRD(),
+				meta: api.NewMock(
+					mock.New200Response(hotWarmTpl()),
+					mock.New200Response(mock.NewStringBody(`{}`)),
+				),
+			},
+			wantID: true,
+		},
+		{
+			name: "returns the validation errors as diagnostics",
+			args: args{
+				d: newRD(),
+				meta: api.NewMock(
+					mock.New200Response(hotWarmTpl()),
+					mock.NewErrorResponse(400, mock.APIError{
+						Code: "deployments.invalid_request", Message: "invalid request",
+					}),
+				),
+			},
+			want: diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "failed validating deployment: 1 error occurred:\n\t* api error: deployments.invalid_request: invalid request\n\n",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := read(context.Background(), tt.args.d, tt.args.meta)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantID, tt.args.d.Id() != "")
+		})
+	}
+}
+
+func fileAsResponseBody(t *testing.T, name string) io.ReadCloser {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var buf = new(bytes.Buffer)
+	if _, err := io.Copy(buf, f); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("\n")
+
+	return ioutil.NopCloser(buf)
+}
+
+func Test_newSchema(t *testing.T) {
+	s := newSchema()
+	for _, k := range []string{
+		"request_id", "wait_for", "poll_interval", "migrate_to_latest_hardware",
+		"topology_aliases", "traffic_filter", "traffic_filter_exclude",
+		"traffic_filter_include_default", "plan_hash", "resource_ids",
+		"system_tags", "trust_self_account_id",
+	} {
+		assert.NotContains(t, s, k)
+	}
+	assert.NotContains(t, s["elasticsearch"].Elem.(*schema.Resource).Schema, "trust_self")
+	assert.NotContains(t, s["observability"].Elem.(*schema.Resource).Schema, "self")
+	assert.True(t, s["observability"].Elem.(*schema.Resource).Schema["deployment_id"].Required)
+}
@@ -0,0 +1,72 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentvalidationdatasource
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/ecresource/deploymentresource"
+)
+
+// newSchema returns the ec_deployment schema without the settings
+// and attributes which are only meaningful once a deployment is created.
+func newSchema() map[string]*schema.Schema {
+	s := deploymentresource.NewSchema()
+	for _, k := range []string{
+		"request_id",
+		"verify_docker_images",
+		"elasticsearch_username",
+		"elasticsearch_password",
+		"apm_secret_token",
+		"created_at",
+		"last_modified",
+		"wait_for",
+		"poll_interval",
+		"migrate_to_latest_hardware",
+		"topology_aliases",
+		"traffic_filter",
+		"traffic_filter_exclude",
+		"traffic_filter_include_default",
+		"plan_hash",
+		"resource_ids",
+		"system_tags",
+		"trust_self_account_id",
+	} {
+		delete(s, k)
+	}
+
+	// The trust with the current organization requires its account ID to be
+	// looked up, and an observability destination on the deployment itself
+	// requires its ID, which are only known once a deployment is created.
+	delete(s["elasticsearch"].Elem.(*schema.Resource).Schema, "trust_self")
+
+	observability := s["observability"].Elem.(*schema.Resource).Schema
+	delete(observability, "self")
+	observability["deployment_id"].Required = true
+	observability["deployment_id"].Optional = false
+	observability["deployment_id"].Computed = false
+
+	// The provider "region" isn't used as the default of the validated
+	// deployments, so the region must be set.
+	s["region"].ForceNew = false
+	s["region"].Required = true
+	s["region"].Optional = false
+	s["region"].Computed = false
+
+	return s
+}
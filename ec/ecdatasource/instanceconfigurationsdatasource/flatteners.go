@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package instanceconfigurationsdatasource
+
+import (
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+// flattenInstanceConfigurations flattens the instance configurations, leaving
+// out the ones which don't match the instanceType when it's set.
+func flattenInstanceConfigurations(in []*models.InstanceConfiguration, instanceType string) []interface{} {
+	result := make([]interface{}, 0, len(in))
+	for _, ic := range in {
+		if ic == nil {
+			continue
+		}
+
+		var icType string
+		if ic.InstanceType != nil {
+			icType = *ic.InstanceType
+		}
+
+		if instanceType != "" && instanceType != icType {
+			continue
+		}
+
+		m := map[string]interface{}{
+			"id":            ic.ID,
+			"description":   ic.Description,
+			"instance_type": icType,
+			"max_zones":     int(ic.MaxZones),
+		}
+
+		if ic.Name != nil {
+			m["name"] = *ic.Name
+		}
+
+		if sizes := ic.DiscreteSizes; sizes != nil {
+			if sizes.Resource != nil {
+				m["size_resource"] = *sizes.Resource
+			}
+
+			if sizes.DefaultSize != nil {
+				m["default_size"] = util.MemoryToState(*sizes.DefaultSize)
+			}
+
+			allowed := make([]interface{}, 0, len(sizes.Sizes))
+			for _, size := range sizes.Sizes {
+				allowed = append(allowed, util.MemoryToState(size))
+			}
+			m["sizes"] = allowed
+		}
+
+		result = append(result, m)
+	}
+
+	return result
+}
@@ -0,0 +1,119 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package instanceconfigurationsdatasource
+
+import (
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_flattenInstanceConfigurations(t *testing.T) {
+	ics := []*models.InstanceConfiguration{
+		{
+			ID:           "aws.data.highio.i3",
+			Name:         ec.String("aws.data.highio.i3"),
+			Description:  "Instance configuration for I/O optimized data nodes",
+			InstanceType: ec.String("elasticsearch"),
+			MaxZones:     3,
+			DiscreteSizes: &models.DiscreteSizes{
+				Resource:    ec.String("memory"),
+				DefaultSize: ec.Int32(8192),
+				Sizes:       []int32{1024, 2048, 4096, 8192, 15360},
+			},
+		},
+		{
+			ID:           "aws.kibana.r5d",
+			Name:         ec.String("aws.kibana.r5d"),
+			InstanceType: ec.String("kibana"),
+			MaxZones:     3,
+			DiscreteSizes: &models.DiscreteSizes{
+				Resource:    ec.String("memory"),
+				DefaultSize: ec.Int32(1024),
+				Sizes:       []int32{1024, 2048, 4096, 8192},
+			},
+		},
+	}
+
+	esIC := map[string]interface{}{
+		"id":            "aws.data.highio.i3",
+		"name":          "aws.data.highio.i3",
+		"description":   "Instance configuration for I/O optimized data nodes",
+		"instance_type": "elasticsearch",
+		"max_zones":     3,
+		"size_resource": "memory",
+		"default_size":  "8g",
+		"sizes":         []interface{}{"1g", "2g", "4g", "8g", "15g"},
+	}
+	kibanaIC := map[string]interface{}{
+		"id":            "aws.kibana.r5d",
+		"name":          "aws.kibana.r5d",
+		"description":   "",
+		"instance_type": "kibana",
+		"max_zones":     3,
+		"size_resource": "memory",
+		"default_size":  "1g",
+		"sizes":         []interface{}{"1g", "2g", "4g", "8g"},
+	}
+
+	type args struct {
+		in           []*models.InstanceConfiguration
+		instanceType string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "empty list returns an empty list",
+			want: []interface{}{},
+		},
+		{
+			name: "flattens all the instance configurations",
+			args: args{in: ics},
+			want: []interface{}{esIC, kibanaIC},
+		},
+		{
+			name: "flattens the instance configurations matching the instance type",
+			args: args{in: ics, instanceType: "kibana"},
+			want: []interface{}{kibanaIC},
+		},
+		{
+			name: "flattens an instance configuration without discrete sizes",
+			args: args{in: []*models.InstanceConfiguration{{
+				ID:           "some-ic",
+				InstanceType: ec.String("apm"),
+			}}},
+			want: []interface{}{map[string]interface{}{
+				"id":            "some-ic",
+				"description":   "",
+				"instance_type": "apm",
+				"max_zones":     0,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenInstanceConfigurations(tt.args.in, tt.args.instanceType)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
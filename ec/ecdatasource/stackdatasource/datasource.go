@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
@@ -62,7 +63,13 @@ func read(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diag
 	versionExpr := d.Get("version_regex").(string)
 	version := d.Get("version").(string)
 	lock := d.Get("lock").(bool)
-	stack, err := stackFromFilters(versionExpr, version, lock, res.Stacks)
+
+	var stack *models.StackVersionConfig
+	if prefix := d.Get("version_prefix").(string); prefix != "" {
+		stack, err = stackFromPrefix(prefix, res.Stacks)
+	} else {
+		stack, err = stackFromFilters(versionExpr, version, lock, res.Stacks)
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -103,6 +110,22 @@ func stackFromFilters(expr, version string, locked bool, stacks []*models.StackV
 	)
 }
 
+// stackFromPrefix returns the latest stack version which starts with the
+// version prefix, such as "8.11", matching whole version components only.
+// The stacks are expected to be sorted from the latest to the oldest version.
+func stackFromPrefix(prefix string, stacks []*models.StackVersionConfig) (*models.StackVersionConfig, error) {
+	prefix = strings.TrimSuffix(prefix, ".")
+	for _, stack := range stacks {
+		if stack.Version == prefix || strings.HasPrefix(stack.Version, prefix+".") {
+			return stack, nil
+		}
+	}
+
+	return nil, fmt.Errorf(`failed to obtain a stack version with the prefix "%s": `+
+		`please specify a valid version_prefix`, prefix,
+	)
+}
+
 func modelToState(d *schema.ResourceData, stack *models.StackVersionConfig) error {
 	if stack == nil {
 		return nil
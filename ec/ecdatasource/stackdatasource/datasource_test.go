@@ -254,3 +254,52 @@ func Test_stackFromFilters(t *testing.T) {
 		})
 	}
 }
+
+func Test_stackFromPrefix(t *testing.T) {
+	var stackPacks = []*models.StackVersionConfig{
+		{Version: "8.11.3"},
+		{Version: "8.11.2"},
+		{Version: "8.1.3"},
+		{Version: "8.1.0"},
+		{Version: "7.17.15"},
+	}
+	tests := []struct {
+		name   string
+		prefix string
+		want   *models.StackVersionConfig
+		err    error
+	}{
+		{
+			name:   "returns the latest patch version of a minor version",
+			prefix: "8.11",
+			want:   &models.StackVersionConfig{Version: "8.11.3"},
+		},
+		{
+			name:   "doesn't match partial version components",
+			prefix: "8.1",
+			want:   &models.StackVersionConfig{Version: "8.1.3"},
+		},
+		{
+			name:   "ignores a trailing dot",
+			prefix: "7.",
+			want:   &models.StackVersionConfig{Version: "7.17.15"},
+		},
+		{
+			name:   "returns the exact version",
+			prefix: "8.11.2",
+			want:   &models.StackVersionConfig{Version: "8.11.2"},
+		},
+		{
+			name:   "returns an error when no version matches the prefix",
+			prefix: "8.12",
+			err:    errors.New(`failed to obtain a stack version with the prefix "8.12": please specify a valid version_prefix`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stackFromPrefix(tt.prefix, stackPacks)
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
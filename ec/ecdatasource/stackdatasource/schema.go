@@ -24,8 +24,14 @@ import (
 func newSchema() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		"version_regex": {
-			Type:     schema.TypeString,
-			Required: true,
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"version_regex", "version_prefix"},
+		},
+		"version_prefix": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"version_regex", "version_prefix"},
 		},
 		"region": {
 			Type:     schema.TypeString,
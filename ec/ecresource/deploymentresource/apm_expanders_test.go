@@ -227,6 +227,51 @@ func Test_expandApmResources(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "parses an APM resource with tail based sampling user settings",
+			args: args{
+				tpl: tpl(),
+				ess: []interface{}{map[string]interface{}{
+					"ref_id":                       "main-apm",
+					"elasticsearch_cluster_ref_id": "main-elasticsearch",
+					"resource_id":                  mock.ValidClusterID,
+					"region":                       "some-region",
+					"config": []interface{}{map[string]interface{}{
+						"user_settings_yaml": "apm-server.sampling.tail.enabled: true\napm-server.sampling.tail.interval: 1m",
+						"user_settings_json": `{"apm-server.sampling.tail.policies":[{"sample_rate":0.1}]}`,
+					}},
+					"topology": []interface{}{map[string]interface{}{
+						"instance_configuration_id": "aws.apm.r5d",
+						"size":                      "1g",
+						"size_resource":             "memory",
+						"zone_count":                1,
+					}},
+				}},
+			},
+			want: []*models.ApmPayload{{
+				ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+				Region:                    ec.String("some-region"),
+				RefID:                     ec.String("main-apm"),
+				Plan: &models.ApmPlan{
+					Apm: &models.ApmConfiguration{
+						UserSettingsYaml: "apm-server.sampling.tail.enabled: true\napm-server.sampling.tail.interval: 1m",
+						UserSettingsJSON: map[string]interface{}{
+							"apm-server.sampling.tail.policies": []interface{}{
+								map[string]interface{}{"sample_rate": 0.1},
+							},
+						},
+					},
+					ClusterTopology: []*models.ApmTopologyElement{{
+						ZoneCount:               1,
+						InstanceConfigurationID: "aws.apm.r5d",
+						Size: &models.TopologySize{
+							Resource: ec.String("memory"),
+							Value:    ec.Int32(1024),
+						},
+					}},
+				},
+			}},
+		},
 		{
 			name: "tries to parse an apm resource when the template doesn't have an APM instance set.",
 			args: args{
@@ -261,3 +306,92 @@ func Test_expandApmResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_expandApmConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []interface{}
+		want *models.ApmConfiguration
+		err  error
+	}{
+		{
+			name: "expands all the user settings",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+				"user_settings_override_json": `{"some.setting":"override"}`,
+			}},
+			want: &models.ApmConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON: map[string]interface{}{
+					"some.setting": "value",
+				},
+				UserSettingsOverrideJSON: map[string]interface{}{
+					"some.setting": "override",
+				},
+			},
+		},
+		{
+			name: "expands debug_enabled into the system settings",
+			raw: []interface{}{map[string]interface{}{
+				"debug_enabled": true,
+			}},
+			want: &models.ApmConfiguration{
+				SystemSettings: &models.ApmSystemSettings{
+					DebugEnabled: ec.Bool(true),
+				},
+			},
+		},
+		{
+			name: "expands an explicitly disabled debug_enabled",
+			raw: []interface{}{map[string]interface{}{
+				"debug_enabled": false,
+			}},
+			want: &models.ApmConfiguration{
+				SystemSettings: &models.ApmSystemSettings{
+					DebugEnabled: ec.Bool(false),
+				},
+			},
+		},
+		{
+			name: "leaves the system settings unset without system settings",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_yaml": "apm-server.rum.enabled: true",
+			}},
+			want: &models.ApmConfiguration{
+				UserSettingsYaml: "apm-server.rum.enabled: true",
+			},
+		},
+		{
+			name: "leaves the JSON user settings unset when empty",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_json":          "",
+				"user_settings_override_json": "",
+			}},
+			want: &models.ApmConfiguration{},
+		},
+		{
+			name: "fails expanding an invalid user_settings_override_json",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_override_json": `{"some.setting":}`,
+			}},
+			want: &models.ApmConfiguration{},
+			err:  errors.New("failed expanding apm user_settings_override_json: invalid character '}' looking for beginning of value"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.ApmConfiguration
+			err := expandApmConfig(tt.raw, &got)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, &got)
+		})
+	}
+}
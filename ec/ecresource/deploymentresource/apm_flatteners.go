@@ -18,9 +18,6 @@
 package deploymentresource
 
 import (
-	"bytes"
-	"encoding/json"
-
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
@@ -31,7 +28,13 @@ func flattenApmResources(in []*models.ApmResourceInfo, name string) []interface{
 	var result = make([]interface{}, 0, len(in))
 	for _, res := range in {
 		var m = make(map[string]interface{})
-		if util.IsCurrentApmPlanEmpty(res) || isApmResourceStopped(res) {
+		if util.IsCurrentApmPlanEmpty(res) {
+			continue
+		}
+
+		// Resources which have been scaled to zero are kept in the state so
+		// that their configuration block doesn't cause a perpetual diff.
+		if isApmResourceStopped(res) && !isApmPlanScaledToZero(res.Info.PlanInfo.Current.Plan) {
 			continue
 		}
 
@@ -41,10 +44,16 @@ func flattenApmResources(in []*models.ApmResourceInfo, name string) []interface{
 
 		if res.Info.ID != nil && *res.Info.ID != "" {
 			m["resource_id"] = *res.Info.ID
+		} else if res.ID != nil && *res.ID != "" {
+			m["resource_id"] = *res.ID
 		}
 
-		if res.Region != nil {
+		m["kind"] = "apm"
+
+		if res.Region != nil && *res.Region != "" {
 			m["region"] = *res.Region
+		} else if res.Info.Region != "" {
+			m["region"] = res.Info.Region
 		}
 
 		plan := res.Info.PlanInfo.Current.Plan
@@ -64,17 +73,46 @@ func flattenApmResources(in []*models.ApmResourceInfo, name string) []interface{
 			m["config"] = cfg
 		}
 
+		if plan.Apm != nil && plan.Apm.SystemSettings != nil && plan.Apm.SystemSettings.SecretToken != "" {
+			m["secret_token"] = plan.Apm.SystemSettings.SecretToken
+		}
+
 		result = append(result, m)
 	}
 
 	return result
 }
 
+// setApmSecretToken sets the secret token on the flattened APM resources which
+// don't have one, since the plan only contains it when it has been set
+// explicitly, and the secret token is otherwise only returned upon creation.
+func setApmSecretToken(apm []interface{}, token string) {
+	if token == "" {
+		return
+	}
+
+	for _, raw := range apm {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if t, _ := m["secret_token"].(string); t == "" {
+			m["secret_token"] = token
+		}
+	}
+}
+
 func flattenApmTopology(plan *models.ApmPlan) []interface{} {
+	var scaledToZero = isApmPlanScaledToZero(plan)
 	var result = make([]interface{}, 0, len(plan.ClusterTopology))
 	for _, topology := range plan.ClusterTopology {
 		var m = make(map[string]interface{})
-		if topology.Size == nil || topology.Size.Value == nil || *topology.Size.Value == 0 {
+		if topology.Size == nil || topology.Size.Value == nil {
+			continue
+		}
+
+		if *topology.Size.Value == 0 && !scaledToZero {
 			continue
 		}
 
@@ -83,7 +121,7 @@ func flattenApmTopology(plan *models.ApmPlan) []interface{} {
 		}
 
 		if topology.Size != nil {
-			m["size"] = util.MemoryToState(*topology.Size.Value)
+			m["size"] = util.SizeToState(*topology.Size.Value)
 			m["size_resource"] = *topology.Size.Resource
 		}
 
@@ -101,25 +139,9 @@ func flattenApmConfig(cfg *models.ApmConfiguration) []interface{} {
 		return nil
 	}
 
-	if cfg.UserSettingsYaml != "" {
-		m["user_settings_yaml"] = cfg.UserSettingsYaml
-	}
-
-	if cfg.UserSettingsOverrideYaml != "" {
-		m["user_settings_override_yaml"] = cfg.UserSettingsOverrideYaml
-	}
-
-	if o := cfg.UserSettingsJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_json"] = string(b)
-		}
-	}
-
-	if o := cfg.UserSettingsOverrideJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_override_json"] = string(b)
-		}
-	}
+	flattenUserSettings(m, cfg.UserSettingsYaml, cfg.UserSettingsOverrideYaml,
+		cfg.UserSettingsJSON, cfg.UserSettingsOverrideJSON,
+	)
 
 	if cfg.DockerImage != "" {
 		m["docker_image"] = cfg.DockerImage
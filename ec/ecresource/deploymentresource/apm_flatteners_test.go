@@ -97,6 +97,7 @@ func Test_flattenApmResource(t *testing.T) {
 				map[string]interface{}{
 					"elasticsearch_cluster_ref_id": "main-elasticsearch",
 					"ref_id":                       "main-apm",
+					"kind":                         "apm",
 					"resource_id":                  mock.ValidClusterID,
 					"region":                       "some-region",
 					"http_endpoint":                "http://apmresource.cloud.elastic.co:9200",
@@ -207,6 +208,7 @@ func Test_flattenApmResource(t *testing.T) {
 			want: []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"kind":                         "apm",
 				"resource_id":                  mock.ValidClusterID,
 				"region":                       "some-region",
 				"http_endpoint":                "http://apmresource.cloud.elastic.co:9200",
@@ -278,6 +280,7 @@ func Test_flattenApmResource(t *testing.T) {
 			want: []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"kind":                         "apm",
 				"resource_id":                  mock.ValidClusterID,
 				"region":                       "some-region",
 				"http_endpoint":                "http://apmresource.cloud.elastic.co:9200",
@@ -298,6 +301,101 @@ func Test_flattenApmResource(t *testing.T) {
 				}},
 			}},
 		},
+		{
+			name: "parses the apm resource id and region from the resource when missing from the info",
+			args: args{in: []*models.ApmResourceInfo{
+				{
+					ID:                        &mock.ValidClusterID,
+					RefID:                     ec.String("main-apm"),
+					ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+					Info: &models.ApmInfo{
+						Name:   ec.String("some-apm-name"),
+						Region: "some-region",
+						Status: ec.String("started"),
+						PlanInfo: &models.ApmPlansInfo{Current: &models.ApmPlanInfo{
+							Plan: &models.ApmPlan{
+								Apm: &models.ApmConfiguration{
+									Version: "7.7.0",
+								},
+								ClusterTopology: []*models.ApmTopologyElement{
+									{
+										ZoneCount:               1,
+										InstanceConfigurationID: "aws.apm.r4",
+										Size: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+								},
+							},
+						}},
+					},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"elasticsearch_cluster_ref_id": "main-elasticsearch",
+				"ref_id":                       "main-apm",
+				"kind":                         "apm",
+				"resource_id":                  mock.ValidClusterID,
+				"region":                       "some-region",
+				"topology": []interface{}{map[string]interface{}{
+					"instance_configuration_id": "aws.apm.r4",
+					"size":                      "1g",
+					"size_resource":             "memory",
+					"zone_count":                int32(1),
+				}},
+			}},
+		},
+		{
+			name: "parses the apm secret token from the system settings",
+			args: args{in: []*models.ApmResourceInfo{
+				{
+					Region:                    ec.String("some-region"),
+					RefID:                     ec.String("main-apm"),
+					ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+					Info: &models.ApmInfo{
+						ID:     &mock.ValidClusterID,
+						Name:   ec.String("some-apm-name"),
+						Region: "some-region",
+						Status: ec.String("started"),
+						PlanInfo: &models.ApmPlansInfo{Current: &models.ApmPlanInfo{
+							Plan: &models.ApmPlan{
+								Apm: &models.ApmConfiguration{
+									Version: "7.7.0",
+									SystemSettings: &models.ApmSystemSettings{
+										SecretToken: "some-secret-token",
+									},
+								},
+								ClusterTopology: []*models.ApmTopologyElement{
+									{
+										ZoneCount:               1,
+										InstanceConfigurationID: "aws.apm.r4",
+										Size: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+								},
+							},
+						}},
+					},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"elasticsearch_cluster_ref_id": "main-elasticsearch",
+				"ref_id":                       "main-apm",
+				"kind":                         "apm",
+				"resource_id":                  mock.ValidClusterID,
+				"region":                       "some-region",
+				"secret_token":                 "some-secret-token",
+				"topology": []interface{}{map[string]interface{}{
+					"instance_configuration_id": "aws.apm.r4",
+					"size":                      "1g",
+					"size_resource":             "memory",
+					"zone_count":                int32(1),
+				}},
+			}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -306,3 +404,90 @@ func Test_flattenApmResource(t *testing.T) {
 		})
 	}
 }
+
+func Test_setApmSecretToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		apm   []interface{}
+		token string
+		want  []interface{}
+	}{
+		{
+			name:  "sets the secret token when missing",
+			apm:   []interface{}{map[string]interface{}{"ref_id": "main-apm"}},
+			token: "some-secret-token",
+			want: []interface{}{map[string]interface{}{
+				"ref_id":       "main-apm",
+				"secret_token": "some-secret-token",
+			}},
+		},
+		{
+			name: "keeps the secret token read from the plan",
+			apm: []interface{}{map[string]interface{}{
+				"ref_id":       "main-apm",
+				"secret_token": "plan-secret-token",
+			}},
+			token: "some-secret-token",
+			want: []interface{}{map[string]interface{}{
+				"ref_id":       "main-apm",
+				"secret_token": "plan-secret-token",
+			}},
+		},
+		{
+			name: "doesn't set an empty secret token",
+			apm:  []interface{}{map[string]interface{}{"ref_id": "main-apm"}},
+			want: []interface{}{map[string]interface{}{"ref_id": "main-apm"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setApmSecretToken(tt.apm, tt.token)
+			assert.Equal(t, tt.want, tt.apm)
+		})
+	}
+}
+
+func Test_flattenApmConfig(t *testing.T) {
+	type args struct {
+		cfg *models.ApmConfiguration
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "flattens no config when empty",
+			args: args{cfg: &models.ApmConfiguration{}},
+		},
+		{
+			name: "flattens the user settings set server side",
+			args: args{cfg: &models.ApmConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON:         map[string]interface{}{"some.setting": "value"},
+				UserSettingsOverrideJSON: map[string]interface{}{},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+			}},
+		},
+		{
+			name: "flattens the custom docker image set server side",
+			args: args{cfg: &models.ApmConfiguration{
+				DockerImage: "docker.elastic.co/cloud-ci/apm:7.15.0-SNAPSHOT",
+			}},
+			want: []interface{}{map[string]interface{}{
+				"docker_image": "docker.elastic.co/cloud-ci/apm:7.15.0-SNAPSHOT",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenApmConfig(tt.args.cfg)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
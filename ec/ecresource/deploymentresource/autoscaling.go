@@ -0,0 +1,83 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+// checkAutoscaling returns an informational warning listing the Elasticsearch
+// tiers which have autoscaling policies and their bounds, for each of the
+// resources with autoscaling enabled, so that the autoscaling behaviour of the
+// deployment is clear to the user.
+func checkAutoscaling(ess []*models.ElasticsearchPayload) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, es := range ess {
+		if es == nil || es.Plan == nil || es.Plan.AutoscalingEnabled == nil || !*es.Plan.AutoscalingEnabled {
+			continue
+		}
+
+		var tiers, bounds []string
+		for _, t := range es.Plan.ClusterTopology {
+			if t == nil || (t.AutoscalingMin == nil && t.AutoscalingMax == nil) {
+				continue
+			}
+
+			tiers = append(tiers, t.ID)
+			bounds = append(bounds, fmt.Sprintf("* %s: %s", t.ID, autoscalingBounds(t)))
+		}
+
+		if len(tiers) == 0 {
+			continue
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary: fmt.Sprintf("autoscaling is enabled for the Elasticsearch tiers: %s",
+				strings.Join(tiers, ", "),
+			),
+			Detail: "The tiers are autoscaled within the following bounds:\n" +
+				strings.Join(bounds, "\n"),
+		})
+	}
+
+	return diags
+}
+
+// autoscalingBounds formats the autoscaling minimum and maximum sizes of a
+// topology element, omitting the ones which aren't set.
+func autoscalingBounds(t *models.ElasticsearchClusterTopologyElement) string {
+	var bounds []string
+	if size := t.AutoscalingMin; size != nil && size.Value != nil {
+		bounds = append(bounds, fmt.Sprintf("min %s", util.SizeToState(*size.Value)))
+	}
+	if size := t.AutoscalingMax; size != nil && size.Value != nil {
+		bounds = append(bounds, fmt.Sprintf("max %s", util.SizeToState(*size.Value)))
+	}
+	if len(bounds) == 0 {
+		return "no size bounds"
+	}
+
+	return strings.Join(bounds, ", ")
+}
@@ -0,0 +1,234 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_checkAutoscaling(t *testing.T) {
+	newSize := func(v int32) *models.TopologySize {
+		return &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(v)}
+	}
+	newPayload := func(enabled *bool) []*models.ElasticsearchPayload {
+		return []*models.ElasticsearchPayload{{
+			RefID: ec.String("main-elasticsearch"),
+			Plan: &models.ElasticsearchClusterPlan{
+				AutoscalingEnabled: enabled,
+				ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+					{
+						ID:             "hot_content",
+						Size:           newSize(8192),
+						AutoscalingMax: newSize(2097152),
+					},
+					{
+						ID:   "master",
+						Size: newSize(0),
+					},
+					{
+						ID:             "ml",
+						Size:           newSize(0),
+						AutoscalingMin: newSize(0),
+						AutoscalingMax: newSize(65536),
+					},
+					{
+						ID:             "warm",
+						Size:           newSize(4096),
+						AutoscalingMin: newSize(4096),
+						AutoscalingMax: newSize(118784),
+					},
+				},
+			},
+		}}
+	}
+	tests := []struct {
+		name string
+		ess  []*models.ElasticsearchPayload
+		want diag.Diagnostics
+	}{
+		{
+			name: "returns no diagnostics without resources",
+		},
+		{
+			name: "returns no diagnostics when autoscaling isn't set",
+			ess:  newPayload(nil),
+		},
+		{
+			name: "returns no diagnostics when autoscaling is disabled",
+			ess:  newPayload(ec.Bool(false)),
+		},
+		{
+			name: "lists the autoscaled tiers and their bounds",
+			ess:  newPayload(ec.Bool(true)),
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  "autoscaling is enabled for the Elasticsearch tiers: hot_content, ml, warm",
+				Detail: "The tiers are autoscaled within the following bounds:\n" +
+					"* hot_content: max 2t\n" +
+					"* ml: min 0g, max 64g\n" +
+					"* warm: min 4g, max 116g",
+			}},
+		},
+		{
+			name: "returns no diagnostics when no tier has autoscaling sizes",
+			ess: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(true),
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+						{ID: "hot_content", Size: newSize(8192)},
+					},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkAutoscaling(tt.ess)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Test_autoscalingTemplateMaxPlans enables autoscaling without any explicit
+// autoscaling maximum sizes, which are then taken from the deployment
+// template, and ensures that two consecutive plans after applying the
+// deployment are clean.
+func Test_autoscalingTemplateMaxPlans(t *testing.T) {
+	ioOptimizedTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-aws-io-optimized-v2.json")
+	}
+	newConfig := func(hot map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.12.0",
+			"autoscale":              true,
+			"elasticsearch": []interface{}{map[string]interface{}{
+				"topology": []interface{}{
+					map[string]interface{}{"id": "cold"},
+					hot,
+					map[string]interface{}{"id": "ml"},
+					map[string]interface{}{"id": "warm"},
+				},
+			}},
+		}
+	}
+	// apply sets the state from the applied deployment resources, as the
+	// create and update operations followed by a read do.
+	apply := func(t *testing.T, d *schema.ResourceData, resources *models.DeploymentCreateResources) {
+		t.Helper()
+		res := newRoundTripResponse(&models.DeploymentCreateRequest{
+			Resources: resources, Metadata: &models.DeploymentCreateMetadata{},
+		})
+		if err := modelToState(d, res, models.RemoteResources{}); err != nil {
+			t.Fatalf("failed flattening the deployment: %v", err)
+		}
+		if err := d.Set("resource_ids", flattenResourceIDs(res.Resources)); err != nil {
+			t.Fatal(err)
+		}
+		if err := setPlanHash(d, resources); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// plan asserts that the configuration doesn't result in any diff and
+	// that the hot tier autoscaling maximum is the deployment template one.
+	plan := func(t *testing.T, d *schema.ResourceData, config map[string]interface{}) {
+		t.Helper()
+		diff, err := Resource(nil, nil, nil).Diff(
+			context.Background(), d.State(), terraform.NewResourceConfigRaw(config), nil,
+		)
+		assert.NoError(t, err)
+		if diff != nil {
+			assert.Empty(t, diff.Attributes)
+		}
+		assert.Equal(t, "116g", d.Get("elasticsearch.0.topology.1.autoscaling.0.max_size"))
+		assert.Equal(t, "memory", d.Get("elasticsearch.0.topology.1.autoscaling.0.max_size_resource"))
+	}
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{
+			name: "without an autoscaling block",
+			config: newConfig(map[string]interface{}{
+				"id": "hot_content", "size": "8g",
+			}),
+		},
+		{
+			name: "with an autoscaling block which only sets the minimum size",
+			config: newConfig(map[string]interface{}{
+				"id": "hot_content", "size": "8g",
+				"autoscaling": []interface{}{map[string]interface{}{
+					"min_size": "8g",
+				}},
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				State:  tt.config,
+				Schema: newSchema(),
+			})
+
+			create, err := CreateResourceToModel(d, api.NewMock(mock.New200Response(ioOptimizedTpl())))
+			if err != nil {
+				t.Fatalf("failed building the create payload: %v", err)
+			}
+			apply(t, d, create.Resources)
+			plan(t, d, tt.config)
+
+			update, err := updateResourceToModel(d, api.NewMock(mock.New200Response(ioOptimizedTpl())))
+			if err != nil {
+				t.Fatalf("failed building the update payload: %v", err)
+			}
+			assert.Equal(t,
+				autoscalingMaxSizes(create.Resources.Elasticsearch),
+				autoscalingMaxSizes(update.Resources.Elasticsearch),
+			)
+			apply(t, d, &models.DeploymentCreateResources{
+				Elasticsearch: update.Resources.Elasticsearch,
+			})
+			plan(t, d, tt.config)
+		})
+	}
+}
+
+func autoscalingMaxSizes(ess []*models.ElasticsearchPayload) map[string]*models.TopologySize {
+	sizes := make(map[string]*models.TopologySize)
+	for _, es := range ess {
+		for _, topology := range es.Plan.ClusterTopology {
+			sizes[topology.ID] = topology.AutoscalingMax
+		}
+	}
+	return sizes
+}
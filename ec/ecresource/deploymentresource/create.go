@@ -33,43 +33,87 @@ func createResource(ctx context.Context, d *schema.ResourceData, meta interface{
 	client := meta.(*api.API)
 	reqID := deploymentapi.RequestID(d.Get("request_id").(string))
 
-	req, err := createResourceToModel(d, client)
+	req, err := CreateResourceToModel(d, client)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
+	if err := addTrustSelf(ctx, d, client, req.Resources.Elasticsearch); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Warnings about docker images which can't be resolved or conflict with
+	// the built-in plugins, unhealthy observability destinations, resource
+	// version skews, the autoscaled tiers or the unsupported topology sizes are
+	// returned along any other diagnostics, since these don't prevent the
+	// deployment from being created.
+	diags := checkDockerImages(ctx, d)
+	diags = append(diags, checkDockerImagePlugins(d)...)
+	diags = append(diags, checkObservabilityDestination(
+		d.Get("observability").([]interface{}), client,
+	)...)
+	if req.Resources != nil {
+		diags = append(diags, checkAutoscaling(req.Resources.Elasticsearch)...)
+		diags = append(diags, checkDiscreteSizes(d, client, req.Resources.Elasticsearch)...)
+	}
+
+	overrides, err := OverrideVersions(d, req, deploymentapi.PayloadOverrides{
+		Name:    d.Get("name").(string),
+		Version: d.Get("version").(string),
+		Region:  d.Get("region").(string),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	diags = append(diags, checkVersionSkew(d)...)
+
 	res, err := deploymentapi.Create(deploymentapi.CreateParams{
 		API:       client,
 		RequestID: reqID,
 		Request:   req,
-		Overrides: &deploymentapi.PayloadOverrides{
-			Name:    d.Get("name").(string),
-			Version: d.Get("version").(string),
-			Region:  d.Get("region").(string),
-		},
+		Overrides: overrides,
 	})
+	// The payload is dumped once the version and region overrides have been
+	// applied to it, so it's the one which has been sent.
+	dumpPayload("create", req)
 	if err != nil {
 		merr := multierror.NewPrefixed("failed creating deployment", err)
 		return diag.FromErr(merr.Append(newCreationError(reqID)))
 	}
 
-	if err := WaitForPlanCompletion(client, *res.ID); err != nil {
+	if err := waitForResources(ctx, d, client, *res.ID); err != nil {
 		merr := multierror.NewPrefixed("failed tracking create progress", err)
 		return diag.FromErr(merr.Append(newCreationError(reqID)))
 	}
 
 	d.SetId(*res.ID)
 
+	if err := setPlanHash(d, req.Resources); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// When the observability settings target the deployment itself, these
+	// can only be set once the deployment ID is known.
+	if observabilityTargetsSelf(d.Get("observability").([]interface{})) {
+		if err := updateObservabilitySelf(ctx, d, client, *res.ID); err != nil {
+			merr := multierror.NewPrefixed("failed setting the deployment observability", err)
+			return diag.FromErr(merr)
+		}
+	}
+
 	// Since before the deployment has been read, there's no real state
 	// persisted, it'd better to handle each of the errors by appending
 	// it to the `diag.Diagnostics` since it has support for it.
-	var diags diag.Diagnostics
 	if err := handleRemoteClusters(d, client); err != nil {
 		diags = append(diags, diag.FromErr(err)...)
 	}
 
+	if err := handleTrafficFilterExclusions(d, client); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+
 	if diag := readResource(ctx, d, meta); diag != nil {
-		diags = append(diags, diags...)
+		diags = append(diags, diag...)
 	}
 
 	if err := parseCredentials(d, res.Resources); err != nil {
@@ -54,7 +54,7 @@ func deleteResource(ctx context.Context, d *schema.ResourceData, meta interface{
 			))
 		}
 
-		if err := WaitForPlanCompletion(client, d.Id()); err != nil {
+		if err := WaitForPlanCompletion(ctx, client, d.Id()); err != nil {
 			if shouldRetryShutdown(err, retries, maxRetries) {
 				retries++
 				return resource.RetryableError(err)
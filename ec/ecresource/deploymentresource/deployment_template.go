@@ -0,0 +1,98 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/deptemplateapi"
+	"github.com/elastic/cloud-sdk-go/pkg/client/deployment_templates"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+)
+
+// maxTemplateAlternatives is the maximum number of deployment template IDs
+// which are suggested when the configured one doesn't exist in the region.
+const maxTemplateAlternatives = 5
+
+// getDeploymentTemplate obtains the deployment template from the region. When
+// the template doesn't exist in the region, such as templates which are only
+// valid in other regions, the returned error lists some of the templates
+// which are available in the region instead of the raw API error.
+// The template instance configurations are obtained as well, since their
+// discrete sizes are used to validate the topology sizes.
+func getDeploymentTemplate(client *api.API, id, region string) (*models.DeploymentTemplateInfoV2, error) {
+	template, err := deptemplateapi.Get(deptemplateapi.GetParams{
+		API:        client,
+		TemplateID: id,
+		Region:     region,
+	})
+	if err == nil {
+		return template, nil
+	}
+
+	var notFound *deployment_templates.GetDeploymentTemplateV2NotFound
+	if !errors.As(err, &notFound) {
+		return nil, err
+	}
+
+	templates, listErr := deptemplateapi.List(deptemplateapi.ListParams{
+		API:                        client,
+		Region:                     region,
+		HideInstanceConfigurations: true,
+	})
+	if listErr != nil {
+		return nil, err
+	}
+
+	return nil, newTemplateNotFoundError(id, region, templates)
+}
+
+// newTemplateNotFoundError returns an error for a deployment template which
+// doesn't exist in the region, suggesting up to maxTemplateAlternatives of
+// the templates which do.
+func newTemplateNotFoundError(id, region string, templates []*models.DeploymentTemplateInfoV2) error {
+	ids := make([]string, 0, len(templates))
+	for _, tpl := range templates {
+		if tpl != nil && tpl.ID != nil {
+			ids = append(ids, fmt.Sprintf(`"%s"`, *tpl.ID))
+		}
+	}
+
+	if len(ids) == 0 {
+		return fmt.Errorf(
+			`deployment template "%s" doesn't exist in region "%s", which has no deployment templates available`,
+			id, region,
+		)
+	}
+
+	sort.Strings(ids)
+	var more string
+	if len(ids) > maxTemplateAlternatives {
+		more = fmt.Sprintf(" (and %d more)", len(ids)-maxTemplateAlternatives)
+		ids = ids[:maxTemplateAlternatives]
+	}
+
+	return fmt.Errorf(
+		`deployment template "%s" doesn't exist in region "%s", the available deployment templates include %s%s`,
+		id, region, strings.Join(ids, ", "), more,
+	)
+}
@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getDeploymentTemplate(t *testing.T) {
+	notFound := func() mock.Response {
+		return mock.NewErrorResponse(404, mock.APIError{
+			Code: "deployments.deployment_template_not_found", Message: "not found",
+		})
+	}
+	templates := func(ids ...string) []*models.DeploymentTemplateInfoV2 {
+		result := make([]*models.DeploymentTemplateInfoV2, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, &models.DeploymentTemplateInfoV2{ID: ec.String(id)})
+		}
+		return result
+	}
+	tests := []struct {
+		name   string
+		client *api.API
+		want   *models.DeploymentTemplateInfoV2
+		err    error
+	}{
+		{
+			name: "returns the deployment template",
+			client: api.NewMock(mock.New200StructResponse(
+				models.DeploymentTemplateInfoV2{ID: ec.String("aws-io-optimized-v2")},
+			)),
+			want: &models.DeploymentTemplateInfoV2{ID: ec.String("aws-io-optimized-v2")},
+		},
+		{
+			name: "lists the available deployment templates when it doesn't exist in the region",
+			client: api.NewMock(notFound(), mock.New200StructResponse(templates(
+				"gcp-io-optimized", "gcp-compute-optimized", "gcp-cross-cluster-search",
+			))),
+			err: errors.New(`deployment template "aws-io-optimized-v2" doesn't exist in region "gcp-us-central1", the available deployment templates include "gcp-compute-optimized", "gcp-cross-cluster-search", "gcp-io-optimized"`),
+		},
+		{
+			name: "lists only a few of the available deployment templates",
+			client: api.NewMock(notFound(), mock.New200StructResponse(templates(
+				"gcp-a", "gcp-b", "gcp-c", "gcp-d", "gcp-e", "gcp-f", "gcp-g",
+			))),
+			err: errors.New(`deployment template "aws-io-optimized-v2" doesn't exist in region "gcp-us-central1", the available deployment templates include "gcp-a", "gcp-b", "gcp-c", "gcp-d", "gcp-e" (and 2 more)`),
+		},
+		{
+			name:   "returns the original error when the templates can't be listed",
+			client: api.NewMock(notFound(), mock.NewErrorResponse(500, mock.APIError{Code: "some", Message: "message"})),
+			err:    errors.New("api error: 1 error occurred:\n\t* deployments.deployment_template_not_found: not found\n\n"),
+		},
+		{
+			name:   "returns any other error",
+			client: api.NewMock(mock.NewErrorResponse(500, mock.APIError{Code: "some", Message: "message"})),
+			err:    errors.New("api error: 1 error occurred:\n\t* some: message\n\n"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDeploymentTemplate(tt.client, "aws-io-optimized-v2", "gcp-us-central1")
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type contextFunc = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// withDeploymentContext wraps a CRUD function so that the summary of any of
+// its error diagnostics is prefixed with the deployment ID, template ID and
+// region, which makes failures of runs managing multiple deployments easier
+// to tell apart. Warnings are returned untouched.
+func withDeploymentContext(f contextFunc) contextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		diags := f(ctx, d, meta)
+		if !diags.HasError() {
+			return diags
+		}
+
+		prefix := deploymentContext(d)
+		for i := range diags {
+			if diags[i].Severity == diag.Error {
+				diags[i].Summary = fmt.Sprintf("%s: %s", prefix, diags[i].Summary)
+			}
+		}
+		return diags
+	}
+}
+
+// deploymentContext returns a short description of the deployment, made of
+// the set deployment ID, template ID and region.
+func deploymentContext(d *schema.ResourceData) string {
+	var attrs []string
+	if tplID, _ := d.Get("deployment_template_id").(string); tplID != "" {
+		attrs = append(attrs, fmt.Sprintf("deployment_template_id %s", tplID))
+	}
+	if region, _ := d.Get("region").(string); region != "" {
+		attrs = append(attrs, fmt.Sprintf("region %s", region))
+	}
+
+	description := "deployment"
+	if id := d.Id(); id != "" {
+		description = fmt.Sprintf("deployment %s", id)
+	}
+	if len(attrs) == 0 {
+		return description
+	}
+
+	return fmt.Sprintf("%s (%s)", description, strings.Join(attrs, ", "))
+}
@@ -0,0 +1,94 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_withDeploymentContext(t *testing.T) {
+	newRD := func(id string) *schema.ResourceData {
+		d := util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State: map[string]interface{}{
+				"deployment_template_id": "aws-io-optimized-v2",
+				"region":                 "us-east-1",
+			},
+		})
+		d.SetId(id)
+		return d
+	}
+	tests := []struct {
+		name  string
+		d     *schema.ResourceData
+		diags diag.Diagnostics
+		want  diag.Diagnostics
+	}{
+		{
+			name: "returns no diagnostics untouched",
+			d:    newRD(mock.ValidClusterID),
+		},
+		{
+			name:  "returns warnings untouched",
+			d:     newRD(mock.ValidClusterID),
+			diags: diag.Diagnostics{{Severity: diag.Warning, Summary: "some warning"}},
+			want:  diag.Diagnostics{{Severity: diag.Warning, Summary: "some warning"}},
+		},
+		{
+			name: "prefixes the errors with the deployment context",
+			d:    newRD(mock.ValidClusterID),
+			diags: diag.Diagnostics{
+				{Severity: diag.Warning, Summary: "some warning"},
+				{Severity: diag.Error, Summary: "failed updating deployment"},
+			},
+			want: diag.Diagnostics{
+				{Severity: diag.Warning, Summary: "some warning"},
+				{
+					Severity: diag.Error,
+					Summary:  "deployment " + mock.ValidClusterID + " (deployment_template_id aws-io-optimized-v2, region us-east-1): failed updating deployment",
+				},
+			},
+		},
+		{
+			name:  "prefixes the errors without a deployment id",
+			d:     newRD(""),
+			diags: diag.Diagnostics{{Severity: diag.Error, Summary: "failed creating deployment"}},
+			want: diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "deployment (deployment_template_id aws-io-optimized-v2, region us-east-1): failed creating deployment",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := withDeploymentContext(func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+				return tt.diags
+			})
+			assert.Equal(t, tt.want, f(context.Background(), tt.d, nil))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+// checkDiscreteSizes returns a warning for each Elasticsearch topology
+// element whose size isn't one of the discrete sizes of its instance
+// configuration, since the API rounds or rejects any other size. Failures to
+// obtain the deployment template are only logged, since the sizes are
+// validated by the API as well.
+func checkDiscreteSizes(d *schema.ResourceData, client *api.API, ess []*models.ElasticsearchPayload) diag.Diagnostics {
+	tplID := d.Get("deployment_template_id").(string)
+	if tplID == "" || len(ess) == 0 {
+		return nil
+	}
+
+	template, err := getDeploymentTemplate(client, tplID, d.Get("region").(string))
+	if err != nil {
+		log.Printf("[DEBUG] skipping the topology size check: %s", err)
+		return nil
+	}
+
+	return discreteSizesDiagnostics(ess, template.InstanceConfigurations)
+}
+
+// discreteSizesDiagnostics validates the size of the Elasticsearch topology
+// elements against the discrete sizes of their instance configuration,
+// reporting the nearest valid sizes for each invalid size. Topology elements
+// whose instance configuration isn't part of the deployment template, or
+// which is sized in a different resource, aren't validated.
+func discreteSizesDiagnostics(ess []*models.ElasticsearchPayload, ics []*models.InstanceConfigurationInfo) diag.Diagnostics {
+	var sizes = make(map[string]*models.DiscreteSizes, len(ics))
+	for _, ic := range ics {
+		if ic != nil && ic.DiscreteSizes != nil && len(ic.DiscreteSizes.Sizes) > 0 {
+			sizes[ic.ID] = ic.DiscreteSizes
+		}
+	}
+
+	var diags diag.Diagnostics
+	for _, es := range ess {
+		if es == nil || es.Plan == nil {
+			continue
+		}
+
+		for _, t := range es.Plan.ClusterTopology {
+			if t == nil || t.Size == nil || t.Size.Value == nil || *t.Size.Value <= 0 {
+				continue
+			}
+
+			discrete, ok := sizes[t.InstanceConfigurationID]
+			if !ok || !sameSizeResource(t.Size, discrete) {
+				continue
+			}
+
+			nearest := nearestDiscreteSizes(*t.Size.Value, discrete.Sizes)
+			if len(nearest) == 0 {
+				continue
+			}
+
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary: fmt.Sprintf(
+					`elasticsearch topology %s size "%s" isn't supported by the "%s" instance configuration`,
+					t.ID, util.SizeToState(*t.Size.Value), t.InstanceConfigurationID,
+				),
+				Detail: fmt.Sprintf(
+					"The size may be rounded or rejected by the API. The nearest valid sizes are: %s.",
+					strings.Join(nearest, ", "),
+				),
+			})
+		}
+	}
+
+	return diags
+}
+
+// sameSizeResource returns true when the size and the discrete sizes are
+// expressed in the same resource. An unset resource defaults to "memory".
+func sameSizeResource(size *models.TopologySize, discrete *models.DiscreteSizes) bool {
+	var sizeResource, discreteResource = "memory", "memory"
+	if size.Resource != nil && *size.Resource != "" {
+		sizeResource = *size.Resource
+	}
+	if discrete.Resource != nil && *discrete.Resource != "" {
+		discreteResource = *discrete.Resource
+	}
+	return sizeResource == discreteResource
+}
+
+// nearestDiscreteSizes returns the nearest discrete sizes below and above the
+// size, or nothing when the size is one of the discrete sizes.
+func nearestDiscreteSizes(size int32, discrete []int32) []string {
+	var valid = make([]int32, len(discrete))
+	copy(valid, discrete)
+	sort.Slice(valid, func(i, j int) bool { return valid[i] < valid[j] })
+
+	i := sort.Search(len(valid), func(i int) bool { return valid[i] >= size })
+	if i < len(valid) && valid[i] == size {
+		return nil
+	}
+
+	var nearest []string
+	if i > 0 {
+		nearest = append(nearest, fmt.Sprintf(`"%s"`, util.SizeToState(valid[i-1])))
+	}
+	if i < len(valid) {
+		nearest = append(nearest, fmt.Sprintf(`"%s"`, util.SizeToState(valid[i])))
+	}
+
+	return nearest
+}
@@ -0,0 +1,102 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_discreteSizesDiagnostics(t *testing.T) {
+	tpl := parseDeploymentTemplate(t, "testdata/template-aws-io-optimized-v2.json")
+	newEs := func(topology ...*models.ElasticsearchClusterTopologyElement) []*models.ElasticsearchPayload {
+		return []*models.ElasticsearchPayload{{
+			Plan: &models.ElasticsearchClusterPlan{ClusterTopology: topology},
+		}}
+	}
+	newTopology := func(id, ic string, size int32, resource string) *models.ElasticsearchClusterTopologyElement {
+		return &models.ElasticsearchClusterTopologyElement{
+			ID:                      id,
+			InstanceConfigurationID: ic,
+			Size: &models.TopologySize{
+				Value:    ec.Int32(size),
+				Resource: ec.String(resource),
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		ess  []*models.ElasticsearchPayload
+		want diag.Diagnostics
+	}{
+		{
+			name: "returns nothing for the discrete sizes and the disabled topology elements",
+			ess: newEs(
+				newTopology("hot_content", "aws.data.highio.i3", 8192, "memory"),
+				newTopology("warm", "aws.data.highstorage.d3", 0, "memory"),
+			),
+		},
+		{
+			name: "returns the nearest valid sizes of an unsupported size",
+			ess: newEs(
+				newTopology("hot_content", "aws.data.highio.i3", 16384, "memory"),
+			),
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `elasticsearch topology hot_content size "16g" isn't supported by the "aws.data.highio.i3" instance configuration`,
+				Detail:   `The size may be rounded or rejected by the API. The nearest valid sizes are: "15g", "29g".`,
+			}},
+		},
+		{
+			name: "returns the nearest valid size of a size outside of the discrete sizes bounds",
+			ess: newEs(
+				newTopology("master", "aws.master.r5d", 32768, "memory"),
+				newTopology("warm", "aws.data.highstorage.d3", 1024, "memory"),
+			),
+			want: diag.Diagnostics{
+				{
+					Severity: diag.Warning,
+					Summary:  `elasticsearch topology master size "32g" isn't supported by the "aws.master.r5d" instance configuration`,
+					Detail:   `The size may be rounded or rejected by the API. The nearest valid sizes are: "15g".`,
+				},
+				{
+					Severity: diag.Warning,
+					Summary:  `elasticsearch topology warm size "1g" isn't supported by the "aws.data.highstorage.d3" instance configuration`,
+					Detail:   `The size may be rounded or rejected by the API. The nearest valid sizes are: "2g".`,
+				},
+			},
+		},
+		{
+			name: "skips the instance configurations which aren't part of the template and other size resources",
+			ess: newEs(
+				newTopology("hot_content", "aws.data.highio.i3en", 16384, "memory"),
+				newTopology("warm", "aws.data.highstorage.d3", 16384, "storage"),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := discreteSizesDiagnostics(tt.ess, tpl.InstanceConfigurations)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
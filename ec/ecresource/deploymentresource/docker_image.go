@@ -0,0 +1,316 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+const defaultDockerImageCheckTimeout = 5 * time.Second
+
+// manifestMediaTypes are sent as the accepted manifest types, since some
+// registries respond with a 404 when the schema version can't be served.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// dockerImageChecker verifies that the docker image tags set in the resources
+// config blocks can be resolved by their registry.
+type dockerImageChecker struct {
+	client *http.Client
+	scheme string
+}
+
+var defaultDockerImageChecker = dockerImageChecker{
+	client: &http.Client{Timeout: defaultDockerImageCheckTimeout},
+	scheme: "https",
+}
+
+// checkDockerImages returns a warning for each of the "config.docker_image"
+// settings which can't be found in their registry. The check is only done
+// when "verify_docker_images" is set.
+func checkDockerImages(ctx context.Context, d *schema.ResourceData) diag.Diagnostics {
+	if !d.Get("verify_docker_images").(bool) {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+	for _, image := range getDockerImages(d) {
+		diags = append(diags, defaultDockerImageChecker.check(ctx, image)...)
+	}
+
+	return diags
+}
+
+// checkDockerImagePlugins returns a warning when the Elasticsearch config sets
+// both a custom docker image and built-in plugins, since the plugins bundled in
+// the image may conflict with the enabled built-in ones.
+func checkDockerImagePlugins(d *schema.ResourceData) diag.Diagnostics {
+	resources, _ := d.Get("elasticsearch").([]interface{})
+	for _, raw := range resources {
+		res, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		cfgs, _ := res["config"].([]interface{})
+		for _, rawCfg := range cfgs {
+			cfg, ok := rawCfg.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			image, _ := cfg["docker_image"].(string)
+			plugins, _ := cfg["plugins"].(*schema.Set)
+			if image == "" || plugins == nil || plugins.Len() == 0 {
+				continue
+			}
+
+			names := util.ItemsToString(plugins.List())
+			sort.Strings(names)
+			return diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf(`elasticsearch docker_image "%s" is set along with built-in plugins`, image),
+				Detail: fmt.Sprintf(
+					"The plugins bundled in the custom image may conflict with the enabled built-in plugins (%s), which can cause the plan to fail.",
+					strings.Join(names, ", "),
+				),
+			}}
+		}
+	}
+
+	return nil
+}
+
+// getDockerImages returns the docker images set in any of the resources'
+// config blocks.
+func getDockerImages(d *schema.ResourceData) []string {
+	var images []string
+	for _, kind := range resourceKinds {
+		resources, _ := d.Get(kind).([]interface{})
+		for _, raw := range resources {
+			res, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			cfgs, _ := res["config"].([]interface{})
+			for _, rawCfg := range cfgs {
+				cfg, ok := rawCfg.(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				if image, _ := cfg["docker_image"].(string); image != "" {
+					images = append(images, image)
+				}
+			}
+		}
+	}
+	return images
+}
+
+// check issues a HEAD request to the image manifest in its registry. Only
+// images with an explicit registry are checked, and any errors reaching the
+// registry are ignored since the check is a best effort. Registries which
+// respond with a bearer token challenge, such as docker.elastic.co and Docker
+// Hub, are retried with an anonymous pull token. A warning is only returned
+// when the registry responds that the manifest doesn't exist, so images which
+// require credentials are never reported.
+func (c dockerImageChecker) check(ctx context.Context, image string) diag.Diagnostics {
+	url, ok := dockerImageManifestURL(c.scheme, image)
+	if !ok {
+		return nil
+	}
+
+	status, challenge, err := c.head(ctx, url, "")
+	if err != nil {
+		return nil
+	}
+
+	if status == http.StatusUnauthorized {
+		token, ok := c.token(ctx, challenge)
+		if !ok {
+			return nil
+		}
+
+		if status, _, err = c.head(ctx, url, token); err != nil {
+			return nil
+		}
+	}
+
+	if status != http.StatusNotFound {
+		return nil
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf(`docker image "%s" not found`, image),
+		Detail: fmt.Sprintf(
+			"The registry responded with %d for %s, the deployment changes may fail to apply.",
+			status, url,
+		),
+	}}
+}
+
+// head issues a HEAD request to the manifest URL, with the bearer token when
+// set, and returns the response status and authentication challenge.
+func (c dockerImageChecker) head(ctx context.Context, url, token string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+
+	return res.StatusCode, res.Header.Get("WWW-Authenticate"), nil
+}
+
+// challengeParams matches the key="value" parameters of an authentication
+// challenge.
+var challengeParams = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// token obtains an anonymous token from the realm of a bearer challenge, such
+// as `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+// It returns false when the challenge isn't a bearer one or the token can't be
+// obtained.
+func (c dockerImageChecker) token(ctx context.Context, challenge string) (string, bool) {
+	const bearer = "bearer "
+	if len(challenge) < len(bearer) || !strings.EqualFold(challenge[:len(bearer)], bearer) {
+		return "", false
+	}
+
+	params := make(url.Values)
+	var realm string
+	for _, match := range challengeParams.FindAllStringSubmatch(challenge[len(bearer):], -1) {
+		if match[1] == "realm" {
+			realm = match[2]
+			continue
+		}
+		params.Set(match[1], match[2])
+	}
+
+	realmURL, err := url.Parse(realm)
+	if err != nil || realm == "" {
+		return "", false
+	}
+	realmURL.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realmURL.String(), nil)
+	if err != nil {
+		return "", false
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", false
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", false
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", false
+	}
+
+	if body.Token != "" {
+		return body.Token, true
+	}
+	return body.AccessToken, body.AccessToken != ""
+}
+
+// dockerImageManifestURL returns the registry manifest URL for the image. It
+// returns false when the image doesn't specify an explicit registry.
+func dockerImageManifestURL(scheme, image string) (string, bool) {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	registry, repository := parts[0], parts[1]
+	if !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		return "", false
+	}
+
+	reference := "latest"
+	if i := strings.LastIndex(repository, "@"); i > 0 {
+		repository, reference = repository[:i], repository[i+1:]
+	} else if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, reference = repository[:i], repository[i+1:]
+	}
+
+	if repository == "" || reference == "" {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, registry, repository, reference), true
+}
+
+// suppressEquivalentDockerImage suppresses the diff of docker images which
+// only differ in the case of their registry host, or in trailing slashes.
+func suppressEquivalentDockerImage(k, old, new string, d *schema.ResourceData) bool {
+	return normalizeDockerImage(old) == normalizeDockerImage(new)
+}
+
+// normalizeDockerImage lowercases the explicit registry host of the image,
+// which is case-insensitive, and removes the trailing slashes of the host and
+// of the image itself.
+func normalizeDockerImage(image string) string {
+	image = strings.TrimRight(image, "/")
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) != 2 {
+		return image
+	}
+
+	registry, repository := parts[0], strings.TrimLeft(parts[1], "/")
+	if !strings.ContainsAny(registry, ".:") && !strings.EqualFold(registry, "localhost") {
+		return image
+	}
+
+	return strings.ToLower(registry) + "/" + repository
+}
@@ -0,0 +1,273 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_dockerImageManifestURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  string
+		ok    bool
+	}{
+		{
+			name:  "image without an explicit registry isn't checked",
+			image: "elasticsearch:7.17.0",
+		},
+		{
+			name:  "image with an organization but no registry isn't checked",
+			image: "elastic/elasticsearch:7.17.0",
+		},
+		{
+			name:  "image with a registry and tag",
+			image: "docker.elastic.co/cloud-ci/elasticsearch:7.17.0-SNAPSHOT",
+			want:  "https://docker.elastic.co/v2/cloud-ci/elasticsearch/manifests/7.17.0-SNAPSHOT",
+			ok:    true,
+		},
+		{
+			name:  "image with a registry port and no tag defaults to latest",
+			image: "localhost:5000/elasticsearch",
+			want:  "https://localhost:5000/v2/elasticsearch/manifests/latest",
+			ok:    true,
+		},
+		{
+			name:  "image with a registry and digest",
+			image: "docker.elastic.co/elasticsearch/elasticsearch@sha256:abcdef",
+			want:  "https://docker.elastic.co/v2/elasticsearch/elasticsearch/manifests/sha256:abcdef",
+			ok:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := dockerImageManifestURL("https", tt.image)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_dockerImageChecker_check(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:elastic/elasticsearch:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "anonymous-token"}`)
+		case strings.HasPrefix(r.URL.Path, "/v2/elastic/") && r.Header.Get("Authorization") != "Bearer anonymous-token":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="http://%s/token",service="registry",scope="repository:elastic/elasticsearch:pull"`, r.Host,
+			))
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasPrefix(r.URL.Path, "/v2/private/"):
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="http://%s/token",service="registry",scope="repository:private/elasticsearch:pull"`, r.Host,
+			))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/cloud-ci/elasticsearch/manifests/7.17.0-private":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/cloud-ci/elasticsearch/manifests/7.17.0",
+			r.URL.Path == "/v2/elastic/elasticsearch/manifests/7.17.0":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	registry := strings.TrimPrefix(srv.URL, "http://")
+	checker := dockerImageChecker{client: srv.Client(), scheme: "http"}
+
+	tests := []struct {
+		name  string
+		image string
+		want  diag.Diagnostics
+	}{
+		{
+			name:  "existing tag returns no warnings",
+			image: registry + "/cloud-ci/elasticsearch:7.17.0",
+		},
+		{
+			name:  "unauthorized registry returns no warnings",
+			image: registry + "/cloud-ci/elasticsearch:7.17.0-private",
+		},
+		{
+			name:  "existing tag behind a bearer token challenge returns no warnings",
+			image: registry + "/elastic/elasticsearch:7.17.0",
+		},
+		{
+			name:  "missing tag behind a bearer token challenge returns a warning",
+			image: registry + "/elastic/elasticsearch:7.17.99",
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf(`docker image "%s/elastic/elasticsearch:7.17.99" not found`, registry),
+				Detail: fmt.Sprintf(
+					"The registry responded with 404 for %s/v2/elastic/elasticsearch/manifests/7.17.99, the deployment changes may fail to apply.",
+					srv.URL,
+				),
+			}},
+		},
+		{
+			name:  "image which requires credentials returns no warnings",
+			image: registry + "/private/elasticsearch:7.17.99",
+		},
+		{
+			name:  "image without an explicit registry returns no warnings",
+			image: "elasticsearch:7.17.0",
+		},
+		{
+			name:  "missing tag returns a warning",
+			image: registry + "/cloud-ci/elasticsearch:7.17.99",
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf(`docker image "%s/cloud-ci/elasticsearch:7.17.99" not found`, registry),
+				Detail: fmt.Sprintf(
+					"The registry responded with 404 for %s/v2/cloud-ci/elasticsearch/manifests/7.17.99, the deployment changes may fail to apply.",
+					srv.URL,
+				),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checker.check(context.Background(), tt.image)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_checkDockerImagePlugins(t *testing.T) {
+	newDeployment := func(cfg map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.17.0",
+			"elasticsearch": []interface{}{map[string]interface{}{
+				"config": []interface{}{cfg},
+			}},
+		}
+	}
+	tests := []struct {
+		name string
+		cfg  map[string]interface{}
+		want diag.Diagnostics
+	}{
+		{
+			name: "no warnings with only a custom docker image",
+			cfg: map[string]interface{}{
+				"docker_image": "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+			},
+		},
+		{
+			name: "no warnings with only built-in plugins",
+			cfg: map[string]interface{}{
+				"plugins": []interface{}{"analysis-icu"},
+			},
+		},
+		{
+			name: "warns when a custom docker image is set along with built-in plugins",
+			cfg: map[string]interface{}{
+				"docker_image": "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+				"plugins":      []interface{}{"repository-s3", "analysis-icu"},
+			},
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `elasticsearch docker_image "docker.elastic.co/cloud-ci/elasticsearch:7.17.0" is set along with built-in plugins`,
+				Detail:   "The plugins bundled in the custom image may conflict with the enabled built-in plugins (analysis-icu, repository-s3), which can cause the plan to fail.",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				State:  newDeployment(tt.cfg),
+				Schema: newSchema(),
+			})
+			got := checkDockerImagePlugins(d)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_suppressEquivalentDockerImage(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want bool
+	}{
+		{
+			name: "suppresses registry hosts which differ in case",
+			old:  "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+			new:  "Docker.Elastic.CO/cloud-ci/elasticsearch:7.17.0",
+			want: true,
+		},
+		{
+			name: "suppresses registry hosts with a trailing slash",
+			old:  "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+			new:  "docker.elastic.co//cloud-ci/elasticsearch:7.17.0",
+			want: true,
+		},
+		{
+			name: "suppresses images with a trailing slash",
+			old:  "localhost:5000/elasticsearch:7.17.0",
+			new:  "LOCALHOST:5000/elasticsearch:7.17.0/",
+			want: true,
+		},
+		{
+			name: "doesn't suppress repositories which differ in case",
+			old:  "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+			new:  "docker.elastic.co/Cloud-CI/elasticsearch:7.17.0",
+			want: false,
+		},
+		{
+			name: "doesn't suppress different tags",
+			old:  "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+			new:  "docker.elastic.co/cloud-ci/elasticsearch:7.17.1",
+			want: false,
+		},
+		{
+			name: "doesn't suppress removing the image",
+			old:  "docker.elastic.co/cloud-ci/elasticsearch:7.17.0",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suppressEquivalentDockerImage("", tt.old, tt.new, nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -19,13 +19,14 @@ package deploymentresource
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 
-	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/deploymentsize"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
@@ -40,15 +41,68 @@ const (
 	masterDataTierRole = "master"
 )
 
+// These are the user settings overrides which the typed Elasticsearch
+// security settings are expanded into.
+const (
+	anonymousRolesSetting    = "xpack.security.authc.anonymous.roles"
+	anonymousUsernameSetting = "xpack.security.authc.anonymous.username"
+)
+
+// dedicatedMastersTier is the ID of the dedicated master tier, which is
+// added by the "dedicated_masters" setting.
+const dedicatedMastersTier = "master"
+
+// defaultDedicatedMastersSize is the size of the dedicated master tier added
+// by the "dedicated_masters" setting when the deployment template doesn't
+// size it, which matches the default size of the master instance
+// configurations.
+const defaultDedicatedMastersSize = 1024
+
+// remoteClusterClientRole is the node role which is stripped from all the
+// topology elements when "include_remote_cluster_client" is false.
+const remoteClusterClientRole = "remote_cluster_client"
+
+// tierNodeRoles are the node roles of the single purpose tiers, which are set
+// on the topology elements of these tiers when the deployment template doesn't
+// set any node_roles for them.
+var tierNodeRoles = map[string][]string{
+	"ml":        {"ml", remoteClusterClientRole},
+	"transform": {"transform", remoteClusterClientRole},
+}
+
+// snapshotRestoreStrategies are the accepted values for the
+// "snapshot_source.strategy" setting.
+var snapshotRestoreStrategies = []string{
+	models.RestoreSnapshotConfigurationStrategyFull,
+	models.RestoreSnapshotConfigurationStrategyPartial,
+	models.RestoreSnapshotConfigurationStrategyRecovery,
+}
+
+// planStrategies are the accepted values for the "plan_strategy" setting.
+var planStrategies = []string{
+	"autodetect", "grow_and_shrink", "rolling", "rolling_grow_and_shrink",
+}
+
 // expandEsResources expands Elasticsearch resources
 func expandEsResources(ess []interface{}, tpl *models.ElasticsearchPayload) ([]*models.ElasticsearchPayload, error) {
 	if len(ess) == 0 {
 		return nil, nil
 	}
 
+	// Each resource is expanded from its own copy of the template, since the
+	// template payload is modified in place by expandEsResource.
+	tpls := []*models.ElasticsearchPayload{tpl}
+	for range ess[1:] {
+		c, err := copyEsPayload(tpl)
+		if err != nil {
+			return nil, err
+		}
+		tpls = append(tpls, c)
+	}
+
 	result := make([]*models.ElasticsearchPayload, 0, len(ess))
-	for _, raw := range ess {
-		resResource, err := expandEsResource(raw, tpl)
+	for i, raw := range ess {
+		resResource, err := expandEsResource(raw, tpls[i])
 		if err != nil {
 			return nil, err
 		}
@@ -58,6 +112,24 @@ func expandEsResources(ess []interface{}, tpl *models.ElasticsearchPayload) ([]*
 	return result, nil
 }
 
+// copyEsPayload returns a deep copy of the Elasticsearch payload.
+func copyEsPayload(in *models.ElasticsearchPayload) (*models.ElasticsearchPayload, error) {
+	if in == nil {
+		return nil, nil
+	}
+
+	b, err := in.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed copying elasticsearch template: %w", err)
+	}
+
+	var out models.ElasticsearchPayload
+	if err := out.UnmarshalBinary(b); err != nil {
+		return nil, fmt.Errorf("failed copying elasticsearch template: %w", err)
+	}
+	return &out, nil
+}
+
 // expandEsResource expands a single Elasticsearch resource
 func expandEsResource(raw interface{}, res *models.ElasticsearchPayload) (*models.ElasticsearchPayload, error) {
 	es := raw.(map[string]interface{})
@@ -84,10 +156,24 @@ func expandEsResource(raw interface{}, res *models.ElasticsearchPayload) (*model
 		res.Plan.ClusterTopology = topology
 	}
 
+	if dedicated, ok := es["dedicated_masters"].(bool); ok && dedicated {
+		if err := expandDedicatedMasters(res.Plan.ClusterTopology); err != nil {
+			return nil, err
+		}
+	}
+
 	// Fixes the node_roles field to remove the dedicated tier roles from the
 	// list when these are set as a dedicated tier as a topology element.
 	updateNodeRolesOnDedicatedTiers(res.Plan.ClusterTopology)
 
+	if include, ok := es["include_remote_cluster_client"].(bool); ok && !include {
+		for _, topology := range res.Plan.ClusterTopology {
+			topology.NodeRoles = removeItemFromSlice(
+				topology.NodeRoles, remoteClusterClientRole,
+			)
+		}
+	}
+
 	if cfg, ok := es["config"]; ok {
 		if err := expandEsConfig(cfg, res.Plan.Elasticsearch); err != nil {
 			return nil, err
@@ -117,6 +203,15 @@ func expandEsResource(raw interface{}, res *models.ElasticsearchPayload) (*model
 		}
 	}
 
+	if threshold, ok := es["dedicated_masters_threshold"]; ok {
+		if t := threshold.(int); t > 0 {
+			if res.Settings == nil {
+				res.Settings = &models.ElasticsearchClusterSettings{}
+			}
+			res.Settings.DedicatedMastersThreshold = int32(t)
+		}
+	}
+
 	if trust, ok := es["trust_account"]; ok {
 		if t := trust.(*schema.Set); t.Len() > 0 {
 			if res.Settings == nil {
@@ -161,6 +256,12 @@ func expandEsTopology(raw interface{}, topologies []*models.ElasticsearchCluster
 			return nil, fmt.Errorf("elasticsearch topology %s: %w", topologyID, err)
 		}
 		if size != nil {
+			if sr, _ := topology["size_resource"].(string); sr == "" {
+				size.Resource = nil
+			}
+			if err := matchSizeResource(size, elem.Size); err != nil {
+				return nil, fmt.Errorf("elasticsearch topology %s: %w", topologyID, err)
+			}
 			elem.Size = size
 		}
 
@@ -181,10 +282,24 @@ func expandEsTopology(raw interface{}, topologies []*models.ElasticsearchCluster
 			}
 		}
 
+		if attrs, ok := topology["node_attributes"].(map[string]interface{}); ok {
+			expandNodeAttributes(attrs, elem)
+		}
+
 		if autoscalingRaw := topology["autoscaling"]; autoscalingRaw != nil {
+			// The coordinating tier can only be autoscaled when the deployment
+			// template declares its autoscaling limits.
+			var tplAutoscaling = elem.AutoscalingMax != nil || elem.AutoscalingMin != nil
 			for _, autoscaleRaw := range autoscalingRaw.([]interface{}) {
 				autoscale := autoscaleRaw.(map[string]interface{})
 
+				if topologyID == "coordinating" && !tplAutoscaling && hasAutoscalingSize(autoscale) {
+					return nil, fmt.Errorf(
+						"elasticsearch topology %s: autoscaling is not supported by the deployment template",
+						topologyID,
+					)
+				}
+
 				if elem.AutoscalingMax == nil {
 					elem.AutoscalingMax = new(models.TopologySize)
 				}
@@ -223,6 +338,12 @@ func expandEsTopology(raw interface{}, topologies []*models.ElasticsearchCluster
 						}
 					}
 				}
+
+				if disabled, _ := autoscale["disabled"].(bool); disabled {
+					if err := disableTopologyAutoscaling(elem); err != nil {
+						return nil, fmt.Errorf("elasticsearch topology %s: %w", topologyID, err)
+					}
+				}
 			}
 		}
 
@@ -239,6 +360,134 @@ func expandEsTopology(raw interface{}, topologies []*models.ElasticsearchCluster
 	return res, nil
 }
 
+// expandNodeAttributes merges the configured node attributes into the ones
+// declared in the deployment template, overriding any attribute with the
+// same key.
+func expandNodeAttributes(attrs map[string]interface{}, elem *models.ElasticsearchClusterTopologyElement) {
+	if len(attrs) == 0 {
+		return
+	}
+
+	if elem.Elasticsearch == nil {
+		elem.Elasticsearch = &models.ElasticsearchConfiguration{}
+	}
+
+	if elem.Elasticsearch.NodeAttributes == nil {
+		elem.Elasticsearch.NodeAttributes = make(map[string]string, len(attrs))
+	}
+
+	for k, v := range attrs {
+		elem.Elasticsearch.NodeAttributes[k] = v.(string)
+	}
+}
+
+// matchSizeResource validates the size resource against the one declared in
+// the deployment template for the topology element. When "size_resource"
+// isn't set, the template's resource is used, or "memory" when the template
+// doesn't declare any.
+func matchSizeResource(size, tplSize *models.TopologySize) error {
+	if tplSize == nil || tplSize.Resource == nil || *tplSize.Resource == "" {
+		if size.Resource == nil {
+			size.Resource = ec.String("memory")
+		}
+		return nil
+	}
+
+	if size.Resource == nil {
+		size.Resource = ec.String(*tplSize.Resource)
+		return nil
+	}
+
+	if *size.Resource == *tplSize.Resource {
+		return nil
+	}
+
+	return fmt.Errorf(
+		`size_resource "%s" doesn't match the deployment template size resource "%s"`,
+		*size.Resource, *tplSize.Resource,
+	)
+}
+
+// disableTopologyAutoscaling pins the autoscaling sizes of the topology element
+// to its size, so that the element isn't autoscaled while the rest of the
+// deployment is. The minimum size is only pinned when it's set.
+func disableTopologyAutoscaling(elem *models.ElasticsearchClusterTopologyElement) error {
+	if elem.Size == nil || elem.Size.Value == nil || *elem.Size.Value <= 0 {
+		return errors.New("autoscaling can only be disabled on a sized topology element")
+	}
+
+	elem.AutoscalingMax = &models.TopologySize{
+		Resource: elem.Size.Resource,
+		Value:    ec.Int32(*elem.Size.Value),
+	}
+
+	if elem.AutoscalingMin != nil {
+		elem.AutoscalingMin = &models.TopologySize{
+			Resource: elem.Size.Resource,
+			Value:    ec.Int32(*elem.Size.Value),
+		}
+	}
+
+	return nil
+}
+
+// hasAutoscalingSize returns true when any of the autoscaling limits is set.
+func hasAutoscalingSize(autoscale map[string]interface{}) bool {
+	for _, attr := range []string{"max_size", "min_size"} {
+		if size, ok := autoscale[attr].(string); ok && size != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAutoscalingLimits returns true when any of the topology elements of the
+// Elasticsearch payload declares an autoscaling maximum size.
+func hasAutoscalingLimits(es *models.ElasticsearchPayload) bool {
+	if es == nil || es.Plan == nil {
+		return false
+	}
+
+	for _, t := range es.Plan.ClusterTopology {
+		if t != nil && t.AutoscalingMax != nil && t.AutoscalingMax.Value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAutoscalingLimits returns an error for each of the sized topology
+// elements of the Elasticsearch resources with autoscaling enabled which lack
+// an autoscaling maximum size. It's meant for resources expanded from
+// deployment templates without any autoscaling limits, such as some custom
+// templates, so that a clear error is returned instead of sending a nil or
+// zero autoscaling ceiling to the API.
+func validateAutoscalingLimits(ess []*models.ElasticsearchPayload, templateID string) error {
+	merr := multierror.NewPrefixed("invalid autoscaling configuration")
+	for _, es := range ess {
+		if es == nil || es.Plan == nil || es.Plan.AutoscalingEnabled == nil || !*es.Plan.AutoscalingEnabled {
+			continue
+		}
+
+		for _, t := range es.Plan.ClusterTopology {
+			if t == nil || t.Size == nil || t.Size.Value == nil || *t.Size.Value == 0 {
+				continue
+			}
+
+			if t.AutoscalingMax != nil && t.AutoscalingMax.Value != nil && *t.AutoscalingMax.Value > 0 {
+				continue
+			}
+
+			merr = merr.Append(fmt.Errorf(
+				`elasticsearch topology %s: autoscaling is enabled but the "%s" deployment template doesn't declare its autoscaling limits, set "autoscaling.max_size" or disable autoscaling`,
+				t.ID, templateID,
+			))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
 // expandAutoscalingDimension centralises processing of %_size and %_size_resource attributes
 // Due to limitations in the Terraform SDK, it's not possible to specify a Default on a Computed schema member
 // to work around this limitation, this function will default the %_size_resource attribute to `memory`.
@@ -250,7 +499,7 @@ func expandAutoscalingDimension(autoscale map[string]interface{}, model *models.
 
 	if size := autoscale[sizeAttribute]; size != nil {
 		if size := size.(string); size != "" {
-			val, err := deploymentsize.ParseGb(size)
+			val, err := util.ParseSize(size)
 			if err != nil {
 				return err
 			}
@@ -309,6 +558,43 @@ func expandEsConfig(raw interface{}, esCfg *models.ElasticsearchConfiguration) e
 		if v, ok := cfg["docker_image"]; ok {
 			esCfg.DockerImage = v.(string)
 		}
+
+		if err := expandEsSecuritySettings(cfg, esCfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expandEsSecuritySettings sets the typed security settings into the user
+// settings overrides, taking precedence over the same settings set through
+// "user_settings_override_json".
+func expandEsSecuritySettings(cfg map[string]interface{}, esCfg *models.ElasticsearchConfiguration) error {
+	var settings = make(map[string]interface{})
+	if roles, ok := cfg["anonymous_access_roles"].(*schema.Set); ok && roles.Len() > 0 {
+		settings[anonymousRolesSetting] = util.ItemsToString(roles.List())
+	}
+
+	if username, ok := cfg["anonymous_access_username"].(string); ok && username != "" {
+		settings[anonymousUsernameSetting] = username
+	}
+
+	if len(settings) == 0 {
+		return nil
+	}
+
+	if esCfg.UserSettingsOverrideJSON == nil {
+		esCfg.UserSettingsOverrideJSON = make(map[string]interface{})
+	}
+
+	overrides, ok := esCfg.UserSettingsOverrideJSON.(map[string]interface{})
+	if !ok {
+		return errors.New("failed expanding elasticsearch security settings: user_settings_override_json must be a JSON object")
+	}
+
+	for k, v := range settings {
+		overrides[k] = v
 	}
 
 	return nil
@@ -324,6 +610,10 @@ func expandSnapshotSource(raw interface{}, restore *models.RestoreSnapshotConfig
 		if snapshotName, ok := rs["snapshot_name"]; ok {
 			restore.SnapshotName = ec.String(snapshotName.(string))
 		}
+
+		if strategy, ok := rs["strategy"]; ok && strategy.(string) != "" {
+			restore.Strategy = strategy.(string)
+		}
 	}
 }
 
@@ -442,6 +732,42 @@ func updateNodeRolesOnDedicatedTiers(topologies []*models.ElasticsearchClusterTo
 	}
 }
 
+// expandDedicatedMasters sizes the dedicated master tier of the topology,
+// unless it's already sized. The size defaults to the minimum size of the
+// tier in the deployment template, or to defaultDedicatedMastersSize when the
+// template doesn't set any.
+func expandDedicatedMasters(topologies []*models.ElasticsearchClusterTopologyElement) error {
+	for _, topology := range topologies {
+		if topology.ID != dedicatedMastersTier {
+			continue
+		}
+
+		if topology.Size != nil && topology.Size.Value != nil && *topology.Size.Value > 0 {
+			return nil
+		}
+
+		var size = &models.TopologySize{
+			Resource: ec.String("memory"),
+			Value:    ec.Int32(defaultDedicatedMastersSize),
+		}
+		if ctrl := topology.TopologyElementControl; ctrl != nil && ctrl.Min != nil {
+			if ctrl.Min.Value != nil && *ctrl.Min.Value > 0 {
+				size.Value = ec.Int32(*ctrl.Min.Value)
+			}
+		}
+		if topology.Size != nil && topology.Size.Resource != nil {
+			size.Resource = topology.Size.Resource
+		}
+
+		topology.Size = size
+		return nil
+	}
+
+	return errors.New(
+		"elasticsearch dedicated_masters: the deployment template doesn't have a dedicated master tier",
+	)
+}
+
 func dedicatedTopoogies(topologies []*models.ElasticsearchClusterTopologyElement) (dataTier *models.ElasticsearchClusterTopologyElement, hasMasterTier, hasIngestTier bool) {
 	for _, topology := range topologies {
 		var hasSomeDataRole bool
@@ -610,3 +936,43 @@ func expandExternalTrust(raw []interface{}, es *models.ElasticsearchClusterSetti
 
 	es.Trust.External = append(es.Trust.External, external...)
 }
+
+// expandPlanStrategy sets the transient plan strategy of the Elasticsearch
+// payloads from the "plan_strategy" setting of the matching resource. When
+// unset, the strategy is left to the server.
+func expandPlanStrategy(raw []interface{}, ess []*models.ElasticsearchPayload) {
+	for i, rawEs := range raw {
+		if i >= len(ess) {
+			return
+		}
+
+		es, ok := rawEs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		strategy, _ := es["plan_strategy"].(string)
+		if strategy == "" {
+			continue
+		}
+
+		var planStrategy models.PlanStrategy
+		switch strategy {
+		case "autodetect":
+			planStrategy.Autodetect = make(map[string]interface{})
+		case "grow_and_shrink":
+			planStrategy.GrowAndShrink = make(map[string]interface{})
+		case "rolling":
+			planStrategy.Rolling = &models.RollingStrategyConfig{}
+		case "rolling_grow_and_shrink":
+			planStrategy.RollingGrowAndShrink = make(map[string]interface{})
+		default:
+			continue
+		}
+
+		if ess[i].Plan.Transient == nil {
+			ess[i].Plan.Transient = &models.TransientElasticsearchPlanConfiguration{}
+		}
+		ess[i].Plan.Transient.Strategy = &planStrategy
+	}
+}
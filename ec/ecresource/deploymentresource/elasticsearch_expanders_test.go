@@ -23,7 +23,9 @@ import (
 
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/go-openapi/strfmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -86,6 +88,30 @@ func Test_expandEsResource(t *testing.T) {
 		)
 	}
 
+	coordinatingAutoscalingTpl := func() *models.ElasticsearchPayload {
+		tpl := create710()
+		for _, t := range tpl.Plan.ClusterTopology {
+			if t.ID == "coordinating" {
+				t.AutoscalingMax = &models.TopologySize{
+					Value:    ec.Int32(61440),
+					Resource: ec.String("memory"),
+				}
+			}
+		}
+		return tpl
+	}
+
+	// The data tier drops the ingest role once the coordinating tier is sized.
+	coordinatingAutoscalingWant := func(want *models.ElasticsearchPayload) []*models.ElasticsearchPayload {
+		res := enrichWithEmptyTopologies(coordinatingAutoscalingTpl(), want)
+		for _, t := range res[0].Plan.ClusterTopology {
+			if t.ID == "hot_content" {
+				t.NodeRoles = removeItemFromSlice(t.NodeRoles, ingestDataTierRole)
+			}
+		}
+		return res
+	}
+
 	type args struct {
 		ess []interface{}
 		dt  *models.ElasticsearchPayload
@@ -165,6 +191,73 @@ func Test_expandEsResource(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name: "parses an ES resource with dedicated_masters_threshold",
+			args: args{
+				dt: tp770(),
+				ess: []interface{}{
+					map[string]interface{}{
+						"ref_id":                      "main-elasticsearch",
+						"resource_id":                 mock.ValidClusterID,
+						"version":                     "7.7.0",
+						"region":                      "some-region",
+						"dedicated_masters_threshold": 3,
+						"topology": []interface{}{map[string]interface{}{
+							"id":         "hot_content",
+							"size":       "2g",
+							"zone_count": 1,
+						}},
+					},
+				},
+			},
+			want: enrichWithEmptyTopologies(tp770(), &models.ElasticsearchPayload{
+				Region: ec.String("some-region"),
+				RefID:  ec.String("main-elasticsearch"),
+				Settings: &models.ElasticsearchClusterSettings{
+					DedicatedMastersThreshold: 3,
+				},
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(false),
+					Elasticsearch: &models.ElasticsearchConfiguration{
+						Version: "7.7.0",
+					},
+					DeploymentTemplate: &models.DeploymentTemplateReference{
+						ID: ec.String("aws-io-optimized-v2"),
+					},
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+						{
+							ID:                      "hot_content",
+							ZoneCount:               1,
+							InstanceConfigurationID: "aws.data.highio.i3",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(2048),
+							},
+							NodeType: &models.ElasticsearchNodeType{
+								Data:   ec.Bool(true),
+								Ingest: ec.Bool(true),
+								Master: ec.Bool(true),
+							},
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{
+									"data": "hot",
+								},
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(1024),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(118784),
+								Resource: ec.String("memory"),
+							},
+						},
+					},
+				},
+			}),
+		},
 		{
 			name: "parses an ES resource with empty version (7.10.0) in state uses node_roles from the DT",
 			args: args{
@@ -319,6 +412,27 @@ func Test_expandEsResource(t *testing.T) {
 			},
 			err: errors.New(`elasticsearch topology invalid: invalid id: valid topology IDs are "coordinating", "hot_content", "warm", "cold", "master", "ml"`),
 		},
+		{
+			name: "parses an ES resource with a size_resource not matching the template",
+			args: args{
+				dt: tp770(),
+				ess: []interface{}{
+					map[string]interface{}{
+						"ref_id":      "main-elasticsearch",
+						"resource_id": mock.ValidClusterID,
+						"version":     "7.7.0",
+						"region":      "some-region",
+						"topology": []interface{}{map[string]interface{}{
+							"id":            "hot_content",
+							"size":          "2g",
+							"size_resource": "storage",
+							"zone_count":    1,
+						}},
+					},
+				},
+			},
+			err: errors.New(`elasticsearch topology hot_content: size_resource "storage" doesn't match the deployment template size resource "memory"`),
+		},
 		{
 			name: "parses an ES resource without a topology",
 			args: args{
@@ -785,6 +899,117 @@ func Test_expandEsResource(t *testing.T) {
 				},
 			}),
 		},
+		{
+			name: "parses an ES resource with node type overrides and topology config (HotWarm)",
+			args: args{
+				dt: hotWarmTpl770(),
+				ess: []interface{}{map[string]interface{}{
+					"ref_id":      "main-elasticsearch",
+					"resource_id": mock.ValidClusterID,
+					"region":      "some-region",
+					"topology": []interface{}{
+						map[string]interface{}{
+							"id":               "hot_content",
+							"node_type_data":   "true",
+							"node_type_master": "false",
+							"node_type_ingest": "true",
+							"config": []interface{}{map[string]interface{}{
+								"user_settings_yaml": "indices.fielddata.cache.size: 40%",
+							}},
+						},
+						map[string]interface{}{
+							"id":               "warm",
+							"node_type_master": "true",
+							"config": []interface{}{map[string]interface{}{
+								"user_settings_json": `{"indices.queries.cache.size":"20%"}`,
+							}},
+						},
+					},
+				}},
+			},
+			want: enrichWithEmptyTopologies(hotWarmTpl770(), &models.ElasticsearchPayload{
+				Region: ec.String("some-region"),
+				RefID:  ec.String("main-elasticsearch"),
+				Settings: &models.ElasticsearchClusterSettings{
+					DedicatedMastersThreshold: 6,
+					Curation:                  nil,
+				},
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(false),
+					Elasticsearch: &models.ElasticsearchConfiguration{
+						Version:  "7.7.0",
+						Curation: nil,
+					},
+					DeploymentTemplate: &models.DeploymentTemplateReference{
+						ID: ec.String("aws-hot-warm-v2"),
+					},
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+						{
+							ID: "hot_content",
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{
+									"data": "hot",
+								},
+								UserSettingsYaml: "indices.fielddata.cache.size: 40%",
+							},
+							ZoneCount:               2,
+							InstanceConfigurationID: "aws.data.highio.i3",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(4096),
+							},
+							NodeType: &models.ElasticsearchNodeType{
+								Data:   ec.Bool(true),
+								Ingest: ec.Bool(true),
+								Master: ec.Bool(false),
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(1024),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(118784),
+								Resource: ec.String("memory"),
+							},
+						},
+						{
+							ID: "warm",
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{
+									"data": "warm",
+								},
+								UserSettingsJSON: map[string]interface{}{
+									"indices.queries.cache.size": "20%",
+								},
+							},
+							ZoneCount:               2,
+							InstanceConfigurationID: "aws.data.highstorage.d2",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(4096),
+							},
+							NodeType: &models.ElasticsearchNodeType{
+								Data:   ec.Bool(true),
+								Ingest: ec.Bool(true),
+								Master: ec.Bool(true),
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(0),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(118784),
+								Resource: ec.String("memory"),
+							},
+						},
+					},
+				},
+			}),
+		},
 		{
 			name: "migrates old node_type state to new node_roles payload when the cold tier is set",
 			args: args{
@@ -1353,50 +1578,37 @@ func Test_expandEsResource(t *testing.T) {
 			}),
 		},
 		{
-			name: "autoscaling enabled overriding the size and resources",
+			name: "parses a frozen tier heavy ES resource with user settings",
 			args: args{
-				dt: hotWarm7111Tpl(),
+				dt: eceDefaultTpl(),
 				ess: []interface{}{map[string]interface{}{
-					"autoscale":   "true",
 					"ref_id":      "main-elasticsearch",
 					"resource_id": mock.ValidClusterID,
 					"region":      "some-region",
+					"config": []interface{}{map[string]interface{}{
+						"user_settings_yaml": "xpack.searchable.snapshot.shared_cache.size: 90%",
+					}},
 					"topology": []interface{}{
 						map[string]interface{}{
-							"id": "hot_content",
-							"autoscaling": []interface{}{
-								map[string]interface{}{
-									"max_size_resource": "storage",
-									"max_size":          "450g",
-								},
-							},
-						},
-						map[string]interface{}{
-							"id": "warm",
-							"autoscaling": []interface{}{
-								map[string]interface{}{
-									"max_size_resource": "storage",
-									"max_size":          "870g",
-								},
-							},
+							"id":         "hot_content",
+							"size":       "1g",
+							"zone_count": 1,
 						},
 						map[string]interface{}{
-							"id":   "cold",
-							"size": "4g",
+							"id":         "frozen",
+							"size":       "8g",
+							"zone_count": 2,
 							"autoscaling": []interface{}{
 								map[string]interface{}{
+									"max_size":          "2048g",
 									"max_size_resource": "storage",
-									"max_size":          "1740g",
-
-									"min_size_resource": "storage",
-									"min_size":          "4g",
 								},
 							},
 						},
 					},
 				}},
 			},
-			want: enrichWithEmptyTopologies(hotWarm7111Tpl(), &models.ElasticsearchPayload{
+			want: enrichWithEmptyTopologies(eceDefaultTpl(), &models.ElasticsearchPayload{
 				Region: ec.String("some-region"),
 				RefID:  ec.String("main-elasticsearch"),
 				Settings: &models.ElasticsearchClusterSettings{
@@ -1404,13 +1616,14 @@ func Test_expandEsResource(t *testing.T) {
 					Curation:                  nil,
 				},
 				Plan: &models.ElasticsearchClusterPlan{
-					AutoscalingEnabled: ec.Bool(true),
+					AutoscalingEnabled: ec.Bool(false),
 					Elasticsearch: &models.ElasticsearchConfiguration{
-						Version:  "7.11.1",
-						Curation: nil,
+						Version:          "7.17.3",
+						Curation:         nil,
+						UserSettingsYaml: "xpack.searchable.snapshot.shared_cache.size: 90%",
 					},
 					DeploymentTemplate: &models.DeploymentTemplateReference{
-						ID: ec.String("aws-hot-warm-v2"),
+						ID: ec.String("aws-io-optimized-v2"),
 					},
 					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
 						{
@@ -1420,19 +1633,19 @@ func Test_expandEsResource(t *testing.T) {
 									"data": "hot",
 								},
 							},
-							ZoneCount:               2,
-							InstanceConfigurationID: "aws.data.highio.i3",
+							ZoneCount:               1,
+							InstanceConfigurationID: "data.default",
 							Size: &models.TopologySize{
 								Resource: ec.String("memory"),
-								Value:    ec.Int32(4096),
+								Value:    ec.Int32(1024),
 							},
 							NodeRoles: []string{
 								"master",
 								"ingest",
-								"remote_cluster_client",
 								"data_hot",
-								"transform",
 								"data_content",
+								"remote_cluster_client",
+								"transform",
 							},
 							TopologyElementControl: &models.TopologyElementControl{
 								Min: &models.TopologySize{
@@ -1441,26 +1654,25 @@ func Test_expandEsResource(t *testing.T) {
 								},
 							},
 							AutoscalingMax: &models.TopologySize{
-								Value:    ec.Int32(460800),
-								Resource: ec.String("storage"),
+								Value:    ec.Int32(2097152),
+								Resource: ec.String("memory"),
 							},
 						},
 						{
-							ID: "warm",
+							ID: "frozen",
 							Elasticsearch: &models.ElasticsearchConfiguration{
 								NodeAttributes: map[string]string{
-									"data": "warm",
+									"data": "frozen",
 								},
 							},
 							ZoneCount:               2,
-							InstanceConfigurationID: "aws.data.highstorage.d2",
+							InstanceConfigurationID: "data.frozen",
 							Size: &models.TopologySize{
 								Resource: ec.String("memory"),
-								Value:    ec.Int32(4096),
+								Value:    ec.Int32(8192),
 							},
 							NodeRoles: []string{
-								"data_warm",
-								"remote_cluster_client",
+								"data_frozen",
 							},
 							TopologyElementControl: &models.TopologyElementControl{
 								Min: &models.TopologySize{
@@ -1469,27 +1681,152 @@ func Test_expandEsResource(t *testing.T) {
 								},
 							},
 							AutoscalingMax: &models.TopologySize{
-								Value:    ec.Int32(890880),
+								Value:    ec.Int32(2097152),
 								Resource: ec.String("storage"),
 							},
 						},
-						{
-							ID: "cold",
-							Elasticsearch: &models.ElasticsearchConfiguration{
-								NodeAttributes: map[string]string{
-									"data": "cold",
-								},
-							},
-							ZoneCount:               1,
-							InstanceConfigurationID: "aws.data.highstorage.d2",
-							Size: &models.TopologySize{
-								Resource: ec.String("memory"),
-								Value:    ec.Int32(4096),
-							},
-							NodeRoles: []string{
-								"data_cold",
-								"remote_cluster_client",
-							},
+					},
+				},
+			}),
+		},
+		{
+			name: "autoscaling enabled overriding the size and resources",
+			args: args{
+				dt: hotWarm7111Tpl(),
+				ess: []interface{}{map[string]interface{}{
+					"autoscale":   "true",
+					"ref_id":      "main-elasticsearch",
+					"resource_id": mock.ValidClusterID,
+					"region":      "some-region",
+					"topology": []interface{}{
+						map[string]interface{}{
+							"id": "hot_content",
+							"autoscaling": []interface{}{
+								map[string]interface{}{
+									"max_size_resource": "storage",
+									"max_size":          "450g",
+								},
+							},
+						},
+						map[string]interface{}{
+							"id": "warm",
+							"autoscaling": []interface{}{
+								map[string]interface{}{
+									"max_size_resource": "storage",
+									"max_size":          "870g",
+								},
+							},
+						},
+						map[string]interface{}{
+							"id":   "cold",
+							"size": "4g",
+							"autoscaling": []interface{}{
+								map[string]interface{}{
+									"max_size_resource": "storage",
+									"max_size":          "1740g",
+
+									"min_size_resource": "storage",
+									"min_size":          "4g",
+								},
+							},
+						},
+					},
+				}},
+			},
+			want: enrichWithEmptyTopologies(hotWarm7111Tpl(), &models.ElasticsearchPayload{
+				Region: ec.String("some-region"),
+				RefID:  ec.String("main-elasticsearch"),
+				Settings: &models.ElasticsearchClusterSettings{
+					DedicatedMastersThreshold: 6,
+					Curation:                  nil,
+				},
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(true),
+					Elasticsearch: &models.ElasticsearchConfiguration{
+						Version:  "7.11.1",
+						Curation: nil,
+					},
+					DeploymentTemplate: &models.DeploymentTemplateReference{
+						ID: ec.String("aws-hot-warm-v2"),
+					},
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+						{
+							ID: "hot_content",
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{
+									"data": "hot",
+								},
+							},
+							ZoneCount:               2,
+							InstanceConfigurationID: "aws.data.highio.i3",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(4096),
+							},
+							NodeRoles: []string{
+								"master",
+								"ingest",
+								"remote_cluster_client",
+								"data_hot",
+								"transform",
+								"data_content",
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(1024),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(460800),
+								Resource: ec.String("storage"),
+							},
+						},
+						{
+							ID: "warm",
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{
+									"data": "warm",
+								},
+							},
+							ZoneCount:               2,
+							InstanceConfigurationID: "aws.data.highstorage.d2",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(4096),
+							},
+							NodeRoles: []string{
+								"data_warm",
+								"remote_cluster_client",
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(0),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(890880),
+								Resource: ec.String("storage"),
+							},
+						},
+						{
+							ID: "cold",
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{
+									"data": "cold",
+								},
+							},
+							ZoneCount:               1,
+							InstanceConfigurationID: "aws.data.highstorage.d2",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(4096),
+							},
+							NodeRoles: []string{
+								"data_cold",
+								"remote_cluster_client",
+							},
 							TopologyElementControl: &models.TopologyElementControl{
 								Min: &models.TopologySize{
 									Resource: ec.String("memory"),
@@ -1663,19 +2000,870 @@ func Test_expandEsResource(t *testing.T) {
 				},
 			}),
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := expandEsResources(tt.args.ess, tt.args.dt)
-			if err != nil {
-				var msg string
-				if tt.err != nil {
-					msg = tt.err.Error()
-				}
-				assert.EqualError(t, err, msg)
-			}
-
-			assert.Equal(t, tt.want, got)
+		{
+			name: "parses an ES resource with a recovery snapshot strategy",
+			args: args{
+				dt: tp770(),
+				ess: []interface{}{
+					map[string]interface{}{
+						"ref_id":      "main-elasticsearch",
+						"resource_id": mock.ValidClusterID,
+						"region":      "some-region",
+						"snapshot_source": []interface{}{map[string]interface{}{
+							"snapshot_name":                   "__latest_success__",
+							"source_elasticsearch_cluster_id": mock.ValidClusterID,
+							"strategy":                        "recovery",
+						}},
+						"topology": []interface{}{map[string]interface{}{
+							"id":         "hot_content",
+							"size":       "2g",
+							"zone_count": 1,
+						}},
+					},
+				},
+			},
+			want: enrichWithEmptyTopologies(tp770(), &models.ElasticsearchPayload{
+				Region: ec.String("some-region"),
+				RefID:  ec.String("main-elasticsearch"),
+				Settings: &models.ElasticsearchClusterSettings{
+					DedicatedMastersThreshold: 6,
+				},
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(false),
+					Elasticsearch: &models.ElasticsearchConfiguration{
+						Version: "7.7.0",
+					},
+					DeploymentTemplate: &models.DeploymentTemplateReference{
+						ID: ec.String("aws-io-optimized-v2"),
+					},
+					Transient: &models.TransientElasticsearchPlanConfiguration{
+						RestoreSnapshot: &models.RestoreSnapshotConfiguration{
+							SnapshotName:    ec.String("__latest_success__"),
+							SourceClusterID: mock.ValidClusterID,
+							Strategy:        "recovery",
+						},
+					},
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+						{
+							ID:                      "hot_content",
+							ZoneCount:               1,
+							InstanceConfigurationID: "aws.data.highio.i3",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(2048),
+							},
+							NodeType: &models.ElasticsearchNodeType{
+								Data:   ec.Bool(true),
+								Ingest: ec.Bool(true),
+								Master: ec.Bool(true),
+							},
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								NodeAttributes: map[string]string{"data": "hot"},
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(1024),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(118784),
+								Resource: ec.String("memory"),
+							},
+						},
+					},
+				},
+			}),
+		},
+		{
+			name: "parses an ES resource with coordinating autoscaling permitted by the template",
+			args: args{
+				dt: coordinatingAutoscalingTpl(),
+				ess: []interface{}{map[string]interface{}{
+					"autoscale":   "true",
+					"ref_id":      "main-elasticsearch",
+					"resource_id": mock.ValidClusterID,
+					"region":      "some-region",
+					"topology": []interface{}{
+						map[string]interface{}{
+							"id":   "coordinating",
+							"size": "1g",
+							"autoscaling": []interface{}{
+								map[string]interface{}{
+									"max_size": "8g",
+								},
+							},
+						},
+					},
+				}},
+			},
+			want: coordinatingAutoscalingWant(&models.ElasticsearchPayload{
+				Region: ec.String("some-region"),
+				RefID:  ec.String("main-elasticsearch"),
+				Settings: &models.ElasticsearchClusterSettings{
+					DedicatedMastersThreshold: 6,
+				},
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(true),
+					Elasticsearch: &models.ElasticsearchConfiguration{
+						Version: "7.10.0",
+					},
+					DeploymentTemplate: &models.DeploymentTemplateReference{
+						ID: ec.String("aws-io-optimized-v2"),
+					},
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+						{
+							ID:                      "coordinating",
+							ZoneCount:               2,
+							InstanceConfigurationID: "aws.coordinating.m5d",
+							Size: &models.TopologySize{
+								Resource: ec.String("memory"),
+								Value:    ec.Int32(1024),
+							},
+							NodeRoles: []string{
+								"ingest",
+								"remote_cluster_client",
+							},
+							TopologyElementControl: &models.TopologyElementControl{
+								Min: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(0),
+								},
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value:    ec.Int32(8192),
+								Resource: ec.String("memory"),
+							},
+						},
+					},
+				},
+			}),
+		},
+		{
+			name: "fails to parse coordinating autoscaling when the template doesn't permit it",
+			args: args{
+				dt: create710(),
+				ess: []interface{}{map[string]interface{}{
+					"autoscale":   "true",
+					"ref_id":      "main-elasticsearch",
+					"resource_id": mock.ValidClusterID,
+					"region":      "some-region",
+					"topology": []interface{}{
+						map[string]interface{}{
+							"id":   "coordinating",
+							"size": "1g",
+							"autoscaling": []interface{}{
+								map[string]interface{}{
+									"max_size": "8g",
+								},
+							},
+						},
+					},
+				}},
+			},
+			err: errors.New("elasticsearch topology coordinating: autoscaling is not supported by the deployment template"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEsResources(tt.args.ess, tt.args.dt)
+			if err != nil {
+				var msg string
+				if tt.err != nil {
+					msg = tt.err.Error()
+				}
+				assert.EqualError(t, err, msg)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_expandEsResourceRemoteClusterClient(t *testing.T) {
+	hotWarmTpl := func() *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-aws-hot-warm-v2.json")),
+			"aws-hot-warm-v2",
+			"7.11.1",
+			true,
+		)
+	}
+	hotWarmColdTopology := []interface{}{
+		map[string]interface{}{"id": "hot_content", "size": "4g"},
+		map[string]interface{}{"id": "warm", "size": "4g"},
+		map[string]interface{}{"id": "cold", "size": "2g"},
+	}
+	tests := []struct {
+		name string
+		es   map[string]interface{}
+		want map[string][]string
+	}{
+		{
+			name: "keeps remote_cluster_client by default",
+			es: map[string]interface{}{
+				"ref_id":   "main-elasticsearch",
+				"topology": hotWarmColdTopology,
+			},
+			want: map[string][]string{
+				"hot_content": {"master", "ingest", "remote_cluster_client", "data_hot", "transform", "data_content"},
+				"warm":        {"data_warm", "remote_cluster_client"},
+				"cold":        {"data_cold", "remote_cluster_client"},
+			},
+		},
+		{
+			name: "keeps remote_cluster_client when included",
+			es: map[string]interface{}{
+				"ref_id":                        "main-elasticsearch",
+				"include_remote_cluster_client": true,
+				"topology":                      hotWarmColdTopology,
+			},
+			want: map[string][]string{
+				"hot_content": {"master", "ingest", "remote_cluster_client", "data_hot", "transform", "data_content"},
+				"warm":        {"data_warm", "remote_cluster_client"},
+				"cold":        {"data_cold", "remote_cluster_client"},
+			},
+		},
+		{
+			name: "strips remote_cluster_client from all the tiers when excluded",
+			es: map[string]interface{}{
+				"ref_id":                        "main-elasticsearch",
+				"include_remote_cluster_client": false,
+				"topology":                      hotWarmColdTopology,
+			},
+			want: map[string][]string{
+				"hot_content": {"master", "ingest", "data_hot", "transform", "data_content"},
+				"warm":        {"data_warm"},
+				"cold":        {"data_cold"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEsResource(tt.es, hotWarmTpl())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			for _, topology := range got.Plan.ClusterTopology {
+				if want, ok := tt.want[topology.ID]; ok {
+					assert.Equal(t, want, topology.NodeRoles, topology.ID)
+				}
+			}
+		})
+	}
+}
+
+func Test_expandEsResourceDedicatedMasters(t *testing.T) {
+	ioOptimizedTpl := func() *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-aws-io-optimized-v2.json")),
+			"aws-io-optimized-v2",
+			"7.12.0",
+			true,
+		)
+	}
+	ccsTpl := func() *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-aws-cross-cluster-search-v2.json")),
+			"aws-cross-cluster-search-v2",
+			"7.12.0",
+			true,
+		)
+	}
+	hotTopology := map[string]interface{}{"id": "hot_content", "size": "8g"}
+	type want struct {
+		size      *models.TopologySize
+		zones     int32
+		hotRoles  []string
+		threshold int32
+	}
+	tests := []struct {
+		name string
+		es   map[string]interface{}
+		tpl  *models.ElasticsearchPayload
+		want want
+		err  error
+	}{
+		{
+			name: "leaves the master tier unsized by default",
+			es: map[string]interface{}{
+				"topology": []interface{}{hotTopology},
+			},
+			tpl: ioOptimizedTpl(),
+			want: want{
+				size:      &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(0)},
+				zones:     3,
+				hotRoles:  []string{"master", "ingest", "remote_cluster_client", "data_hot", "transform", "data_content"},
+				threshold: 6,
+			},
+		},
+		{
+			name: "sizes the master tier from the default size",
+			es: map[string]interface{}{
+				"dedicated_masters": true,
+				"topology":          []interface{}{hotTopology},
+			},
+			tpl: ioOptimizedTpl(),
+			want: want{
+				size:      &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(1024)},
+				zones:     3,
+				hotRoles:  []string{"ingest", "remote_cluster_client", "data_hot", "transform", "data_content"},
+				threshold: 6,
+			},
+		},
+		{
+			name: "sizes the master tier from the template minimum size",
+			es: map[string]interface{}{
+				"dedicated_masters": true,
+				"topology":          []interface{}{hotTopology},
+			},
+			tpl: func() *models.ElasticsearchPayload {
+				tpl := ioOptimizedTpl()
+				for _, topology := range tpl.Plan.ClusterTopology {
+					if topology.ID == "master" {
+						topology.TopologyElementControl.Min.Value = ec.Int32(2048)
+					}
+				}
+				return tpl
+			}(),
+			want: want{
+				size:      &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(2048)},
+				zones:     3,
+				hotRoles:  []string{"ingest", "remote_cluster_client", "data_hot", "transform", "data_content"},
+				threshold: 6,
+			},
+		},
+		{
+			name: "keeps the size of an explicit master topology element",
+			es: map[string]interface{}{
+				"dedicated_masters": true,
+				"topology": []interface{}{
+					hotTopology,
+					map[string]interface{}{"id": "master", "size": "4g", "zone_count": 2},
+				},
+			},
+			tpl: ioOptimizedTpl(),
+			want: want{
+				size:      &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(4096)},
+				zones:     2,
+				hotRoles:  []string{"ingest", "remote_cluster_client", "data_hot", "transform", "data_content"},
+				threshold: 6,
+			},
+		},
+		{
+			name: "fails when the template doesn't have a master tier",
+			es: map[string]interface{}{
+				"dedicated_masters": true,
+			},
+			tpl: ccsTpl(),
+			err: errors.New("elasticsearch dedicated_masters: the deployment template doesn't have a dedicated master tier"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEsResource(tt.es, tt.tpl)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want.threshold, got.Settings.DedicatedMastersThreshold)
+			for _, topology := range got.Plan.ClusterTopology {
+				switch topology.ID {
+				case "master":
+					assert.Equal(t, tt.want.size, topology.Size)
+					assert.Equal(t, tt.want.zones, topology.ZoneCount)
+				case "hot_content":
+					assert.Equal(t, tt.want.hotRoles, topology.NodeRoles)
+				}
+			}
+		})
+	}
+}
+
+func Test_expandEsResourceAutoscalingDisabled(t *testing.T) {
+	ioOptimizedTpl := func() *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-aws-io-optimized-v2.json")),
+			"aws-io-optimized-v2",
+			"7.11.1",
+			true,
+		)
+	}
+	disabled := []interface{}{map[string]interface{}{"disabled": true}}
+	tests := []struct {
+		name    string
+		es      map[string]interface{}
+		wantMax map[string]*models.TopologySize
+		wantMin map[string]*models.TopologySize
+		err     string
+	}{
+		{
+			name: "pins the cold tier autoscaling while the deployment autoscales",
+			es: map[string]interface{}{
+				"ref_id":    "main-elasticsearch",
+				"autoscale": "true",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content", "size": "8g"},
+					map[string]interface{}{"id": "cold", "size": "2g", "autoscaling": disabled},
+				},
+			},
+			wantMax: map[string]*models.TopologySize{
+				"hot_content": {Resource: ec.String("memory"), Value: ec.Int32(118784)},
+				"cold":        {Resource: ec.String("memory"), Value: ec.Int32(2048)},
+			},
+		},
+		{
+			name: "pins both the maximum and minimum sizes when the tier has a minimum",
+			es: map[string]interface{}{
+				"ref_id":    "main-elasticsearch",
+				"autoscale": "true",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content", "size": "8g"},
+					map[string]interface{}{"id": "ml", "size": "1g", "autoscaling": disabled},
+				},
+			},
+			wantMax: map[string]*models.TopologySize{
+				"ml": {Resource: ec.String("memory"), Value: ec.Int32(1024)},
+			},
+			wantMin: map[string]*models.TopologySize{
+				"ml": {Resource: ec.String("memory"), Value: ec.Int32(1024)},
+			},
+		},
+		{
+			name: "fails disabling the autoscaling of an unsized tier",
+			es: map[string]interface{}{
+				"ref_id":    "main-elasticsearch",
+				"autoscale": "true",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content", "size": "8g"},
+					map[string]interface{}{"id": "cold", "autoscaling": disabled},
+				},
+			},
+			err: "elasticsearch topology cold: autoscaling can only be disabled on a sized topology element",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEsResource(tt.es, ioOptimizedTpl())
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, ec.Bool(true), got.Plan.AutoscalingEnabled)
+			for _, topology := range got.Plan.ClusterTopology {
+				if want, ok := tt.wantMax[topology.ID]; ok {
+					assert.Equal(t, want, topology.AutoscalingMax, topology.ID)
+				}
+				if want, ok := tt.wantMin[topology.ID]; ok {
+					assert.Equal(t, want, topology.AutoscalingMin, topology.ID)
+				}
+			}
+		})
+	}
+}
+
+func Test_expandEsResourceNodeAttributes(t *testing.T) {
+	ioOptimizedTpl := func() *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-aws-io-optimized-v2.json")),
+			"aws-io-optimized-v2",
+			"7.11.1",
+			true,
+		)
+	}
+	tests := []struct {
+		name  string
+		attrs map[string]interface{}
+		want  map[string]string
+	}{
+		{
+			name: "keeps the template node attributes when none are set",
+			want: map[string]string{"data": "hot"},
+		},
+		{
+			name:  "merges the node attributes with the template ones",
+			attrs: map[string]interface{}{"rack": "rack-1"},
+			want:  map[string]string{"data": "hot", "rack": "rack-1"},
+		},
+		{
+			name:  "overrides the template node attributes",
+			attrs: map[string]interface{}{"data": "hotter", "rack": "rack-1"},
+			want:  map[string]string{"data": "hotter", "rack": "rack-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topology := map[string]interface{}{"id": "hot_content", "size": "8g"}
+			if tt.attrs != nil {
+				topology["node_attributes"] = tt.attrs
+			}
+			got, err := expandEsResource(map[string]interface{}{
+				"ref_id":   "main-elasticsearch",
+				"topology": []interface{}{topology},
+			}, ioOptimizedTpl())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			elem, err := matchEsTopologyID("hot_content", got.Plan.ClusterTopology)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, elem.Elasticsearch.NodeAttributes)
+		})
+	}
+}
+
+func Test_validateAutoscalingLimits(t *testing.T) {
+	newTpl := func(name string) *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-"+name+".json")),
+			name, "7.11.1", true,
+		)
+	}
+	tests := []struct {
+		name         string
+		tpl          string
+		es           map[string]interface{}
+		wantTplLimit bool
+		err          error
+	}{
+		{
+			name: "fails when the template has no autoscaling limits and autoscaling is enabled",
+			tpl:  "aws-cross-cluster-search-v2",
+			es: map[string]interface{}{
+				"autoscale": "true",
+				"topology":  []interface{}{map[string]interface{}{"id": "hot_content", "size": "2g"}},
+			},
+			err: multierror.NewPrefixed("invalid autoscaling configuration",
+				errors.New(`elasticsearch topology hot_content: autoscaling is enabled but the "aws-cross-cluster-search-v2" deployment template doesn't declare its autoscaling limits, set "autoscaling.max_size" or disable autoscaling`),
+			),
+		},
+		{
+			name: "accepts a template without autoscaling limits when these are configured",
+			tpl:  "aws-cross-cluster-search-v2",
+			es: map[string]interface{}{
+				"autoscale": "true",
+				"topology": []interface{}{map[string]interface{}{
+					"id":   "hot_content",
+					"size": "2g",
+					"autoscaling": []interface{}{map[string]interface{}{
+						"max_size": "8g",
+					}},
+				}},
+			},
+		},
+		{
+			name: "accepts a template without autoscaling limits when autoscaling is disabled",
+			tpl:  "aws-cross-cluster-search-v2",
+			es: map[string]interface{}{
+				"topology": []interface{}{map[string]interface{}{"id": "hot_content", "size": "2g"}},
+			},
+		},
+		{
+			name: "accepts a template with autoscaling limits",
+			tpl:  "aws-io-optimized-v2",
+			es: map[string]interface{}{
+				"autoscale": "true",
+				"topology":  []interface{}{map[string]interface{}{"id": "hot_content", "size": "8g"}},
+			},
+			wantTplLimit: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl := newTpl(tt.tpl)
+			assert.Equal(t, tt.wantTplLimit, hasAutoscalingLimits(tpl))
+
+			tt.es["ref_id"] = "main-elasticsearch"
+			es, err := expandEsResource(tt.es, tpl)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = validateAutoscalingLimits([]*models.ElasticsearchPayload{es}, tt.tpl)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_expandEsResourcesMultiple(t *testing.T) {
+	tpl := enrichElasticsearchTemplate(
+		esResource(parseDeploymentTemplate(t, "testdata/template-aws-io-optimized-v2.json")),
+		"aws-io-optimized-v2",
+		"7.11.1",
+		true,
+	)
+	ess := []interface{}{
+		map[string]interface{}{
+			"ref_id":    "main-elasticsearch",
+			"autoscale": "true",
+			"topology": []interface{}{
+				map[string]interface{}{"id": "hot_content", "size": "8g"},
+			},
+		},
+		map[string]interface{}{
+			"ref_id":    "secondary-elasticsearch",
+			"autoscale": "false",
+			"topology": []interface{}{
+				map[string]interface{}{"id": "hot_content", "size": "4g"},
+			},
+		},
+	}
+
+	got, err := expandEsResources(ess, tpl)
+	if !assert.NoError(t, err) || !assert.Len(t, got, 2) {
+		return
+	}
+
+	assert.Equal(t, ec.String("main-elasticsearch"), got[0].RefID)
+	assert.Equal(t, ec.Bool(true), got[0].Plan.AutoscalingEnabled)
+	assert.Equal(t, ec.String("secondary-elasticsearch"), got[1].RefID)
+	assert.Equal(t, ec.Bool(false), got[1].Plan.AutoscalingEnabled)
+	for i, want := range []int32{8192, 4096} {
+		for _, topology := range got[i].Plan.ClusterTopology {
+			if topology.ID == "hot_content" {
+				assert.Equal(t, ec.Int32(want), topology.Size.Value)
+			}
+		}
+	}
+}
+
+func Test_expandEsResourceTierSizes(t *testing.T) {
+	hotWarmTpl := func() *models.ElasticsearchPayload {
+		return enrichElasticsearchTemplate(
+			esResource(parseDeploymentTemplate(t, "testdata/template-aws-hot-warm-v2.json")),
+			"aws-hot-warm-v2",
+			"7.11.1",
+			true,
+		)
+	}
+	tests := []struct {
+		name string
+		es   map[string]interface{}
+		want map[string]int32
+	}{
+		{
+			name: "allows a warm tier larger than the hot tier",
+			es: map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"region": "us-east-1",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content", "size": "4g"},
+					map[string]interface{}{"id": "warm", "size": "16g"},
+				},
+			},
+			want: map[string]int32{"hot_content": 4096, "warm": 16384},
+		},
+		{
+			name: "allows a cold tier larger than the hot and warm tiers",
+			es: map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"region": "us-east-1",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "cold", "size": "8g"},
+					map[string]interface{}{"id": "hot_content", "size": "2g"},
+					map[string]interface{}{"id": "warm", "size": "4g"},
+				},
+			},
+			want: map[string]int32{"cold": 8192, "hot_content": 2048, "warm": 4096},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEsResource(tt.es, hotWarmTpl())
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, got.Validate(strfmt.Default))
+
+			for _, topology := range got.Plan.ClusterTopology {
+				if want, ok := tt.want[topology.ID]; ok {
+					assert.Equal(t, ec.Int32(want), topology.Size.Value, topology.ID)
+				}
+			}
+		})
+	}
+}
+
+func Test_matchSizeResource(t *testing.T) {
+	type args struct {
+		size    *models.TopologySize
+		tplSize *models.TopologySize
+	}
+	tests := []struct {
+		name string
+		args args
+		want *models.TopologySize
+		err  error
+	}{
+		{
+			name: "keeps the size when the template has no size",
+			args: args{
+				size: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+			},
+			want: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+		},
+		{
+			name: "keeps the size when the resources match",
+			args: args{
+				size:    &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+				tplSize: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(0)},
+			},
+			want: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+		},
+		{
+			name: "uses the template resource when the resource isn't set",
+			args: args{
+				size:    &models.TopologySize{Value: ec.Int32(2048)},
+				tplSize: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(0)},
+			},
+			want: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+		},
+		{
+			name: "defaults to the memory resource when neither the resource nor the template one are set",
+			args: args{
+				size: &models.TopologySize{Value: ec.Int32(2048)},
+			},
+			want: &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(2048)},
+		},
+		{
+			name: "returns an error when the memory resource is set and doesn't match",
+			args: args{
+				size:    &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(2048)},
+				tplSize: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(0)},
+			},
+			want: &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(2048)},
+			err:  errors.New(`size_resource "memory" doesn't match the deployment template size resource "storage"`),
+		},
+		{
+			name: "returns an error when the resources don't match",
+			args: args{
+				size:    &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+				tplSize: &models.TopologySize{Resource: ec.String("memory"), Value: ec.Int32(1024)},
+			},
+			want: &models.TopologySize{Resource: ec.String("storage"), Value: ec.Int32(2048)},
+			err:  errors.New(`size_resource "storage" doesn't match the deployment template size resource "memory"`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := matchSizeResource(tt.args.size, tt.args.tplSize)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, tt.args.size)
+		})
+	}
+}
+
+func Test_expandEsConfig(t *testing.T) {
+	repositorySettings := `{
+		"s3": {
+			"client": {
+				"searchable": {
+					"endpoint": "s3.us-east-1.amazonaws.com",
+					"access_key": "some-access-key",
+					"max_retries": 3,
+					"path_style_access": true
+				}
+			}
+		},
+		"xpack.searchable.snapshot.shared_cache.size": "90%"
+	}`
+	tests := []struct {
+		name     string
+		raw      []interface{}
+		want     *models.ElasticsearchConfiguration
+		wantJSON string
+		err      error
+	}{
+		{
+			name: "preserves the structure of nested repository settings",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_json": repositorySettings,
+			}},
+			want: &models.ElasticsearchConfiguration{
+				UserSettingsJSON: map[string]interface{}{
+					"s3": map[string]interface{}{
+						"client": map[string]interface{}{
+							"searchable": map[string]interface{}{
+								"endpoint":          "s3.us-east-1.amazonaws.com",
+								"access_key":        "some-access-key",
+								"max_retries":       float64(3),
+								"path_style_access": true,
+							},
+						},
+					},
+					"xpack.searchable.snapshot.shared_cache.size": "90%",
+				},
+			},
+			wantJSON: `{"user_settings_json":{"s3":{"client":{"searchable":{"access_key":"some-access-key","endpoint":"s3.us-east-1.amazonaws.com","max_retries":3,"path_style_access":true}}},"xpack.searchable.snapshot.shared_cache.size":"90%"}}`,
+		},
+		{
+			name: "expands the security settings into the user settings overrides",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_override_json": `{"some.setting": "override"}`,
+				"anonymous_access_roles":      schema.NewSet(schema.HashString, []interface{}{"viewer"}),
+				"anonymous_access_username":   "anonymous_user",
+			}},
+			want: &models.ElasticsearchConfiguration{
+				UserSettingsOverrideJSON: map[string]interface{}{
+					"some.setting":                            "override",
+					"xpack.security.authc.anonymous.roles":    []string{"viewer"},
+					"xpack.security.authc.anonymous.username": "anonymous_user",
+				},
+			},
+			wantJSON: `{"user_settings_override_json":{"some.setting":"override","xpack.security.authc.anonymous.roles":["viewer"],"xpack.security.authc.anonymous.username":"anonymous_user"}}`,
+		},
+		{
+			name: "leaves the user settings overrides unset without security settings",
+			raw: []interface{}{map[string]interface{}{
+				"anonymous_access_roles":    schema.NewSet(schema.HashString, nil),
+				"anonymous_access_username": "",
+			}},
+			want: &models.ElasticsearchConfiguration{},
+		},
+		{
+			name: "fails expanding an invalid user_settings_json",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_json": `{"s3": {"client": }}`,
+			}},
+			want: &models.ElasticsearchConfiguration{},
+			err:  errors.New("failed expanding elasticsearch user_settings_json: invalid character '}' looking for beginning of value"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.ElasticsearchConfiguration
+			err := expandEsConfig(tt.raw, &got)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, &got)
+			if tt.wantJSON != "" {
+				b, err := got.MarshalBinary()
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.wantJSON, string(b))
+			}
 		})
 	}
 }
@@ -18,11 +18,12 @@
 package deploymentresource
 
 import (
-	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -48,6 +49,8 @@ func flattenEsResources(in []*models.ElasticsearchResourceInfo, name string, rem
 			m["ref_id"] = *res.RefID
 		}
 
+		m["kind"] = "elasticsearch"
+
 		if res.Region != nil {
 			m["region"] = *res.Region
 		}
@@ -65,8 +68,13 @@ func flattenEsResources(in []*models.ElasticsearchResourceInfo, name string, rem
 			m["autoscale"] = strconv.FormatBool(*plan.AutoscalingEnabled)
 		}
 
+		// Always set, since the unset value doesn't match the default.
+		m["include_remote_cluster_client"] = hasRemoteClusterClientRole(plan.ClusterTopology)
+
 		if meta := res.Info.Metadata; meta != nil && meta.CloudID != "" {
 			m["cloud_id"] = meta.CloudID
+		} else if cloudID := buildCloudID(name, res.Info); cloudID != "" {
+			m["cloud_id"] = cloudID
 		}
 
 		for k, v := range util.FlattenClusterEndpoint(res.Info.Metadata) {
@@ -93,6 +101,10 @@ func flattenEsResources(in []*models.ElasticsearchResourceInfo, name string, rem
 		}
 
 		if settings := res.Info.Settings; settings != nil {
+			if settings.DedicatedMastersThreshold > 0 {
+				m["dedicated_masters_threshold"] = int(settings.DedicatedMastersThreshold)
+			}
+
 			if trust := flattenAccountTrust(settings.Trust); trust != nil {
 				m["trust_account"] = trust
 			}
@@ -108,6 +120,170 @@ func flattenEsResources(in []*models.ElasticsearchResourceInfo, name string, rem
 	return result, nil
 }
 
+// setPlanStrategy sets the "plan_strategy" of the flattened Elasticsearch
+// resources from the prior state, since the transient plan settings aren't
+// part of the read plan.
+func setPlanStrategy(es, prior []interface{}) {
+	forEachPrior(es, prior, "ref_id", func(m, priorM map[string]interface{}) {
+		if strategy, _ := priorM["plan_strategy"].(string); strategy != "" {
+			m["plan_strategy"] = strategy
+		}
+	})
+}
+
+// setDedicatedMasters sets the "dedicated_masters" setting of the flattened
+// Elasticsearch resources from the prior state, since it can't be told apart
+// from a sized master tier when read. When set, the master tier is left out
+// of the flattened topology unless the prior state declares it, so the tier
+// added by the setting doesn't show as a diff.
+func setDedicatedMasters(es, prior []interface{}) {
+	forEachPrior(es, prior, "ref_id", func(m, priorM map[string]interface{}) {
+		if dedicated, _ := priorM["dedicated_masters"].(bool); !dedicated {
+			return
+		}
+		m["dedicated_masters"] = true
+
+		priorTopologies, _ := priorM["topology"].([]interface{})
+		if hasTopologyElement(priorTopologies, dedicatedMastersTier) {
+			return
+		}
+
+		topologies, _ := m["topology"].([]interface{})
+		filtered := make([]interface{}, 0, len(topologies))
+		for _, rawTop := range topologies {
+			if !hasTopologyElement([]interface{}{rawTop}, dedicatedMastersTier) {
+				filtered = append(filtered, rawTop)
+			}
+		}
+		m["topology"] = filtered
+	})
+}
+
+// hasTopologyElement returns true when any of the flattened topology elements
+// has the given ID.
+func hasTopologyElement(topologies []interface{}, id string) bool {
+	for _, rawTop := range topologies {
+		if topology, ok := rawTop.(map[string]interface{}); ok && topology["id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenAutoscale returns whether autoscaling is enabled on the current plan
+// of the first running Elasticsearch resource, or nil when it can't be told.
+func flattenAutoscale(in []*models.ElasticsearchResourceInfo) *bool {
+	for _, res := range in {
+		if util.IsCurrentEsPlanEmpty(res) || isEsResourceStopped(res) {
+			continue
+		}
+
+		if enabled := res.Info.PlanInfo.Current.Plan.AutoscalingEnabled; enabled != nil {
+			return enabled
+		}
+	}
+	return nil
+}
+
+// setAutoscalingDisabled sets the "disabled" autoscaling setting of the
+// flattened Elasticsearch topology elements from the prior state, since it
+// can't be told apart from autoscaling sizes which equal the size when read.
+func setAutoscalingDisabled(es, prior []interface{}) {
+	forEachPriorTopology(es, prior, func(topology, priorTopology map[string]interface{}) {
+		if !autoscalingDisabled(priorTopology) {
+			return
+		}
+
+		autoscaling, _ := topology["autoscaling"].([]interface{})
+		for _, rawAutoscale := range autoscaling {
+			if autoscale, ok := rawAutoscale.(map[string]interface{}); ok {
+				autoscale["disabled"] = true
+			}
+		}
+	})
+}
+
+// autoscalingDisabled returns true when the flattened topology element has
+// its autoscaling disabled.
+func autoscalingDisabled(topology map[string]interface{}) bool {
+	autoscaling, _ := topology["autoscaling"].([]interface{})
+	for _, rawAutoscale := range autoscaling {
+		if autoscale, ok := rawAutoscale.(map[string]interface{}); ok {
+			if disabled, _ := autoscale["disabled"].(bool); disabled {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setNodeAttributes keeps only the node attributes of the flattened
+// Elasticsearch topology elements which are set in the prior state, since the
+// ones declared in the deployment template can't be told apart from the
+// configured ones when read.
+func setNodeAttributes(es, prior []interface{}) {
+	forEachPriorTopology(es, prior, func(topology, priorTopology map[string]interface{}) {
+		configured, _ := priorTopology["node_attributes"].(map[string]interface{})
+		attrs, _ := topology["node_attributes"].(map[string]interface{})
+		for k := range attrs {
+			if _, ok := configured[k]; !ok {
+				delete(attrs, k)
+			}
+		}
+
+		if len(attrs) == 0 {
+			delete(topology, "node_attributes")
+		}
+	})
+}
+
+// hasRemoteClusterClientRole returns false when none of the topology elements
+// which use node_roles has the "remote_cluster_client" role.
+func hasRemoteClusterClientRole(topologies []*models.ElasticsearchClusterTopologyElement) bool {
+	var usesNodeRoles bool
+	for _, topology := range topologies {
+		if len(topology.NodeRoles) == 0 {
+			continue
+		}
+		usesNodeRoles = true
+		for _, role := range topology.NodeRoles {
+			if role == remoteClusterClientRole {
+				return true
+			}
+		}
+	}
+	return !usesNodeRoles
+}
+
+// buildCloudID derives the Elasticsearch Cloud ID from the cluster metadata
+// when the API doesn't return one. The format is the same one used by Elastic
+// Cloud: "<name>:base64(<domain>:<port>$<es_id>$<kibana_id>)".
+func buildCloudID(name string, info *models.ElasticsearchClusterInfo) string {
+	if info == nil || info.ClusterID == nil || *info.ClusterID == "" {
+		return ""
+	}
+
+	meta := info.Metadata
+	if meta == nil || meta.Endpoint == "" || meta.Ports == nil || meta.Ports.HTTPS == nil {
+		return ""
+	}
+
+	domain := strings.TrimPrefix(meta.Endpoint, *info.ClusterID+".")
+	if domain == meta.Endpoint {
+		return ""
+	}
+
+	encoded := fmt.Sprintf("%s:%d$%s", domain, *meta.Ports.HTTPS, *info.ClusterID)
+	for _, kibana := range info.AssociatedKibanaClusters {
+		if kibana.KibanaID != nil && *kibana.KibanaID != "" {
+			encoded += "$" + *kibana.KibanaID
+			break
+		}
+	}
+
+	return name + ":" + base64.StdEncoding.EncodeToString([]byte(encoded))
+}
+
 func isPotentiallySizedTopology(topology *models.ElasticsearchClusterTopologyElement, isAutoscaling bool) bool {
 	currentlySized := topology.Size != nil && topology.Size.Value != nil && *topology.Size.Value > 0
 	canBeSized := isAutoscaling && topology.AutoscalingMax != nil && topology.AutoscalingMax.Value != nil && *topology.AutoscalingMax.Value > 0
@@ -136,7 +312,7 @@ func flattenEsTopology(plan *models.ElasticsearchClusterPlan) ([]interface{}, er
 		// }
 
 		if topology.Size != nil {
-			m["size"] = util.MemoryToState(*topology.Size.Value)
+			m["size"] = util.SizeToState(*topology.Size.Value)
 			m["size_resource"] = *topology.Size.Resource
 		}
 
@@ -160,6 +336,14 @@ func flattenEsTopology(plan *models.ElasticsearchClusterPlan) ([]interface{}, er
 			}
 		}
 
+		if es := topology.Elasticsearch; es != nil && len(es.NodeAttributes) > 0 {
+			attrs := make(map[string]interface{}, len(es.NodeAttributes))
+			for k, v := range es.NodeAttributes {
+				attrs[k] = v
+			}
+			m["node_attributes"] = attrs
+		}
+
 		if len(topology.NodeRoles) > 0 {
 			m["node_roles"] = schema.NewSet(schema.HashString, util.StringToItems(
 				topology.NodeRoles...,
@@ -167,14 +351,24 @@ func flattenEsTopology(plan *models.ElasticsearchClusterPlan) ([]interface{}, er
 		}
 
 		autoscaling := make(map[string]interface{})
+		// Both the size and its resource type are persisted, otherwise tiers
+		// which autoscale on storage would show a diff on every plan.
 		if ascale := topology.AutoscalingMax; ascale != nil {
-			autoscaling["max_size_resource"] = *ascale.Resource
-			autoscaling["max_size"] = util.MemoryToState(*ascale.Value)
+			if ascale.Resource != nil {
+				autoscaling["max_size_resource"] = *ascale.Resource
+			}
+			if ascale.Value != nil {
+				autoscaling["max_size"] = util.SizeToState(*ascale.Value)
+			}
 		}
 
 		if ascale := topology.AutoscalingMin; ascale != nil {
-			autoscaling["min_size_resource"] = *ascale.Resource
-			autoscaling["min_size"] = util.MemoryToState(*ascale.Value)
+			if ascale.Resource != nil {
+				autoscaling["min_size_resource"] = *ascale.Resource
+			}
+			if ascale.Value != nil {
+				autoscaling["min_size"] = util.SizeToState(*ascale.Value)
+			}
 		}
 
 		if topology.AutoscalingPolicyOverrideJSON != nil {
@@ -219,25 +413,9 @@ func flattenEsConfig(cfg *models.ElasticsearchConfiguration) []interface{} {
 		)
 	}
 
-	if cfg.UserSettingsYaml != "" {
-		m["user_settings_yaml"] = cfg.UserSettingsYaml
-	}
-
-	if cfg.UserSettingsOverrideYaml != "" {
-		m["user_settings_override_yaml"] = cfg.UserSettingsOverrideYaml
-	}
-
-	if o := cfg.UserSettingsJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_json"] = string(b)
-		}
-	}
-
-	if o := cfg.UserSettingsOverrideJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_override_json"] = string(b)
-		}
-	}
+	flattenUserSettings(m, cfg.UserSettingsYaml, cfg.UserSettingsOverrideYaml,
+		cfg.UserSettingsJSON, cfg.UserSettingsOverrideJSON,
+	)
 
 	if cfg.DockerImage != "" {
 		m["docker_image"] = cfg.DockerImage
@@ -252,6 +430,62 @@ func flattenEsConfig(cfg *models.ElasticsearchConfiguration) []interface{} {
 	return []interface{}{m}
 }
 
+// setEsSecuritySettings moves the anonymous access settings of the flattened
+// Elasticsearch resources out of "user_settings_override_json" into their
+// typed settings, but only the ones which are set through the typed settings
+// in the prior state. Settings configured through "user_settings_override_json"
+// are kept there, so neither way of setting them shows as a perpetual diff.
+func setEsSecuritySettings(es, prior []interface{}) {
+	forEachPrior(es, prior, "ref_id", func(m, priorM map[string]interface{}) {
+		cfg := firstConfig(m)
+		priorCfg := firstConfig(priorM)
+		if cfg == nil || priorCfg == nil {
+			return
+		}
+
+		rawOverrides, _ := cfg["user_settings_override_json"].(string)
+		var overrides map[string]interface{}
+		if err := json.Unmarshal([]byte(rawOverrides), &overrides); err != nil {
+			return
+		}
+
+		if roles, ok := priorCfg["anonymous_access_roles"].(*schema.Set); ok && roles.Len() > 0 {
+			if items := settingItems(overrides[anonymousRolesSetting]); len(items) > 0 {
+				cfg["anonymous_access_roles"] = schema.NewSet(schema.HashString, items)
+				delete(overrides, anonymousRolesSetting)
+			}
+		}
+
+		if username, _ := priorCfg["anonymous_access_username"].(string); username != "" {
+			if v, ok := overrides[anonymousUsernameSetting].(string); ok {
+				cfg["anonymous_access_username"] = v
+				delete(overrides, anonymousUsernameSetting)
+			}
+		}
+
+		if len(overrides) == 0 {
+			delete(cfg, "user_settings_override_json")
+			return
+		}
+
+		if b, err := json.Marshal(overrides); err == nil {
+			cfg["user_settings_override_json"] = string(b)
+		}
+	})
+}
+
+// settingItems returns the items of a list setting, which may be set as a
+// single string.
+func settingItems(setting interface{}) []interface{} {
+	switch s := setting.(type) {
+	case []interface{}:
+		return s
+	case string:
+		return util.StringToItems(s)
+	}
+	return nil
+}
+
 func flattenEsRemotes(in models.RemoteResources) *schema.Set {
 	res := newElasticsearchRemoteSet()
 	for _, r := range in.Resources {
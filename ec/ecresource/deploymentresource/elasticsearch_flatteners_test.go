@@ -18,6 +18,7 @@
 package deploymentresource
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
@@ -149,13 +150,15 @@ func Test_flattenEsResource(t *testing.T) {
 			}},
 			want: []interface{}{
 				map[string]interface{}{
-					"ref_id":         "main-elasticsearch",
-					"resource_id":    mock.ValidClusterID,
-					"region":         "some-region",
-					"cloud_id":       "some CLOUD ID",
-					"http_endpoint":  "http://somecluster.cloud.elastic.co:9200",
-					"https_endpoint": "https://somecluster.cloud.elastic.co:9243",
-					"config":         func() []interface{} { return nil }(),
+					"ref_id":                        "main-elasticsearch",
+					"include_remote_cluster_client": true,
+					"kind":                          "elasticsearch",
+					"resource_id":                   mock.ValidClusterID,
+					"region":                        "some-region",
+					"cloud_id":                      "some CLOUD ID",
+					"http_endpoint":                 "http://somecluster.cloud.elastic.co:9200",
+					"https_endpoint":                "https://somecluster.cloud.elastic.co:9243",
+					"config":                        func() []interface{} { return nil }(),
 					"topology": []interface{}{
 						map[string]interface{}{
 							"config":                    func() []interface{} { return nil }(),
@@ -227,11 +230,13 @@ func Test_flattenEsResource(t *testing.T) {
 				},
 			}},
 			want: []interface{}{map[string]interface{}{
-				"ref_id":         "main-elasticsearch",
-				"resource_id":    mock.ValidClusterID,
-				"region":         "some-region",
-				"http_endpoint":  "http://othercluster.cloud.elastic.co:9200",
-				"https_endpoint": "https://othercluster.cloud.elastic.co:9243",
+				"ref_id":                        "main-elasticsearch",
+				"include_remote_cluster_client": true,
+				"kind":                          "elasticsearch",
+				"resource_id":                   mock.ValidClusterID,
+				"region":                        "some-region",
+				"http_endpoint":                 "http://othercluster.cloud.elastic.co:9200",
+				"https_endpoint":                "https://othercluster.cloud.elastic.co:9243",
 				"config": []interface{}{map[string]interface{}{
 					"user_settings_yaml":          "some.setting: value",
 					"user_settings_override_yaml": "some.setting: value2",
@@ -252,6 +257,155 @@ func Test_flattenEsResource(t *testing.T) {
 				}},
 			}},
 		},
+		{
+			name: "parses multiple elasticsearch resources with their own tiers and autoscaling",
+			args: args{in: []*models.ElasticsearchResourceInfo{
+				{
+					Region: ec.String("some-region"),
+					RefID:  ec.String("main-elasticsearch"),
+					Info: &models.ElasticsearchClusterInfo{
+						ClusterID: ec.String("main-cluster-id"),
+						Region:    "some-region",
+						Status:    ec.String("started"),
+						PlanInfo: &models.ElasticsearchClusterPlansInfo{
+							Current: &models.ElasticsearchClusterPlanInfo{
+								Plan: &models.ElasticsearchClusterPlan{
+									AutoscalingEnabled: ec.Bool(true),
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										Version: "7.12.0",
+									},
+									ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+										{
+											ID:                      "hot_content",
+											ZoneCount:               2,
+											InstanceConfigurationID: "aws.data.highio.i3",
+											Size: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(4096),
+											},
+											AutoscalingMax: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(8192),
+											},
+										},
+										{
+											ID:                      "warm",
+											ZoneCount:               1,
+											InstanceConfigurationID: "aws.data.highstorage.d3",
+											AutoscalingMax: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(16384),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Region: ec.String("some-region"),
+					RefID:  ec.String("secondary-elasticsearch"),
+					Info: &models.ElasticsearchClusterInfo{
+						ClusterID: ec.String("secondary-cluster-id"),
+						Region:    "some-region",
+						Status:    ec.String("started"),
+						PlanInfo: &models.ElasticsearchClusterPlansInfo{
+							Current: &models.ElasticsearchClusterPlanInfo{
+								Plan: &models.ElasticsearchClusterPlan{
+									AutoscalingEnabled: ec.Bool(false),
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										Version: "7.12.0",
+									},
+									ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+										{
+											ID:                      "hot_content",
+											ZoneCount:               1,
+											InstanceConfigurationID: "aws.data.highio.i3",
+											Size: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(2048),
+											},
+											AutoscalingMax: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(4096),
+											},
+										},
+										{
+											ID:                      "warm",
+											ZoneCount:               1,
+											InstanceConfigurationID: "aws.data.highstorage.d3",
+											AutoscalingMax: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(16384),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}},
+			want: []interface{}{
+				map[string]interface{}{
+					"ref_id":                        "main-elasticsearch",
+					"include_remote_cluster_client": true,
+					"kind":                          "elasticsearch",
+					"resource_id":                   "main-cluster-id",
+					"region":                        "some-region",
+					"autoscale":                     "true",
+					"config":                        func() []interface{} { return nil }(),
+					"topology": []interface{}{
+						map[string]interface{}{
+							"config":                    func() []interface{} { return nil }(),
+							"id":                        "hot_content",
+							"instance_configuration_id": "aws.data.highio.i3",
+							"size":                      "4g",
+							"size_resource":             "memory",
+							"zone_count":                int32(2),
+							"autoscaling": []interface{}{map[string]interface{}{
+								"max_size":          "8g",
+								"max_size_resource": "memory",
+							}},
+						},
+						map[string]interface{}{
+							"config":                    func() []interface{} { return nil }(),
+							"id":                        "warm",
+							"instance_configuration_id": "aws.data.highstorage.d3",
+							"zone_count":                int32(1),
+							"autoscaling": []interface{}{map[string]interface{}{
+								"max_size":          "16g",
+								"max_size_resource": "memory",
+							}},
+						},
+					},
+				},
+				map[string]interface{}{
+					"ref_id":                        "secondary-elasticsearch",
+					"include_remote_cluster_client": true,
+					"kind":                          "elasticsearch",
+					"resource_id":                   "secondary-cluster-id",
+					"region":                        "some-region",
+					"autoscale":                     "false",
+					"config":                        func() []interface{} { return nil }(),
+					"topology": []interface{}{
+						map[string]interface{}{
+							"config":                    func() []interface{} { return nil }(),
+							"id":                        "hot_content",
+							"instance_configuration_id": "aws.data.highio.i3",
+							"size":                      "2g",
+							"size_resource":             "memory",
+							"zone_count":                int32(1),
+							"autoscaling": []interface{}{map[string]interface{}{
+								"max_size":          "4g",
+								"max_size_resource": "memory",
+							}},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -377,6 +531,146 @@ func Test_flattenEsTopology(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "flattens a storage-resourced autoscaling max",
+			args: args{plan: &models.ElasticsearchClusterPlan{
+				AutoscalingEnabled: ec.Bool(true),
+				ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+					{
+						ID:                      "hot_content",
+						ZoneCount:               2,
+						InstanceConfigurationID: "aws.data.highio.i3",
+						Size: &models.TopologySize{
+							Value: ec.Int32(4096), Resource: ec.String("memory"),
+						},
+						AutoscalingMax: &models.TopologySize{
+							Value: ec.Int32(2097152), Resource: ec.String("storage"),
+						},
+					},
+					{
+						ID:                      "warm",
+						ZoneCount:               1,
+						InstanceConfigurationID: "aws.data.highstorage.d3",
+						Size: &models.TopologySize{
+							Value: ec.Int32(0), Resource: ec.String("memory"),
+						},
+						AutoscalingMax: &models.TopologySize{
+							Value: ec.Int32(4194304), Resource: ec.String("storage"),
+						},
+					},
+				},
+			}},
+			want: []interface{}{
+				map[string]interface{}{
+					"config":                    func() []interface{} { return nil }(),
+					"id":                        "hot_content",
+					"instance_configuration_id": "aws.data.highio.i3",
+					"size":                      "4g",
+					"size_resource":             "memory",
+					"zone_count":                int32(2),
+					"autoscaling": []interface{}{
+						map[string]interface{}{
+							"max_size":          "2t",
+							"max_size_resource": "storage",
+						},
+					},
+				},
+				map[string]interface{}{
+					"config":                    func() []interface{} { return nil }(),
+					"id":                        "warm",
+					"instance_configuration_id": "aws.data.highstorage.d3",
+					"size":                      "0g",
+					"size_resource":             "memory",
+					"zone_count":                int32(1),
+					"autoscaling": []interface{}{
+						map[string]interface{}{
+							"max_size":          "4t",
+							"max_size_resource": "storage",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "reconstructs the autoscaling sizes from the MB values",
+			args: args{plan: &models.ElasticsearchClusterPlan{
+				AutoscalingEnabled: ec.Bool(true),
+				ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+					{
+						ID:                      "hot_content",
+						ZoneCount:               2,
+						InstanceConfigurationID: "aws.data.highio.i3",
+						Size: &models.TopologySize{
+							Value: ec.Int32(8192), Resource: ec.String("memory"),
+						},
+						AutoscalingMax: &models.TopologySize{
+							Value: ec.Int32(118784), Resource: ec.String("memory"),
+						},
+						AutoscalingMin: &models.TopologySize{
+							Value: ec.Int32(1536), Resource: ec.String("memory"),
+						},
+					},
+				},
+			}},
+			want: []interface{}{
+				map[string]interface{}{
+					"config":                    func() []interface{} { return nil }(),
+					"id":                        "hot_content",
+					"instance_configuration_id": "aws.data.highio.i3",
+					"size":                      "8g",
+					"size_resource":             "memory",
+					"zone_count":                int32(2),
+					"autoscaling": []interface{}{
+						map[string]interface{}{
+							"max_size":          "116g",
+							"max_size_resource": "memory",
+							"min_size":          "1.5g",
+							"min_size_resource": "memory",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "reconstructs the size strings in their largest whole unit",
+			args: args{plan: &models.ElasticsearchClusterPlan{
+				AutoscalingEnabled: ec.Bool(true),
+				ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+					{
+						ID:                      "hot_content",
+						ZoneCount:               1,
+						InstanceConfigurationID: "aws.data.highio.i3",
+						Size: &models.TopologySize{
+							Value: ec.Int32(512), Resource: ec.String("memory"),
+						},
+						AutoscalingMax: &models.TopologySize{
+							Value: ec.Int32(2097152), Resource: ec.String("storage"),
+						},
+						AutoscalingMin: &models.TopologySize{
+							Value: ec.Int32(8192), Resource: ec.String("memory"),
+						},
+					},
+				},
+			}},
+			want: []interface{}{
+				map[string]interface{}{
+					"config":                    func() []interface{} { return nil }(),
+					"id":                        "hot_content",
+					"instance_configuration_id": "aws.data.highio.i3",
+					"size":                      "0.5g",
+					"size_resource":             "memory",
+					"zone_count":                int32(1),
+					"autoscaling": []interface{}{
+						map[string]interface{}{
+							"max_size":          "2t",
+							"max_size_resource": "storage",
+							"min_size":          "8g",
+							"min_size_resource": "memory",
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -389,6 +683,35 @@ func Test_flattenEsTopology(t *testing.T) {
 	}
 }
 
+func Test_flattenEsTopologyNodeRolesOrder(t *testing.T) {
+	newPlan := func(roles ...string) *models.ElasticsearchClusterPlan {
+		return &models.ElasticsearchClusterPlan{
+			ClusterTopology: []*models.ElasticsearchClusterTopologyElement{{
+				ID:                      "hot_content",
+				ZoneCount:               1,
+				InstanceConfigurationID: "aws.data.highio.i3",
+				Size: &models.TopologySize{
+					Value: ec.Int32(4096), Resource: ec.String("memory"),
+				},
+				NodeRoles: roles,
+			}},
+		}
+	}
+	nodeRoles := func(plan *models.ElasticsearchClusterPlan) *schema.Set {
+		got, err := flattenEsTopology(plan)
+		if !assert.NoError(t, err) || !assert.Len(t, got, 1) {
+			return nil
+		}
+		return got[0].(map[string]interface{})["node_roles"].(*schema.Set)
+	}
+
+	want := nodeRoles(newPlan("data_content", "data_hot", "ingest", "master", "remote_cluster_client", "transform"))
+	got := nodeRoles(newPlan("transform", "master", "remote_cluster_client", "data_hot", "ingest", "data_content"))
+
+	assert.True(t, want.Equal(got))
+	assert.Equal(t, want.List(), got.List())
+}
+
 func Test_flattenEsConfig(t *testing.T) {
 	type args struct {
 		cfg *models.ElasticsearchConfiguration
@@ -407,6 +730,44 @@ func Test_flattenEsConfig(t *testing.T) {
 				"plugins": []interface{}{"some-allowed-plugin"},
 			}},
 		},
+		{
+			name: "flattens the user settings set server side",
+			args: args{cfg: &models.ElasticsearchConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON:         map[string]interface{}{"some.setting": "value"},
+				UserSettingsOverrideJSON: map[string]interface{}{},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"plugins":                     []interface{}(nil),
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+			}},
+		},
+		{
+			name: "flattens the custom docker image set server side",
+			args: args{cfg: &models.ElasticsearchConfiguration{
+				DockerImage: "docker.elastic.co/cloud-ci/elasticsearch:7.15.0-SNAPSHOT",
+			}},
+			want: []interface{}{map[string]interface{}{
+				"plugins":      []interface{}(nil),
+				"docker_image": "docker.elastic.co/cloud-ci/elasticsearch:7.15.0-SNAPSHOT",
+			}},
+		},
+		{
+			name: "keeps the security settings in the user settings overrides",
+			args: args{cfg: &models.ElasticsearchConfiguration{
+				UserSettingsOverrideJSON: map[string]interface{}{
+					"xpack.security.authc.anonymous.roles":    []interface{}{"viewer"},
+					"xpack.security.authc.anonymous.username": "anonymous_user",
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"plugins":                     []interface{}(nil),
+				"user_settings_override_json": `{"xpack.security.authc.anonymous.roles":["viewer"],"xpack.security.authc.anonymous.username":"anonymous_user"}`,
+			}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -423,3 +784,533 @@ func Test_flattenEsConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_setEsSecuritySettings(t *testing.T) {
+	const overrides = `{"some.setting":"override","xpack.security.authc.anonymous.roles":["viewer"],"xpack.security.authc.anonymous.username":"anonymous_user"}`
+	newEs := func(cfg map[string]interface{}) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-elasticsearch",
+			"config": []interface{}{cfg},
+		}}
+	}
+	tests := []struct {
+		name  string
+		prior []interface{}
+		want  map[string]interface{}
+	}{
+		{
+			name: "keeps the settings in the overrides without a prior state",
+			want: map[string]interface{}{"user_settings_override_json": overrides},
+		},
+		{
+			name: "keeps the settings in the overrides when set through them in the prior state",
+			prior: newEs(map[string]interface{}{
+				"user_settings_override_json": overrides,
+			}),
+			want: map[string]interface{}{"user_settings_override_json": overrides},
+		},
+		{
+			name: "moves the settings set through the typed settings in the prior state",
+			prior: newEs(map[string]interface{}{
+				"anonymous_access_roles":    schema.NewSet(schema.HashString, []interface{}{"viewer"}),
+				"anonymous_access_username": "anonymous_user",
+			}),
+			want: map[string]interface{}{
+				"anonymous_access_roles":      []interface{}{"viewer"},
+				"anonymous_access_username":   "anonymous_user",
+				"user_settings_override_json": `{"some.setting":"override"}`,
+			},
+		},
+		{
+			name: "moves only the settings set through the typed settings in the prior state",
+			prior: newEs(map[string]interface{}{
+				"anonymous_access_roles": schema.NewSet(schema.HashString, []interface{}{"viewer"}),
+			}),
+			want: map[string]interface{}{
+				"anonymous_access_roles":      []interface{}{"viewer"},
+				"user_settings_override_json": `{"some.setting":"override","xpack.security.authc.anonymous.username":"anonymous_user"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newEs(map[string]interface{}{"user_settings_override_json": overrides})
+			setEsSecuritySettings(got, tt.prior)
+
+			cfg := firstConfig(got[0])
+			if v, ok := cfg["anonymous_access_roles"]; ok {
+				cfg["anonymous_access_roles"] = v.(*schema.Set).List()
+			}
+			assert.Equal(t, tt.want, cfg)
+		})
+	}
+}
+
+func Test_setPlanStrategy(t *testing.T) {
+	newEs := func() []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-elasticsearch",
+		}}
+	}
+	tests := []struct {
+		name  string
+		prior []interface{}
+		want  []interface{}
+	}{
+		{
+			name: "leaves the resources untouched without a prior state",
+			want: newEs(),
+		},
+		{
+			name: "leaves the resources untouched without a prior plan_strategy",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":        "main-elasticsearch",
+				"plan_strategy": "",
+			}},
+			want: newEs(),
+		},
+		{
+			name: "sets the plan_strategy from the prior state",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":        "main-elasticsearch",
+				"plan_strategy": "rolling",
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id":        "main-elasticsearch",
+				"plan_strategy": "rolling",
+			}},
+		},
+		{
+			name: "leaves the resources untouched when the prior one has another ref_id",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":        "secondary-elasticsearch",
+				"plan_strategy": "rolling",
+			}},
+			want: newEs(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newEs()
+			setPlanStrategy(got, tt.prior)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_setDedicatedMasters(t *testing.T) {
+	newEs := func() []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-elasticsearch",
+			"topology": []interface{}{
+				map[string]interface{}{"id": "hot_content", "size": "8g"},
+				map[string]interface{}{"id": "master", "size": "1g"},
+			},
+		}}
+	}
+	tests := []struct {
+		name  string
+		prior []interface{}
+		want  []interface{}
+	}{
+		{
+			name: "leaves the resources untouched without a prior state",
+			want: newEs(),
+		},
+		{
+			name: "leaves the resources untouched without dedicated_masters",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":            "main-elasticsearch",
+				"dedicated_masters": false,
+			}},
+			want: newEs(),
+		},
+		{
+			name: "leaves out the master tier added by dedicated_masters",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":            "main-elasticsearch",
+				"dedicated_masters": true,
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content"},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id":            "main-elasticsearch",
+				"dedicated_masters": true,
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content", "size": "8g"},
+				},
+			}},
+		},
+		{
+			name: "keeps the master tier declared in the prior state",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":            "main-elasticsearch",
+				"dedicated_masters": true,
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content"},
+					map[string]interface{}{"id": "master"},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id":            "main-elasticsearch",
+				"dedicated_masters": true,
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content", "size": "8g"},
+					map[string]interface{}{"id": "master", "size": "1g"},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newEs()
+			setDedicatedMasters(got, tt.prior)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_flattenAutoscale(t *testing.T) {
+	newRes := func(status string, enabled *bool) *models.ElasticsearchResourceInfo {
+		return &models.ElasticsearchResourceInfo{Info: &models.ElasticsearchClusterInfo{
+			Status: ec.String(status),
+			PlanInfo: &models.ElasticsearchClusterPlansInfo{
+				Current: &models.ElasticsearchClusterPlanInfo{
+					Plan: &models.ElasticsearchClusterPlan{AutoscalingEnabled: enabled},
+				},
+			},
+		}}
+	}
+	tests := []struct {
+		name string
+		in   []*models.ElasticsearchResourceInfo
+		want *bool
+	}{
+		{
+			name: "returns nil without resources",
+		},
+		{
+			name: "returns nil when the plan doesn't set it",
+			in:   []*models.ElasticsearchResourceInfo{newRes("started", nil)},
+		},
+		{
+			name: "returns the autoscaling of the current plan",
+			in:   []*models.ElasticsearchResourceInfo{newRes("started", ec.Bool(true))},
+			want: ec.Bool(true),
+		},
+		{
+			name: "skips the stopped resources",
+			in: []*models.ElasticsearchResourceInfo{
+				newRes("stopped", ec.Bool(true)),
+				newRes("started", ec.Bool(false)),
+			},
+			want: ec.Bool(false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flattenAutoscale(tt.in))
+		})
+	}
+}
+
+func Test_setAutoscalingDisabled(t *testing.T) {
+	newEs := func() []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-elasticsearch",
+			"topology": []interface{}{
+				map[string]interface{}{
+					"id": "hot_content",
+					"autoscaling": []interface{}{map[string]interface{}{
+						"max_size": "116g",
+					}},
+				},
+				map[string]interface{}{
+					"id": "cold",
+					"autoscaling": []interface{}{map[string]interface{}{
+						"max_size": "2g",
+					}},
+				},
+			},
+		}}
+	}
+	tests := []struct {
+		name  string
+		prior []interface{}
+		want  []interface{}
+	}{
+		{
+			name: "leaves the topologies untouched without a prior state",
+			want: newEs(),
+		},
+		{
+			name: "sets disabled on the topologies which had it disabled",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id": "hot_content",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"disabled": false,
+						}},
+					},
+					map[string]interface{}{
+						"id": "cold",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"disabled": true,
+						}},
+					},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id": "hot_content",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"max_size": "116g",
+						}},
+					},
+					map[string]interface{}{
+						"id": "cold",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"max_size": "2g",
+							"disabled": true,
+						}},
+					},
+				},
+			}},
+		},
+		{
+			name: "matches the topologies by id",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id": "cold",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"disabled": true,
+						}},
+					},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id": "hot_content",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"max_size": "116g",
+						}},
+					},
+					map[string]interface{}{
+						"id": "cold",
+						"autoscaling": []interface{}{map[string]interface{}{
+							"max_size": "2g",
+							"disabled": true,
+						}},
+					},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newEs()
+			setAutoscalingDisabled(got, tt.prior)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_setNodeAttributes(t *testing.T) {
+	flattened := func() []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-elasticsearch",
+			"topology": []interface{}{
+				map[string]interface{}{
+					"id":              "hot_content",
+					"node_attributes": map[string]interface{}{"data": "hot", "rack": "rack-1"},
+				},
+				map[string]interface{}{
+					"id":              "warm",
+					"node_attributes": map[string]interface{}{"data": "warm"},
+				},
+			},
+		}}
+	}
+	tests := []struct {
+		name  string
+		prior []interface{}
+		want  []interface{}
+	}{
+		{
+			name: "removes all the node attributes without a prior state",
+			want: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content"},
+					map[string]interface{}{"id": "warm"},
+				},
+			}},
+		},
+		{
+			name: "keeps the node attributes set in the prior state",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id":              "hot_content",
+						"node_attributes": map[string]interface{}{"rack": "rack-2"},
+					},
+					map[string]interface{}{"id": "warm"},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id":              "hot_content",
+						"node_attributes": map[string]interface{}{"rack": "rack-1"},
+					},
+					map[string]interface{}{"id": "warm"},
+				},
+			}},
+		},
+		{
+			name: "keeps overridden template node attributes",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content"},
+					map[string]interface{}{
+						"id":              "warm",
+						"node_attributes": map[string]interface{}{"data": "warm"},
+					},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"ref_id": "main-elasticsearch",
+				"topology": []interface{}{
+					map[string]interface{}{"id": "hot_content"},
+					map[string]interface{}{
+						"id":              "warm",
+						"node_attributes": map[string]interface{}{"data": "warm"},
+					},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattened()
+			setNodeAttributes(got, tt.prior)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_hasRemoteClusterClientRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		topologies []*models.ElasticsearchClusterTopologyElement
+		want       bool
+	}{
+		{
+			name: "returns true when the topologies don't use node_roles",
+			topologies: []*models.ElasticsearchClusterTopologyElement{
+				{ID: "hot_content", NodeType: &models.ElasticsearchNodeType{Data: ec.Bool(true)}},
+			},
+			want: true,
+		},
+		{
+			name: "returns true when any of the topologies has the role",
+			topologies: []*models.ElasticsearchClusterTopologyElement{
+				{ID: "hot_content", NodeRoles: []string{"data_hot", "remote_cluster_client"}},
+				{ID: "warm", NodeRoles: []string{"data_warm"}},
+			},
+			want: true,
+		},
+		{
+			name: "returns false when none of the topologies has the role",
+			topologies: []*models.ElasticsearchClusterTopologyElement{
+				{ID: "hot_content", NodeRoles: []string{"master", "data_hot"}},
+				{ID: "warm", NodeRoles: []string{"data_warm"}},
+				{ID: "cold", NodeRoles: []string{"data_cold"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, hasRemoteClusterClientRole(tt.topologies))
+		})
+	}
+}
+
+func Test_buildCloudID(t *testing.T) {
+	type args struct {
+		name string
+		info *models.ElasticsearchClusterInfo
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "empty info returns an empty cloud_id",
+			args: args{name: "my_deployment_name"},
+		},
+		{
+			name: "missing endpoint returns an empty cloud_id",
+			args: args{name: "my_deployment_name", info: &models.ElasticsearchClusterInfo{
+				ClusterID: ec.String("1239f7ee7196439ba2d105319ac5eba7"),
+				Metadata:  &models.ClusterMetadataInfo{},
+			}},
+		},
+		{
+			name: "endpoint not prefixed with the cluster id returns an empty cloud_id",
+			args: args{name: "my_deployment_name", info: &models.ElasticsearchClusterInfo{
+				ClusterID: ec.String("1239f7ee7196439ba2d105319ac5eba7"),
+				Metadata: &models.ClusterMetadataInfo{
+					Endpoint: "somecluster.cloud.elastic.co",
+					Ports:    &models.ClusterMetadataPortInfo{HTTPS: ec.Int32(9243)},
+				},
+			}},
+		},
+		{
+			name: "builds the cloud_id without an associated kibana",
+			args: args{name: "my_deployment_name", info: &models.ElasticsearchClusterInfo{
+				ClusterID: ec.String("1239f7ee7196439ba2d105319ac5eba7"),
+				Metadata: &models.ClusterMetadataInfo{
+					Endpoint: "1239f7ee7196439ba2d105319ac5eba7.eu-central-1.aws.cloud.es.io",
+					Ports:    &models.ClusterMetadataPortInfo{HTTPS: ec.Int32(9243)},
+				},
+			}},
+			want: "my_deployment_name:" + base64.StdEncoding.EncodeToString(
+				[]byte("eu-central-1.aws.cloud.es.io:9243$1239f7ee7196439ba2d105319ac5eba7"),
+			),
+		},
+		{
+			name: "builds the cloud_id with an associated kibana",
+			args: args{name: "my_deployment_name", info: &models.ElasticsearchClusterInfo{
+				ClusterID: ec.String("1239f7ee7196439ba2d105319ac5eba7"),
+				AssociatedKibanaClusters: []*models.KibanaSubClusterInfo{
+					{Enabled: ec.Bool(true), KibanaID: ec.String("123dcfda06254ca789eb287e8b73ff4c")},
+				},
+				Metadata: &models.ClusterMetadataInfo{
+					Endpoint: "1239f7ee7196439ba2d105319ac5eba7.eu-central-1.aws.cloud.es.io",
+					Ports:    &models.ClusterMetadataPortInfo{HTTPS: ec.Int32(9243)},
+				},
+			}},
+			want: "my_deployment_name:ZXUtY2VudHJhbC0xLmF3cy5jbG91ZC5lcy5pbzo5MjQzJDEyMzlmN2VlNzE5NjQzOWJhMmQxMDUzMTlhYzVlYmE3JDEyM2RjZmRhMDYyNTRjYTc4OWViMjg3ZThiNzNmZjRj",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildCloudID(tt.args.name, tt.args.info)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
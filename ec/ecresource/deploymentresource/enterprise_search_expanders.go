@@ -18,12 +18,14 @@
 package deploymentresource
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
@@ -135,12 +137,108 @@ func expandEssTopology(raw interface{}, topologies []*models.EnterpriseSearchTop
 			}
 		}
 
+		if err := expandEssNodeTypes(topology, elem); err != nil {
+			return nil, fmt.Errorf("enterprise_search topology: %w", err)
+		}
+
 		res = append(res, elem)
 	}
 
 	return res, nil
 }
 
+// expandEssNodeTypes sets the node types of the topology element. Since the
+// node types are computed, the deployment template ones are kept when none of
+// them is enabled, which is the case when these aren't set on creation.
+func expandEssNodeTypes(topology map[string]interface{}, elem *models.EnterpriseSearchTopologyElement) error {
+	appserver, _ := topology["node_type_appserver"].(bool)
+	connector, _ := topology["node_type_connector"].(bool)
+	worker, _ := topology["node_type_worker"].(bool)
+	if !appserver && !connector && !worker {
+		return nil
+	}
+
+	if err := validateEssNodeTypes(appserver, connector, worker); err != nil {
+		return err
+	}
+
+	elem.NodeType = &models.EnterpriseSearchNodeTypes{
+		Appserver: ec.Bool(appserver),
+		Connector: ec.Bool(connector),
+		Worker:    ec.Bool(worker),
+	}
+	return nil
+}
+
+// validateEssNodeTypes rejects the node type combinations which the API
+// doesn't accept: the background workers need the Application/API server.
+func validateEssNodeTypes(appserver, connector, worker bool) error {
+	if !appserver && !connector && !worker {
+		return errors.New("at least one of node_type_appserver, node_type_connector or node_type_worker must be enabled")
+	}
+
+	if worker && !appserver {
+		return errors.New("node_type_worker requires node_type_appserver to be enabled")
+	}
+
+	return nil
+}
+
+// checkEssNodeTypes validates the Enterprise Search node types which are
+// explicitly set in the configuration. Since the node types are computed,
+// the expanded ones can't tell an explicit configuration with all of them
+// disabled apart from one which doesn't set any, so the raw configuration is
+// checked instead, once all the node types of a topology element are set.
+func checkEssNodeTypes(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+
+	essList := config.GetAttr("enterprise_search")
+	if essList.IsNull() || !essList.IsKnown() {
+		return nil
+	}
+
+	for essIt := essList.ElementIterator(); essIt.Next(); {
+		_, ess := essIt.Element()
+		if ess.IsNull() || !ess.IsKnown() {
+			continue
+		}
+
+		topologies := ess.GetAttr("topology")
+		if topologies.IsNull() || !topologies.IsKnown() {
+			continue
+		}
+
+		for it := topologies.ElementIterator(); it.Next(); {
+			_, topology := it.Element()
+			if topology.IsNull() || !topology.IsKnown() {
+				continue
+			}
+
+			var nodeTypes []bool
+			for _, attr := range []string{"node_type_appserver", "node_type_connector", "node_type_worker"} {
+				v := topology.GetAttr(attr)
+				if v.IsNull() || !v.IsKnown() {
+					break
+				}
+				nodeTypes = append(nodeTypes, v.True())
+			}
+
+			if len(nodeTypes) < 3 {
+				continue
+			}
+
+			if err := validateEssNodeTypes(nodeTypes[0], nodeTypes[1], nodeTypes[2]); err != nil {
+				return fmt.Errorf("enterprise_search topology: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
 func expandEssConfig(raw interface{}, res *models.EnterpriseSearchConfiguration) error {
 	for _, rawCfg := range raw.([]interface{}) {
 		cfg := rawCfg.(map[string]interface{})
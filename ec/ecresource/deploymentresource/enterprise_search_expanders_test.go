@@ -18,12 +18,16 @@
 package deploymentresource
 
 import (
+	"context"
 	"errors"
+	"strconv"
 	"testing"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -355,3 +359,233 @@ func Test_expandEssResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_expandEssResourcesNodeTypes(t *testing.T) {
+	tpl := func() *models.EnterpriseSearchPayload {
+		return essResource(parseDeploymentTemplate(t,
+			"testdata/template-aws-io-optimized-v2.json",
+		))
+	}
+	withNodeTypes := func(appserver, connector, worker bool) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-enterprise_search",
+			"topology": []interface{}{map[string]interface{}{
+				"instance_configuration_id": "aws.enterprisesearch.m5d",
+				"size":                      "2g",
+				"node_type_appserver":       appserver,
+				"node_type_connector":       connector,
+				"node_type_worker":          worker,
+			}},
+		}}
+	}
+	tests := []struct {
+		name string
+		ess  []interface{}
+		want *models.EnterpriseSearchNodeTypes
+		err  string
+	}{
+		{
+			name: "keeps the deployment template node types when none is enabled",
+			ess:  withNodeTypes(false, false, false),
+			want: &models.EnterpriseSearchNodeTypes{
+				Appserver: ec.Bool(true),
+				Connector: ec.Bool(true),
+				Worker:    ec.Bool(true),
+			},
+		},
+		{
+			name: "sets an appserver only topology",
+			ess:  withNodeTypes(true, false, false),
+			want: &models.EnterpriseSearchNodeTypes{
+				Appserver: ec.Bool(true),
+				Connector: ec.Bool(false),
+				Worker:    ec.Bool(false),
+			},
+		},
+		{
+			name: "sets a connector only topology",
+			ess:  withNodeTypes(false, true, false),
+			want: &models.EnterpriseSearchNodeTypes{
+				Appserver: ec.Bool(false),
+				Connector: ec.Bool(true),
+				Worker:    ec.Bool(false),
+			},
+		},
+		{
+			name: "rejects a worker only topology",
+			ess:  withNodeTypes(false, false, true),
+			err:  "enterprise_search topology: node_type_worker requires node_type_appserver to be enabled",
+		},
+		{
+			name: "rejects a connector and worker topology",
+			ess:  withNodeTypes(false, true, true),
+			err:  "enterprise_search topology: node_type_worker requires node_type_appserver to be enabled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEssResources(tt.ess, tpl())
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, got, 1) {
+				assert.Equal(t, tt.want, got[0].Plan.ClusterTopology[0].NodeType)
+			}
+		})
+	}
+}
+
+func Test_validateEssNodeTypes(t *testing.T) {
+	assert.EqualError(t, validateEssNodeTypes(false, false, false),
+		"at least one of node_type_appserver, node_type_connector or node_type_worker must be enabled",
+	)
+	assert.NoError(t, validateEssNodeTypes(true, true, true))
+}
+
+func Test_checkEssNodeTypes(t *testing.T) {
+	newConfig := func(nodeTypes map[string]interface{}) map[string]interface{} {
+		topology := map[string]interface{}{"size": "2g"}
+		for k, v := range nodeTypes {
+			topology[k] = v
+		}
+		return map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.12.0",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+			"enterprise_search": []interface{}{map[string]interface{}{
+				"topology": []interface{}{topology},
+			}},
+		}
+	}
+	tests := []struct {
+		name      string
+		nodeTypes map[string]interface{}
+		err       error
+	}{
+		{
+			name: "accepts the node types which aren't set",
+		},
+		{
+			name: "accepts some of the node types being disabled",
+			nodeTypes: map[string]interface{}{
+				"node_type_appserver": false,
+				"node_type_connector": false,
+			},
+		},
+		{
+			name: "accepts a valid combination of node types",
+			nodeTypes: map[string]interface{}{
+				"node_type_appserver": true,
+				"node_type_connector": false,
+				"node_type_worker":    true,
+			},
+		},
+		{
+			name: "rejects all the node types being disabled",
+			nodeTypes: map[string]interface{}{
+				"node_type_appserver": false,
+				"node_type_connector": false,
+				"node_type_worker":    false,
+			},
+			err: errors.New("enterprise_search topology: at least one of node_type_appserver, node_type_connector or node_type_worker must be enabled"),
+		},
+		{
+			name: "rejects the workers without the application server",
+			nodeTypes: map[string]interface{}{
+				"node_type_appserver": false,
+				"node_type_connector": true,
+				"node_type_worker":    true,
+			},
+			err: errors.New("enterprise_search topology: node_type_worker requires node_type_appserver to be enabled"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &schema.Resource{Schema: newSchema(), CustomizeDiff: checkEssNodeTypes}
+			config := newConfig(tt.nodeTypes)
+
+			// The raw configuration is only set on the prior state, and only
+			// holds the configured node types.
+			cfgAttrs := map[string]string{
+				"enterprise_search.#":            "1",
+				"enterprise_search.0.topology.#": "1",
+			}
+			for k, v := range tt.nodeTypes {
+				cfgAttrs["enterprise_search.0.topology.0."+k] = strconv.FormatBool(v.(bool))
+			}
+			rawConfig, err := (&terraform.InstanceState{Attributes: cfgAttrs}).
+				AttrsAsObjectValue(r.CoreConfigSchema().ImpliedType())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			state := &terraform.InstanceState{RawConfig: rawConfig}
+			_, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), nil)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_expandEssConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []interface{}
+		want *models.EnterpriseSearchConfiguration
+		err  error
+	}{
+		{
+			name: "expands all the user settings",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+				"user_settings_override_json": `{"some.setting":"override"}`,
+			}},
+			want: &models.EnterpriseSearchConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON: map[string]interface{}{
+					"some.setting": "value",
+				},
+				UserSettingsOverrideJSON: map[string]interface{}{
+					"some.setting": "override",
+				},
+			},
+		},
+		{
+			name: "leaves the JSON user settings unset when empty",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_json":          "",
+				"user_settings_override_json": "",
+			}},
+			want: &models.EnterpriseSearchConfiguration{},
+		},
+		{
+			name: "fails expanding an invalid user_settings_override_json",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_override_json": `{"some.setting":}`,
+			}},
+			want: &models.EnterpriseSearchConfiguration{},
+			err:  errors.New("failed expanding enterprise_search user_settings_override_json: invalid character '}' looking for beginning of value"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.EnterpriseSearchConfiguration
+			err := expandEssConfig(tt.raw, &got)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, &got)
+		})
+	}
+}
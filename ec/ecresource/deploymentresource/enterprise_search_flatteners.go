@@ -18,9 +18,6 @@
 package deploymentresource
 
 import (
-	"bytes"
-	"encoding/json"
-
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
@@ -31,7 +28,13 @@ func flattenEssResources(in []*models.EnterpriseSearchResourceInfo, name string)
 	result := make([]interface{}, 0, len(in))
 	for _, res := range in {
 		m := make(map[string]interface{})
-		if util.IsCurrentEssPlanEmpty(res) || isEssResourceStopped(res) {
+		if util.IsCurrentEssPlanEmpty(res) {
+			continue
+		}
+
+		// Resources which have been scaled to zero are kept in the state so
+		// that their configuration block doesn't cause a perpetual diff.
+		if isEssResourceStopped(res) && !isEssPlanScaledToZero(res.Info.PlanInfo.Current.Plan) {
 			continue
 		}
 
@@ -41,10 +44,16 @@ func flattenEssResources(in []*models.EnterpriseSearchResourceInfo, name string)
 
 		if res.Info.ID != nil && *res.Info.ID != "" {
 			m["resource_id"] = *res.Info.ID
+		} else if res.ID != nil && *res.ID != "" {
+			m["resource_id"] = *res.ID
 		}
 
-		if res.Region != nil {
+		m["kind"] = "enterprise_search"
+
+		if res.Region != nil && *res.Region != "" {
 			m["region"] = *res.Region
+		} else if res.Info.Region != "" {
+			m["region"] = res.Info.Region
 		}
 
 		plan := res.Info.PlanInfo.Current.Plan
@@ -73,10 +82,15 @@ func flattenEssResources(in []*models.EnterpriseSearchResourceInfo, name string)
 }
 
 func flattenEssTopology(plan *models.EnterpriseSearchPlan) []interface{} {
+	var scaledToZero = isEssPlanScaledToZero(plan)
 	var result = make([]interface{}, 0, len(plan.ClusterTopology))
 	for _, topology := range plan.ClusterTopology {
 		var m = make(map[string]interface{})
-		if topology.Size == nil || topology.Size.Value == nil || *topology.Size.Value == 0 {
+		if topology.Size == nil || topology.Size.Value == nil {
+			continue
+		}
+
+		if *topology.Size.Value == 0 && !scaledToZero {
 			continue
 		}
 
@@ -85,7 +99,7 @@ func flattenEssTopology(plan *models.EnterpriseSearchPlan) []interface{} {
 		}
 
 		if topology.Size != nil {
-			m["size"] = util.MemoryToState(*topology.Size.Value)
+			m["size"] = util.SizeToState(*topology.Size.Value)
 			m["size_resource"] = *topology.Size.Resource
 		}
 
@@ -117,25 +131,9 @@ func flattenEssConfig(cfg *models.EnterpriseSearchConfiguration) []interface{} {
 		return nil
 	}
 
-	if cfg.UserSettingsYaml != "" {
-		m["user_settings_yaml"] = cfg.UserSettingsYaml
-	}
-
-	if cfg.UserSettingsOverrideYaml != "" {
-		m["user_settings_override_yaml"] = cfg.UserSettingsOverrideYaml
-	}
-
-	if o := cfg.UserSettingsJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_json"] = string(b)
-		}
-	}
-
-	if o := cfg.UserSettingsOverrideJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_override_json"] = string(b)
-		}
-	}
+	flattenUserSettings(m, cfg.UserSettingsYaml, cfg.UserSettingsOverrideYaml,
+		cfg.UserSettingsJSON, cfg.UserSettingsOverrideJSON,
+	)
 
 	if cfg.DockerImage != "" {
 		m["docker_image"] = cfg.DockerImage
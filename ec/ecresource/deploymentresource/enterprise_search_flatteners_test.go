@@ -158,6 +158,7 @@ func Test_flattenEssResource(t *testing.T) {
 				map[string]interface{}{
 					"elasticsearch_cluster_ref_id": "main-elasticsearch",
 					"ref_id":                       "main-enterprise_search",
+					"kind":                         "enterprise_search",
 					"resource_id":                  mock.ValidClusterID,
 					"region":                       "some-region",
 					"http_endpoint":                "http://enterprisesearchresource.cloud.elastic.co:9200",
@@ -187,3 +188,48 @@ func Test_flattenEssResource(t *testing.T) {
 		})
 	}
 }
+
+func Test_flattenEssConfig(t *testing.T) {
+	type args struct {
+		cfg *models.EnterpriseSearchConfiguration
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "flattens no config when empty",
+			args: args{cfg: &models.EnterpriseSearchConfiguration{}},
+		},
+		{
+			name: "flattens the user settings set server side",
+			args: args{cfg: &models.EnterpriseSearchConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON:         map[string]interface{}{"some.setting": "value"},
+				UserSettingsOverrideJSON: map[string]interface{}{},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+			}},
+		},
+		{
+			name: "flattens the custom docker image set server side",
+			args: args{cfg: &models.EnterpriseSearchConfiguration{
+				DockerImage: "docker.elastic.co/cloud-ci/enterprise-search:7.15.0-SNAPSHOT",
+			}},
+			want: []interface{}{map[string]interface{}{
+				"docker_image": "docker.elastic.co/cloud-ci/enterprise-search:7.15.0-SNAPSHOT",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenEssConfig(tt.args.cfg)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
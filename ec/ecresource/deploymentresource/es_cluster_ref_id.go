@@ -0,0 +1,77 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// defaultEsClusterRefID is the default "elasticsearch_cluster_ref_id" of the
+// resources which depend on an Elasticsearch resource.
+const defaultEsClusterRefID = "main-elasticsearch"
+
+// singleEsRefID returns the ref_id of the Elasticsearch resource when the
+// deployment has a single one.
+func singleEsRefID(es []interface{}) string {
+	if len(es) != 1 {
+		return ""
+	}
+
+	res, ok := es[0].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	refID, _ := res["ref_id"].(string)
+	return refID
+}
+
+// deriveEsClusterRefIDs sets the "elasticsearch_cluster_ref_id" of the
+// flattened resources which use the default one to the ref_id of the single
+// Elasticsearch resource of the deployment, when it isn't the default.
+func deriveEsClusterRefIDs(es []interface{}, resources ...[]interface{}) {
+	esRefID := singleEsRefID(es)
+	if esRefID == "" || esRefID == defaultEsClusterRefID {
+		return
+	}
+
+	for _, rawRes := range resources {
+		for _, r := range rawRes {
+			res, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if refID, _ := res["elasticsearch_cluster_ref_id"].(string); refID == defaultEsClusterRefID {
+				res["elasticsearch_cluster_ref_id"] = esRefID
+			}
+		}
+	}
+}
+
+// suppressDerivedEsClusterRefID is a DiffSuppressFunc which suppresses the
+// change of an omitted "elasticsearch_cluster_ref_id" to its default when the
+// current value is the one derived from the single Elasticsearch resource.
+func suppressDerivedEsClusterRefID(_, old, new string, d *schema.ResourceData) bool {
+	if new != defaultEsClusterRefID || old == "" {
+		return false
+	}
+
+	es, _ := d.Get("elasticsearch").([]interface{})
+	return old == singleEsRefID(es)
+}
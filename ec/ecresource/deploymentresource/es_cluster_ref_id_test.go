@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_deriveEsClusterRefIDs(t *testing.T) {
+	newEs := func(refIDs ...string) []interface{} {
+		var es []interface{}
+		for _, refID := range refIDs {
+			es = append(es, map[string]interface{}{"ref_id": refID})
+		}
+		return es
+	}
+	newKibana := func(esRefID string) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-kibana", "elasticsearch_cluster_ref_id": esRefID,
+		}}
+	}
+	tests := []struct {
+		name   string
+		es     []interface{}
+		kibana []interface{}
+		want   []interface{}
+	}{
+		{
+			name:   "derives the default ref_id from the single elasticsearch resource",
+			es:     newEs("es"),
+			kibana: newKibana("main-elasticsearch"),
+			want:   newKibana("es"),
+		},
+		{
+			name:   "keeps an explicit ref_id",
+			es:     newEs("es"),
+			kibana: newKibana("other"),
+			want:   newKibana("other"),
+		},
+		{
+			name:   "keeps the default ref_id of the default elasticsearch resource",
+			es:     newEs("main-elasticsearch"),
+			kibana: newKibana("main-elasticsearch"),
+			want:   newKibana("main-elasticsearch"),
+		},
+		{
+			name:   "keeps the default ref_id with multiple elasticsearch resources",
+			es:     newEs("es", "other-es"),
+			kibana: newKibana("main-elasticsearch"),
+			want:   newKibana("main-elasticsearch"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deriveEsClusterRefIDs(tt.es, tt.kibana)
+			assert.Equal(t, tt.want, tt.kibana)
+		})
+	}
+}
+
+func Test_suppressDerivedEsClusterRefID(t *testing.T) {
+	newConfig := func(kibana map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.17.3",
+			"elasticsearch":          []interface{}{map[string]interface{}{"ref_id": "es"}},
+			"kibana":                 []interface{}{kibana},
+		}
+	}
+	state := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  newConfig(map[string]interface{}{"elasticsearch_cluster_ref_id": "es"}),
+	}).State()
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "doesn't change the derived ref_id when it's omitted",
+			config: newConfig(map[string]interface{}{}),
+		},
+		{
+			name:   "doesn't change the ref_id when it's explicit",
+			config: newConfig(map[string]interface{}{"elasticsearch_cluster_ref_id": "es"}),
+		},
+		{
+			name:   "changes the ref_id when it's set to another one",
+			config: newConfig(map[string]interface{}{"elasticsearch_cluster_ref_id": "other"}),
+			want:   "other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := schema.Resource{Schema: newSchema()}
+			diff, err := res.Diff(
+				context.Background(), state, terraform.NewResourceConfigRaw(tt.config), nil,
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var attr *terraform.ResourceAttrDiff
+			if diff != nil {
+				attr = diff.Attributes["kibana.0.elasticsearch_cluster_ref_id"]
+			}
+			if tt.want == "" {
+				assert.Nil(t, attr)
+				return
+			}
+			if assert.NotNil(t, attr) {
+				assert.Equal(t, tt.want, attr.New)
+			}
+		})
+	}
+}
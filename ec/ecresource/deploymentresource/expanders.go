@@ -20,10 +20,10 @@ package deploymentresource
 import (
 	"fmt"
 	"sort"
+	"strconv"
 
 	"github.com/blang/semver/v4"
 	"github.com/elastic/cloud-sdk-go/pkg/api"
-	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/deptemplateapi"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/multierror"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
@@ -36,7 +36,9 @@ var (
 	dataTiersVersion = semver.MustParse("7.10.0")
 )
 
-func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.DeploymentCreateRequest, error) {
+// CreateResourceToModel expands the "ec_deployment" configuration into the
+// deployment create request.
+func CreateResourceToModel(d *schema.ResourceData, client *api.API) (*models.DeploymentCreateRequest, error) {
 	var result = models.DeploymentCreateRequest{
 		Name:      d.Get("name").(string),
 		Alias:     d.Get("alias").(string),
@@ -47,12 +49,7 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 
 	dtID := d.Get("deployment_template_id").(string)
 	version := d.Get("version").(string)
-	template, err := deptemplateapi.Get(deptemplateapi.GetParams{
-		API:                        client,
-		TemplateID:                 dtID,
-		Region:                     d.Get("region").(string),
-		HideInstanceConfigurations: true,
-	})
+	template, err := getDeploymentTemplate(client, dtID, d.Get("region").(string))
 	if err != nil {
 		return nil, err
 	}
@@ -62,9 +59,20 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 		return nil, err
 	}
 
+	// The template autoscaling limits are checked before these are
+	// overridden with the configured ones.
+	tplAutoscalingLimits := hasAutoscalingLimits(esResource(template))
+
+	es := d.Get("elasticsearch").([]interface{})
+	kibana := d.Get("kibana").([]interface{})
+	apm := d.Get("apm").([]interface{})
+	integrationsServer := d.Get("integrations_server").([]interface{})
+	enterpriseSearch := d.Get("enterprise_search").([]interface{})
+	deriveEsClusterRefIDs(es, kibana, apm, integrationsServer, enterpriseSearch)
+
 	merr := multierror.NewPrefixed("invalid configuration")
 	esRes, err := expandEsResources(
-		d.Get("elasticsearch").([]interface{}),
+		es,
 		enrichElasticsearchTemplate(
 			esResource(template), dtID, version, useNodeRoles,
 		),
@@ -72,10 +80,14 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	if err != nil {
 		merr = merr.Append(err)
 	}
+	expandAutoscale(d, esRes)
+	if !tplAutoscalingLimits {
+		merr = merr.Append(validateAutoscalingLimits(esRes, dtID))
+	}
 	result.Resources.Elasticsearch = append(result.Resources.Elasticsearch, esRes...)
 
 	kibanaRes, err := expandKibanaResources(
-		d.Get("kibana").([]interface{}), kibanaResource(template),
+		kibana, kibanaResource(template),
 	)
 	if err != nil {
 		merr = merr.Append(err)
@@ -83,7 +95,7 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	result.Resources.Kibana = append(result.Resources.Kibana, kibanaRes...)
 
 	apmRes, err := expandApmResources(
-		d.Get("apm").([]interface{}), apmResource(template),
+		apm, apmResource(template),
 	)
 	if err != nil {
 		merr = merr.Append(err)
@@ -91,7 +103,7 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	result.Resources.Apm = append(result.Resources.Apm, apmRes...)
 
 	integrationsServerRes, err := expandIntegrationsServerResources(
-		d.Get("integrations_server").([]interface{}), integrationsServerResource(template),
+		integrationsServer, integrationsServerResource(template),
 	)
 	if err != nil {
 		merr = merr.Append(err)
@@ -99,7 +111,7 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	result.Resources.IntegrationsServer = append(result.Resources.IntegrationsServer, integrationsServerRes...)
 
 	enterpriseSearchRes, err := expandEssResources(
-		d.Get("enterprise_search").([]interface{}), essResource(template),
+		enterpriseSearch, essResource(template),
 	)
 	if err != nil {
 		merr = merr.Append(err)
@@ -110,9 +122,15 @@ func createResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 		return nil, err
 	}
 
-	expandTrafficFilterCreate(d.Get("traffic_filter").(*schema.Set), &result)
+	// The traffic filter settings aren't part of the data sources which
+	// derive their schema from the resource.
+	filters, _ := d.Get("traffic_filter").(*schema.Set)
+	exclude, _ := d.Get("traffic_filter_exclude").(*schema.Set)
+	expandTrafficFilterCreate(filters, exclude, &result)
 
-	observability, err := expandObservability(d.Get("observability").([]interface{}), client)
+	observability, err := expandObservability(
+		d.Get("observability").([]interface{}), d.Id(), client,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -135,12 +153,7 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 
 	dtID := d.Get("deployment_template_id").(string)
 	version := d.Get("version").(string)
-	template, err := deptemplateapi.Get(deptemplateapi.GetParams{
-		API:                        client,
-		TemplateID:                 dtID,
-		Region:                     d.Get("region").(string),
-		HideInstanceConfigurations: true,
-	})
+	template, err := getDeploymentTemplate(client, dtID, d.Get("region").(string))
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +163,7 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	apm := d.Get("apm").([]interface{})
 	integrationsServer := d.Get("integrations_server").([]interface{})
 	enterpriseSearch := d.Get("enterprise_search").([]interface{})
+	deriveEsClusterRefIDs(es, kibana, apm, integrationsServer, enterpriseSearch)
 
 	// When the deployment template is changed, we need to unset the missing
 	// resource topologies to account for a new instance_configuration_id and
@@ -157,12 +171,22 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	prevDT, _ := d.GetChange("deployment_template_id")
 	if d.HasChange("deployment_template_id") && prevDT.(string) != "" {
 		// If the deployment_template_id is changed, then we unset the
-		// Elasticsearch topology to account for the case where the
-		// instance_configuration_id changes, i.e. Hot / Warm, etc.
+		// Elasticsearch topology elements which aren't part of the new
+		// template, and the settings which depend on the previous template
+		// for the ones which are, i.e. the instance_configuration_id. The
+		// renamed tiers are aliased first so these keep their size.
+		aliasTopology(es, esResource(template), topologyAliases(
+			d.Get("topology_aliases").(map[string]interface{}),
+		))
+		unsetTopology(es, esResource(template))
+	}
 
-		// This might not be necessary going forward as we move to
-		// tiered Elasticsearch nodes.
-		unsetTopology(es)
+	// When the migration to the latest hardware is requested, the instance
+	// configurations of the resources are unset so these are obtained from
+	// the deployment template. Elasticsearch topology elements always use
+	// the deployment template instance configurations.
+	if d.HasChange("migrate_to_latest_hardware") && d.Get("migrate_to_latest_hardware").(bool) {
+		unsetInstanceConfigurations(kibana, apm, integrationsServer, enterpriseSearch)
 	}
 
 	useNodeRoles, err := compatibleWithNodeRoles(version)
@@ -175,6 +199,10 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	}
 	useNodeRoles = useNodeRoles && convertLegacy
 
+	// The template autoscaling limits are checked before these are
+	// overridden with the configured ones.
+	tplAutoscalingLimits := hasAutoscalingLimits(esResource(template))
+
 	merr := multierror.NewPrefixed("invalid configuration")
 	esRes, err := expandEsResources(
 		es, enrichElasticsearchTemplate(
@@ -184,12 +212,18 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 	if err != nil {
 		merr = merr.Append(err)
 	}
+	expandAutoscale(d, esRes)
+	expandAutoscalingMinFromSize(d, esRes)
+	if !tplAutoscalingLimits {
+		merr = merr.Append(validateAutoscalingLimits(esRes, dtID))
+	}
 	result.Resources.Elasticsearch = append(result.Resources.Elasticsearch, esRes...)
 
 	// if the restore snapshot operation has been specified, the snapshot restore
 	// can't be full once the cluster has been created, so the Strategy must be set
-	// to "partial".
+	// to "partial", unless a "recovery" restore has been requested.
 	ensurePartialSnapshotStrategy(esRes)
+	expandPlanStrategy(es, esRes)
 
 	kibanaRes, err := expandKibanaResources(kibana, kibanaResource(template))
 	if err != nil {
@@ -219,11 +253,17 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 		return nil, err
 	}
 
-	observability, err := expandObservability(d.Get("observability").([]interface{}), client)
-	if err != nil {
-		return nil, err
+	// The observability settings read from the legacy monitoring settings
+	// are only sent once they're changed, so the legacy settings are kept.
+	if !hasLegacyMonitoring(d) || d.HasChange("observability") {
+		observability, err := expandObservability(
+			d.Get("observability").([]interface{}), d.Id(), client,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result.Settings.Observability = observability
 	}
-	result.Settings.Observability = observability
 
 	// In order to stop shipping logs and metrics, an empty Observability
 	// object must be passed, as opposed to a nil object when creating a
@@ -232,7 +272,7 @@ func updateResourceToModel(d *schema.ResourceData, client *api.API) (*models.Dep
 		result.Settings.Observability = &models.DeploymentObservabilitySettings{}
 	}
 
-	result.Metadata.Tags = expandTags(d.Get("tags").(map[string]interface{}))
+	result.Metadata.Tags = expandUpdateTags(d)
 
 	return &result, nil
 }
@@ -252,6 +292,9 @@ func enrichElasticsearchTemplate(tpl *models.ElasticsearchPayload, dt, version s
 
 	for _, topology := range tpl.Plan.ClusterTopology {
 		if useNodeRoles {
+			if len(topology.NodeRoles) == 0 {
+				topology.NodeRoles = defaultTierNodeRoles(topology.ID)
+			}
 			topology.NodeType = nil
 			continue
 		}
@@ -261,9 +304,129 @@ func enrichElasticsearchTemplate(tpl *models.ElasticsearchPayload, dt, version s
 	return tpl
 }
 
-func unsetTopology(rawRes []interface{}) {
+// expandAutoscale overrides the autoscaling setting of the Elasticsearch
+// resources when the deployment level "autoscale" attribute is set, taking
+// precedence over the deprecated "elasticsearch.autoscale" string.
+func expandAutoscale(d *schema.ResourceData, ess []*models.ElasticsearchPayload) {
+	// GetOkExists is needed since an explicit false must be honoured.
+	autoscale, ok := d.GetOkExists("autoscale") //nolint:staticcheck
+	if !ok {
+		return
+	}
+
+	for _, es := range ess {
+		if es.Plan == nil {
+			continue
+		}
+		es.Plan.AutoscalingEnabled = ec.Bool(autoscale.(bool))
+	}
+}
+
+// expandAutoscalingMinFromSize sets the current size of the autoscalable
+// topology elements as their autoscaling minimum when autoscaling is being
+// enabled and "autoscale_size_as_min" is set. This ensures the deployment is
+// never scaled down below its current footprint. Explicit minimums are kept.
+func expandAutoscalingMinFromSize(d *schema.ResourceData, ess []*models.ElasticsearchPayload) {
+	if !d.Get("autoscale_size_as_min").(bool) {
+		return
+	}
+
+	if old, _ := d.GetChange("autoscale"); old.(bool) {
+		return
+	}
+
+	for i, es := range ess {
+		if es.Plan == nil || es.Plan.AutoscalingEnabled == nil || !*es.Plan.AutoscalingEnabled {
+			continue
+		}
+
+		old, _ := d.GetChange(fmt.Sprintf("elasticsearch.%d.autoscale", i))
+		if wasEnabled, _ := strconv.ParseBool(old.(string)); wasEnabled {
+			continue
+		}
+
+		for _, topology := range es.Plan.ClusterTopology {
+			if topology.AutoscalingMax == nil || topology.AutoscalingMin != nil {
+				continue
+			}
+
+			size := topology.Size
+			if size == nil || size.Value == nil || *size.Value == 0 {
+				continue
+			}
+
+			topology.AutoscalingMin = &models.TopologySize{
+				Resource: size.Resource,
+				Value:    ec.Int32(*size.Value),
+			}
+		}
+	}
+}
+
+// retainedTopologyFields are the Elasticsearch topology fields which are kept
+// on a deployment template change for the tiers present in both templates.
+var retainedTopologyFields = []string{"id", "size", "size_resource", "zone_count"}
+
+// unsetTopology unsets the Elasticsearch topology elements which aren't part
+// of the deployment template, resetting them to the template defaults. The
+// tiers which are part of it retain their size and zone count.
+func unsetTopology(rawRes []interface{}, tpl *models.ElasticsearchPayload) {
+	var tplIDs = make(map[string]bool)
+	if tpl != nil && tpl.Plan != nil {
+		for _, topology := range tpl.Plan.ClusterTopology {
+			tplIDs[topology.ID] = true
+		}
+	}
+
 	for _, r := range rawRes {
-		delete(r.(map[string]interface{}), "topology")
+		res := r.(map[string]interface{})
+		rawTopologies, _ := res["topology"].([]interface{})
+
+		var topologies []interface{}
+		for _, rawTop := range rawTopologies {
+			topology, ok := rawTop.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if id, _ := topology["id"].(string); !tplIDs[id] {
+				continue
+			}
+
+			var retained = make(map[string]interface{})
+			for _, field := range retainedTopologyFields {
+				if v, ok := topology[field]; ok {
+					retained[field] = v
+				}
+			}
+			topologies = append(topologies, retained)
+		}
+
+		if len(topologies) == 0 {
+			delete(res, "topology")
+			continue
+		}
+		res["topology"] = topologies
+	}
+}
+
+// unsetInstanceConfigurations removes the "instance_configuration_id" of the
+// topology elements of the flattened resources.
+func unsetInstanceConfigurations(resources ...[]interface{}) {
+	for _, rawRes := range resources {
+		for _, r := range rawRes {
+			res, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			topologies, _ := res["topology"].([]interface{})
+			for _, rawTop := range topologies {
+				if topology, ok := rawTop.(map[string]interface{}); ok {
+					delete(topology, "instance_configuration_id")
+				}
+			}
+		}
 	}
 }
 
@@ -284,6 +447,30 @@ func expandTags(raw map[string]interface{}) []*models.MetadataItem {
 	return result
 }
 
+// expandUpdateTags returns the tags of an update request, which are the
+// configured tags along with the system tags, since the update replaces all
+// the deployment tags and would otherwise remove the system tags.
+func expandUpdateTags(d *schema.ResourceData) []*models.MetadataItem {
+	tags := make(map[string]interface{})
+	for k, v := range d.Get("system_tags").(map[string]interface{}) {
+		tags[k] = v
+	}
+	for k, v := range d.Get("tags").(map[string]interface{}) {
+		tags[k] = v
+	}
+	return expandTags(tags)
+}
+
+// defaultTierNodeRoles returns the node roles of the topology elements which
+// the deployment template doesn't set any node_roles for, when the tier ID
+// implies them. Returns nil for any other tier.
+func defaultTierNodeRoles(id string) []string {
+	if roles, ok := tierNodeRoles[id]; ok {
+		return append([]string{}, roles...)
+	}
+	return nil
+}
+
 func compatibleWithNodeRoles(version string) (bool, error) {
 	deploymentVersion, err := semver.Parse(version)
 	if err != nil {
@@ -299,7 +486,10 @@ func ensurePartialSnapshotStrategy(ess []*models.ElasticsearchPayload) {
 		if transient == nil || transient.RestoreSnapshot == nil {
 			continue
 		}
-		transient.RestoreSnapshot.Strategy = "partial"
+		if transient.RestoreSnapshot.Strategy == models.RestoreSnapshotConfigurationStrategyRecovery {
+			continue
+		}
+		transient.RestoreSnapshot.Strategy = models.RestoreSnapshotConfigurationStrategyPartial
 	}
 }
 
@@ -67,6 +67,9 @@ func Test_createResourceToModel(t *testing.T) {
 	ioOptimizedTpl := func() io.ReadCloser {
 		return fileAsResponseBody(t, "testdata/template-aws-io-optimized-v2.json")
 	}
+	gcpIoOptimizedTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-gcp-io-optimized-v2.json")
+	}
 	deploymentOverrideRd := util.NewResourceData(t, util.ResDataParams{
 		ID:     mock.ValidClusterID,
 		State:  newSampleDeploymentOverrides(),
@@ -667,6 +670,146 @@ func Test_createResourceToModel(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "parses the resources with empty declarations (GCP IO Optimized)",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID: mock.ValidClusterID,
+					State: map[string]interface{}{
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "gcp-io-optimized-v2",
+						"region":                 "gcp-us-central1",
+						"version":                "7.7.0",
+						"elasticsearch":          []interface{}{map[string]interface{}{}},
+						"kibana":                 []interface{}{map[string]interface{}{}},
+						"apm":                    []interface{}{map[string]interface{}{}},
+						"enterprise_search":      []interface{}{map[string]interface{}{}},
+						"traffic_filter":         []interface{}{"0.0.0.0/0", "192.168.10.0/24"},
+					},
+					Schema: newSchema(),
+				}),
+				client: api.NewMock(mock.New200Response(gcpIoOptimizedTpl())),
+			},
+			want: &models.DeploymentCreateRequest{
+				Name: "my_deployment_name",
+				Settings: &models.DeploymentCreateSettings{
+					TrafficFilterSettings: &models.TrafficFilterSettings{
+						Rulesets: []string{"0.0.0.0/0", "192.168.10.0/24"},
+					},
+				},
+				Metadata: &models.DeploymentCreateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentCreateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, gcpIoOptimizedTpl(), false), &models.ElasticsearchPayload{
+						Region: ec.String("gcp-us-central1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+						},
+						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(false),
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version: "7.7.0",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("gcp-io-optimized-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{{
+								ID: "hot_content",
+								Elasticsearch: &models.ElasticsearchConfiguration{
+									NodeAttributes: map[string]string{"data": "hot"},
+								},
+								ZoneCount:               2,
+								InstanceConfigurationID: "gcp.data.highio.1",
+								Size: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(8192),
+								},
+								NodeType: &models.ElasticsearchNodeType{
+									Data:   ec.Bool(true),
+									Ingest: ec.Bool(true),
+									Master: ec.Bool(true),
+								},
+								TopologyElementControl: &models.TopologyElementControl{
+									Min: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(1024),
+									},
+								},
+								AutoscalingMax: &models.TopologySize{
+									Value:    ec.Int32(118784),
+									Resource: ec.String("memory"),
+								},
+							}},
+						},
+					}),
+					Kibana: []*models.KibanaPayload{
+						{
+							ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+							Region:                    ec.String("gcp-us-central1"),
+							RefID:                     ec.String("main-kibana"),
+							Plan: &models.KibanaClusterPlan{
+								Kibana: &models.KibanaConfiguration{},
+								ClusterTopology: []*models.KibanaClusterTopologyElement{
+									{
+										ZoneCount:               1,
+										InstanceConfigurationID: "gcp.kibana.1",
+										Size: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+								},
+							},
+						},
+					},
+					Apm: []*models.ApmPayload{
+						{
+							ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+							Region:                    ec.String("gcp-us-central1"),
+							RefID:                     ec.String("main-apm"),
+							Plan: &models.ApmPlan{
+								Apm: &models.ApmConfiguration{},
+								ClusterTopology: []*models.ApmTopologyElement{{
+									ZoneCount:               1,
+									InstanceConfigurationID: "gcp.apm.1",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(512),
+									},
+								}},
+							},
+						},
+					},
+					EnterpriseSearch: []*models.EnterpriseSearchPayload{
+						{
+							ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+							Region:                    ec.String("gcp-us-central1"),
+							RefID:                     ec.String("main-enterprise_search"),
+							Plan: &models.EnterpriseSearchPlan{
+								EnterpriseSearch: &models.EnterpriseSearchConfiguration{},
+								ClusterTopology: []*models.EnterpriseSearchTopologyElement{
+									{
+										ZoneCount:               2,
+										InstanceConfigurationID: "gcp.enterprisesearch.1",
+										Size: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(2048),
+										},
+										NodeType: &models.EnterpriseSearchNodeTypes{
+											Appserver: ec.Bool(true),
+											Connector: ec.Bool(true),
+											Worker:    ec.Bool(true),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "parses the resources with empty declarations (IO Optimized) with node_roles",
 			args: args{
@@ -2285,7 +2428,7 @@ func Test_createResourceToModel(t *testing.T) {
 		},
 		//
 		{
-			name: "deployment with docker_image overrides",
+			name: "deployment with a dedicated ml tier and an autoscaling max override",
 			args: args{
 				d: util.NewResourceData(t, util.ResDataParams{
 					ID:     mock.ValidClusterID,
@@ -2294,40 +2437,23 @@ func Test_createResourceToModel(t *testing.T) {
 						"name":                   "my_deployment_name",
 						"deployment_template_id": "aws-io-optimized-v2",
 						"region":                 "us-east-1",
-						"version":                "7.14.1",
+						"version":                "7.12.0",
+						"autoscale":              true,
 						"elasticsearch": []interface{}{map[string]interface{}{
-							"config": []interface{}{map[string]interface{}{
-								"docker_image": "docker.elastic.com/elasticsearch/container:7.14.1-hash",
-							}},
-							"autoscale": "false",
-							"trust_account": []interface{}{
-								map[string]interface{}{
-									"account_id": "ANID",
-									"trust_all":  "true",
-								},
-							},
 							"topology": []interface{}{
 								map[string]interface{}{
 									"id":   "hot_content",
 									"size": "8g",
 								},
+								map[string]interface{}{
+									"id":   "ml",
+									"size": "1g",
+									"autoscaling": []interface{}{map[string]interface{}{
+										"max_size": "8g",
+									}},
+								},
 							},
 						}},
-						"kibana": []interface{}{map[string]interface{}{
-							"config": []interface{}{map[string]interface{}{
-								"docker_image": "docker.elastic.com/kibana/container:7.14.1-hash",
-							}},
-						}},
-						"apm": []interface{}{map[string]interface{}{
-							"config": []interface{}{map[string]interface{}{
-								"docker_image": "docker.elastic.com/apm/container:7.14.1-hash",
-							}},
-						}},
-						"enterprise_search": []interface{}{map[string]interface{}{
-							"config": []interface{}{map[string]interface{}{
-								"docker_image": "docker.elastic.com/enterprise_search/container:7.14.1-hash",
-							}},
-						}},
 					},
 				}),
 				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
@@ -2344,20 +2470,11 @@ func Test_createResourceToModel(t *testing.T) {
 						RefID:  ec.String("main-elasticsearch"),
 						Settings: &models.ElasticsearchClusterSettings{
 							DedicatedMastersThreshold: 6,
-							Trust: &models.ElasticsearchClusterTrustSettings{
-								Accounts: []*models.AccountTrustRelationship{
-									{
-										AccountID: ec.String("ANID"),
-										TrustAll:  ec.Bool(true),
-									},
-								},
-							},
 						},
 						Plan: &models.ElasticsearchClusterPlan{
-							AutoscalingEnabled: ec.Bool(false),
+							AutoscalingEnabled: ec.Bool(true),
 							Elasticsearch: &models.ElasticsearchConfiguration{
-								Version:     "7.14.1",
-								DockerImage: "docker.elastic.com/elasticsearch/container:7.14.1-hash",
+								Version: "7.12.0",
 							},
 							DeploymentTemplate: &models.DeploymentTemplateReference{
 								ID: ec.String("aws-io-optimized-v2"),
@@ -2393,131 +2510,61 @@ func Test_createResourceToModel(t *testing.T) {
 										Resource: ec.String("memory"),
 									},
 								},
+								{
+									ID:                      "ml",
+									ZoneCount:               1,
+									InstanceConfigurationID: "aws.ml.m5d",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(1024),
+									},
+									NodeRoles: []string{
+										"ml",
+										"remote_cluster_client",
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(0),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(8192),
+										Resource: ec.String("memory"),
+									},
+									AutoscalingMin: &models.TopologySize{
+										Value:    ec.Int32(0),
+										Resource: ec.String("memory"),
+									},
+								},
 							},
 						},
 					}),
-					Apm: []*models.ApmPayload{{
-						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
-						Plan: &models.ApmPlan{
-							Apm: &models.ApmConfiguration{
-								DockerImage: "docker.elastic.com/apm/container:7.14.1-hash",
-								SystemSettings: &models.ApmSystemSettings{
-									DebugEnabled: ec.Bool(false),
+				},
+			},
+		},
+		{
+			name: "deployment with an ml only tier set through explicit node_roles",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "aws-io-optimized-v2",
+						"region":                 "us-east-1",
+						"version":                "7.12.0",
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"topology": []interface{}{
+								map[string]interface{}{
+									"id":   "hot_content",
+									"size": "8g",
 								},
-							},
-							ClusterTopology: []*models.ApmTopologyElement{{
-								InstanceConfigurationID: "aws.apm.r5d",
-								Size: &models.TopologySize{
-									Resource: ec.String("memory"),
-									Value:    ec.Int32(512),
-								},
-								ZoneCount: 1,
-							}},
-						},
-						RefID:  ec.String("main-apm"),
-						Region: ec.String("us-east-1"),
-					}},
-					Kibana: []*models.KibanaPayload{{
-						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
-						Plan: &models.KibanaClusterPlan{
-							Kibana: &models.KibanaConfiguration{
-								DockerImage: "docker.elastic.com/kibana/container:7.14.1-hash",
-							},
-							ClusterTopology: []*models.KibanaClusterTopologyElement{{
-								InstanceConfigurationID: "aws.kibana.r5d",
-								Size: &models.TopologySize{
-									Resource: ec.String("memory"),
-									Value:    ec.Int32(1024),
-								},
-								ZoneCount: 1,
-							}},
-						},
-						RefID:  ec.String("main-kibana"),
-						Region: ec.String("us-east-1"),
-					}},
-					EnterpriseSearch: []*models.EnterpriseSearchPayload{{
-						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
-						Plan: &models.EnterpriseSearchPlan{
-							EnterpriseSearch: &models.EnterpriseSearchConfiguration{
-								DockerImage: "docker.elastic.com/enterprise_search/container:7.14.1-hash",
-							},
-							ClusterTopology: []*models.EnterpriseSearchTopologyElement{{
-								InstanceConfigurationID: "aws.enterprisesearch.m5d",
-								Size: &models.TopologySize{
-									Resource: ec.String("memory"),
-									Value:    ec.Int32(2048),
-								},
-								NodeType: &models.EnterpriseSearchNodeTypes{
-									Appserver: ec.Bool(true),
-									Connector: ec.Bool(true),
-									Worker:    ec.Bool(true),
-								},
-								ZoneCount: 2,
-							}},
-						},
-						RefID:  ec.String("main-enterprise_search"),
-						Region: ec.String("us-east-1"),
-					}},
-				},
-			},
-		},
-		{
-			name: "deployment with trust settings set",
-			args: args{
-				d: util.NewResourceData(t, util.ResDataParams{
-					ID:     mock.ValidClusterID,
-					Schema: newSchema(),
-					State: map[string]interface{}{
-						"name":                   "my_deployment_name",
-						"deployment_template_id": "aws-io-optimized-v2",
-						"region":                 "us-east-1",
-						"version":                "7.12.0",
-						"elasticsearch": []interface{}{map[string]interface{}{
-							"autoscale": "false",
-							"trust_account": []interface{}{
-								map[string]interface{}{
-									"account_id": "ANID",
-									"trust_all":  "true",
-								},
-								map[string]interface{}{
-									"account_id": "anotherID",
-									"trust_all":  "false",
-									"trust_allowlist": []interface{}{
-										"abc", "hij", "dfg",
-									},
-								},
-							},
-							"trust_external": []interface{}{
-								map[string]interface{}{
-									"relationship_id": "external_id",
-									"trust_all":       "true",
-								},
-								map[string]interface{}{
-									"relationship_id": "another_external_id",
-									"trust_all":       "false",
-									"trust_allowlist": []interface{}{
-										"abc", "dfg",
-									},
-								},
-							},
-							"topology": []interface{}{
-								map[string]interface{}{
-									"id":   "cold",
-									"size": "2g",
-								},
-								map[string]interface{}{
-									"id":   "hot_content",
-									"size": "8g",
-									"autoscaling": []interface{}{map[string]interface{}{
-										"max_size": "232g",
-									}},
-								},
-								map[string]interface{}{
-									"id":   "warm",
-									"size": "4g",
-									"autoscaling": []interface{}{map[string]interface{}{
-										"max_size": "116g",
-									}},
+								map[string]interface{}{
+									"id":         "ml",
+									"size":       "1g",
+									"node_roles": []interface{}{"ml"},
 								},
 							},
 						}},
@@ -2537,34 +2584,6 @@ func Test_createResourceToModel(t *testing.T) {
 						RefID:  ec.String("main-elasticsearch"),
 						Settings: &models.ElasticsearchClusterSettings{
 							DedicatedMastersThreshold: 6,
-							Trust: &models.ElasticsearchClusterTrustSettings{
-								Accounts: []*models.AccountTrustRelationship{
-									{
-										AccountID: ec.String("ANID"),
-										TrustAll:  ec.Bool(true),
-									},
-									{
-										AccountID: ec.String("anotherID"),
-										TrustAll:  ec.Bool(false),
-										TrustAllowlist: []string{
-											"abc", "dfg", "hij",
-										},
-									},
-								},
-								External: []*models.ExternalTrustRelationship{
-									{
-										TrustRelationshipID: ec.String("external_id"),
-										TrustAll:            ec.Bool(true),
-									},
-									{
-										TrustRelationshipID: ec.String("another_external_id"),
-										TrustAll:            ec.Bool(false),
-										TrustAllowlist: []string{
-											"abc", "dfg",
-										},
-									},
-								},
-							},
 						},
 						Plan: &models.ElasticsearchClusterPlan{
 							AutoscalingEnabled: ec.Bool(false),
@@ -2575,34 +2594,6 @@ func Test_createResourceToModel(t *testing.T) {
 								ID: ec.String("aws-io-optimized-v2"),
 							},
 							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
-								{
-									ID:                      "cold",
-									ZoneCount:               1,
-									InstanceConfigurationID: "aws.data.highstorage.d3",
-									Size: &models.TopologySize{
-										Resource: ec.String("memory"),
-										Value:    ec.Int32(2048),
-									},
-									NodeRoles: []string{
-										"data_cold",
-										"remote_cluster_client",
-									},
-									Elasticsearch: &models.ElasticsearchConfiguration{
-										NodeAttributes: map[string]string{
-											"data": "cold",
-										},
-									},
-									TopologyElementControl: &models.TopologyElementControl{
-										Min: &models.TopologySize{
-											Resource: ec.String("memory"),
-											Value:    ec.Int32(0),
-										},
-									},
-									AutoscalingMax: &models.TopologySize{
-										Value:    ec.Int32(59392),
-										Resource: ec.String("memory"),
-									},
-								},
 								{
 									ID:                      "hot_content",
 									ZoneCount:               2,
@@ -2629,27 +2620,20 @@ func Test_createResourceToModel(t *testing.T) {
 										},
 									},
 									AutoscalingMax: &models.TopologySize{
-										Value:    ec.Int32(237568),
+										Value:    ec.Int32(118784),
 										Resource: ec.String("memory"),
 									},
 								},
 								{
-									ID:                      "warm",
-									ZoneCount:               2,
-									InstanceConfigurationID: "aws.data.highstorage.d3",
+									ID:                      "ml",
+									ZoneCount:               1,
+									InstanceConfigurationID: "aws.ml.m5d",
 									Size: &models.TopologySize{
 										Resource: ec.String("memory"),
-										Value:    ec.Int32(4096),
-									},
-									NodeRoles: []string{
-										"data_warm",
-										"remote_cluster_client",
-									},
-									Elasticsearch: &models.ElasticsearchConfiguration{
-										NodeAttributes: map[string]string{
-											"data": "warm",
-										},
+										Value:    ec.Int32(1024),
 									},
+									NodeRoles:     []string{"ml"},
+									Elasticsearch: &models.ElasticsearchConfiguration{},
 									TopologyElementControl: &models.TopologyElementControl{
 										Min: &models.TopologySize{
 											Resource: ec.String("memory"),
@@ -2657,7 +2641,11 @@ func Test_createResourceToModel(t *testing.T) {
 										},
 									},
 									AutoscalingMax: &models.TopologySize{
-										Value:    ec.Int32(118784),
+										Value:    ec.Int32(61440),
+										Resource: ec.String("memory"),
+									},
+									AutoscalingMin: &models.TopologySize{
+										Value:    ec.Int32(0),
 										Resource: ec.String("memory"),
 									},
 								},
@@ -2668,10 +2656,32 @@ func Test_createResourceToModel(t *testing.T) {
 			},
 		},
 		{
-			name: "parses the resources with empty declarations (Cross Cluster Search)",
+			name: "deployment with a transform only tier set through explicit node_roles",
 			args: args{
-				d:      deploymentCCS,
-				client: api.NewMock(mock.New200Response(ccsTpl())),
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "aws-io-optimized-v2",
+						"region":                 "us-east-1",
+						"version":                "7.12.0",
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"topology": []interface{}{
+								map[string]interface{}{
+									"id":   "hot_content",
+									"size": "8g",
+								},
+								map[string]interface{}{
+									"id":         "ml",
+									"size":       "1g",
+									"node_roles": []interface{}{"transform"},
+								},
+							},
+						}},
+					},
+				}),
+				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
 			},
 			want: &models.DeploymentCreateRequest{
 				Name:     "my_deployment_name",
@@ -2680,30 +2690,39 @@ func Test_createResourceToModel(t *testing.T) {
 					Tags: []*models.MetadataItem{},
 				},
 				Resources: &models.DeploymentCreateResources{
-					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ccsTpl(), false), &models.ElasticsearchPayload{
-						Region:   ec.String("us-east-1"),
-						RefID:    ec.String("main-elasticsearch"),
-						Settings: &models.ElasticsearchClusterSettings{},
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ioOptimizedTpl(), true), &models.ElasticsearchPayload{
+						Region: ec.String("us-east-1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+						},
 						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(false),
 							Elasticsearch: &models.ElasticsearchConfiguration{
-								Version: "7.9.2",
+								Version: "7.12.0",
 							},
 							DeploymentTemplate: &models.DeploymentTemplateReference{
-								ID: ec.String("aws-cross-cluster-search-v2"),
+								ID: ec.String("aws-io-optimized-v2"),
 							},
 							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
 								{
 									ID:                      "hot_content",
-									ZoneCount:               1,
-									InstanceConfigurationID: "aws.ccs.r5d",
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highio.i3",
 									Size: &models.TopologySize{
 										Resource: ec.String("memory"),
-										Value:    ec.Int32(1024),
+										Value:    ec.Int32(8192),
 									},
-									NodeType: &models.ElasticsearchNodeType{
-										Data:   ec.Bool(true),
-										Ingest: ec.Bool(true),
-										Master: ec.Bool(true),
+									NodeRoles: []string{
+										"master",
+										"ingest",
+										"remote_cluster_client",
+										"data_hot",
+										"transform",
+										"data_content",
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{"data": "hot"},
 									},
 									TopologyElementControl: &models.TopologyElementControl{
 										Min: &models.TopologySize{
@@ -2711,35 +2730,498 @@ func Test_createResourceToModel(t *testing.T) {
 											Value:    ec.Int32(1024),
 										},
 									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
 								},
-							},
-						},
-					}),
-					Kibana: []*models.KibanaPayload{
-						{
-							ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
-							Region:                    ec.String("us-east-1"),
-							RefID:                     ec.String("main-kibana"),
-							Plan: &models.KibanaClusterPlan{
-								Kibana: &models.KibanaConfiguration{},
-								ClusterTopology: []*models.KibanaClusterTopologyElement{
-									{
-										ZoneCount:               1,
-										InstanceConfigurationID: "aws.kibana.r5d",
-										Size: &models.TopologySize{
+								{
+									ID:                      "ml",
+									ZoneCount:               1,
+									InstanceConfigurationID: "aws.ml.m5d",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(1024),
+									},
+									NodeRoles:     []string{"transform"},
+									Elasticsearch: &models.ElasticsearchConfiguration{},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
 											Resource: ec.String("memory"),
-											Value:    ec.Int32(1024),
+											Value:    ec.Int32(0),
 										},
 									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(61440),
+										Resource: ec.String("memory"),
+									},
+									AutoscalingMin: &models.TopologySize{
+										Value:    ec.Int32(0),
+										Resource: ec.String("memory"),
+									},
 								},
 							},
 						},
-					},
+					}),
 				},
 			},
 		},
 		{
-			name: "parses the resources with tags",
+			name: "deployment with docker_image overrides",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "aws-io-optimized-v2",
+						"region":                 "us-east-1",
+						"version":                "7.14.1",
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"config": []interface{}{map[string]interface{}{
+								"docker_image": "docker.elastic.com/elasticsearch/container:7.14.1-hash",
+							}},
+							"autoscale": "false",
+							"trust_account": []interface{}{
+								map[string]interface{}{
+									"account_id": "ANID",
+									"trust_all":  "true",
+								},
+							},
+							"topology": []interface{}{
+								map[string]interface{}{
+									"id":   "hot_content",
+									"size": "8g",
+								},
+							},
+						}},
+						"kibana": []interface{}{map[string]interface{}{
+							"config": []interface{}{map[string]interface{}{
+								"docker_image": "docker.elastic.com/kibana/container:7.14.1-hash",
+							}},
+						}},
+						"apm": []interface{}{map[string]interface{}{
+							"config": []interface{}{map[string]interface{}{
+								"docker_image": "docker.elastic.com/apm/container:7.14.1-hash",
+							}},
+						}},
+						"enterprise_search": []interface{}{map[string]interface{}{
+							"config": []interface{}{map[string]interface{}{
+								"docker_image": "docker.elastic.com/enterprise_search/container:7.14.1-hash",
+							}},
+						}},
+					},
+				}),
+				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
+			},
+			want: &models.DeploymentCreateRequest{
+				Name:     "my_deployment_name",
+				Settings: &models.DeploymentCreateSettings{},
+				Metadata: &models.DeploymentCreateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentCreateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ioOptimizedTpl(), true), &models.ElasticsearchPayload{
+						Region: ec.String("us-east-1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+							Trust: &models.ElasticsearchClusterTrustSettings{
+								Accounts: []*models.AccountTrustRelationship{
+									{
+										AccountID: ec.String("ANID"),
+										TrustAll:  ec.Bool(true),
+									},
+								},
+							},
+						},
+						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(false),
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version:     "7.14.1",
+								DockerImage: "docker.elastic.com/elasticsearch/container:7.14.1-hash",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("aws-io-optimized-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+								{
+									ID:                      "hot_content",
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highio.i3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(8192),
+									},
+									NodeRoles: []string{
+										"master",
+										"ingest",
+										"remote_cluster_client",
+										"data_hot",
+										"transform",
+										"data_content",
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{"data": "hot"},
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
+								},
+							},
+						},
+					}),
+					Apm: []*models.ApmPayload{{
+						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+						Plan: &models.ApmPlan{
+							Apm: &models.ApmConfiguration{
+								DockerImage: "docker.elastic.com/apm/container:7.14.1-hash",
+								SystemSettings: &models.ApmSystemSettings{
+									DebugEnabled: ec.Bool(false),
+								},
+							},
+							ClusterTopology: []*models.ApmTopologyElement{{
+								InstanceConfigurationID: "aws.apm.r5d",
+								Size: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(512),
+								},
+								ZoneCount: 1,
+							}},
+						},
+						RefID:  ec.String("main-apm"),
+						Region: ec.String("us-east-1"),
+					}},
+					Kibana: []*models.KibanaPayload{{
+						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+						Plan: &models.KibanaClusterPlan{
+							Kibana: &models.KibanaConfiguration{
+								DockerImage: "docker.elastic.com/kibana/container:7.14.1-hash",
+							},
+							ClusterTopology: []*models.KibanaClusterTopologyElement{{
+								InstanceConfigurationID: "aws.kibana.r5d",
+								Size: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(1024),
+								},
+								ZoneCount: 1,
+							}},
+						},
+						RefID:  ec.String("main-kibana"),
+						Region: ec.String("us-east-1"),
+					}},
+					EnterpriseSearch: []*models.EnterpriseSearchPayload{{
+						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+						Plan: &models.EnterpriseSearchPlan{
+							EnterpriseSearch: &models.EnterpriseSearchConfiguration{
+								DockerImage: "docker.elastic.com/enterprise_search/container:7.14.1-hash",
+							},
+							ClusterTopology: []*models.EnterpriseSearchTopologyElement{{
+								InstanceConfigurationID: "aws.enterprisesearch.m5d",
+								Size: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(2048),
+								},
+								NodeType: &models.EnterpriseSearchNodeTypes{
+									Appserver: ec.Bool(true),
+									Connector: ec.Bool(true),
+									Worker:    ec.Bool(true),
+								},
+								ZoneCount: 2,
+							}},
+						},
+						RefID:  ec.String("main-enterprise_search"),
+						Region: ec.String("us-east-1"),
+					}},
+				},
+			},
+		},
+		{
+			name: "deployment with trust settings set",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "aws-io-optimized-v2",
+						"region":                 "us-east-1",
+						"version":                "7.12.0",
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"autoscale": "false",
+							"trust_account": []interface{}{
+								map[string]interface{}{
+									"account_id": "ANID",
+									"trust_all":  "true",
+								},
+								map[string]interface{}{
+									"account_id": "anotherID",
+									"trust_all":  "false",
+									"trust_allowlist": []interface{}{
+										"abc", "hij", "dfg",
+									},
+								},
+							},
+							"trust_external": []interface{}{
+								map[string]interface{}{
+									"relationship_id": "external_id",
+									"trust_all":       "true",
+								},
+								map[string]interface{}{
+									"relationship_id": "another_external_id",
+									"trust_all":       "false",
+									"trust_allowlist": []interface{}{
+										"abc", "dfg",
+									},
+								},
+							},
+							"topology": []interface{}{
+								map[string]interface{}{
+									"id":   "cold",
+									"size": "2g",
+								},
+								map[string]interface{}{
+									"id":   "hot_content",
+									"size": "8g",
+									"autoscaling": []interface{}{map[string]interface{}{
+										"max_size": "232g",
+									}},
+								},
+								map[string]interface{}{
+									"id":   "warm",
+									"size": "4g",
+									"autoscaling": []interface{}{map[string]interface{}{
+										"max_size": "116g",
+									}},
+								},
+							},
+						}},
+					},
+				}),
+				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
+			},
+			want: &models.DeploymentCreateRequest{
+				Name:     "my_deployment_name",
+				Settings: &models.DeploymentCreateSettings{},
+				Metadata: &models.DeploymentCreateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentCreateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ioOptimizedTpl(), true), &models.ElasticsearchPayload{
+						Region: ec.String("us-east-1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+							Trust: &models.ElasticsearchClusterTrustSettings{
+								Accounts: []*models.AccountTrustRelationship{
+									{
+										AccountID: ec.String("ANID"),
+										TrustAll:  ec.Bool(true),
+									},
+									{
+										AccountID: ec.String("anotherID"),
+										TrustAll:  ec.Bool(false),
+										TrustAllowlist: []string{
+											"abc", "dfg", "hij",
+										},
+									},
+								},
+								External: []*models.ExternalTrustRelationship{
+									{
+										TrustRelationshipID: ec.String("external_id"),
+										TrustAll:            ec.Bool(true),
+									},
+									{
+										TrustRelationshipID: ec.String("another_external_id"),
+										TrustAll:            ec.Bool(false),
+										TrustAllowlist: []string{
+											"abc", "dfg",
+										},
+									},
+								},
+							},
+						},
+						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(false),
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version: "7.12.0",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("aws-io-optimized-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+								{
+									ID:                      "cold",
+									ZoneCount:               1,
+									InstanceConfigurationID: "aws.data.highstorage.d3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(2048),
+									},
+									NodeRoles: []string{
+										"data_cold",
+										"remote_cluster_client",
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{
+											"data": "cold",
+										},
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(0),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(59392),
+										Resource: ec.String("memory"),
+									},
+								},
+								{
+									ID:                      "hot_content",
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highio.i3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(8192),
+									},
+									NodeRoles: []string{
+										"master",
+										"ingest",
+										"remote_cluster_client",
+										"data_hot",
+										"transform",
+										"data_content",
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{"data": "hot"},
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(237568),
+										Resource: ec.String("memory"),
+									},
+								},
+								{
+									ID:                      "warm",
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highstorage.d3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(4096),
+									},
+									NodeRoles: []string{
+										"data_warm",
+										"remote_cluster_client",
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{
+											"data": "warm",
+										},
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(0),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		},
+		{
+			name: "parses the resources with empty declarations (Cross Cluster Search)",
+			args: args{
+				d:      deploymentCCS,
+				client: api.NewMock(mock.New200Response(ccsTpl())),
+			},
+			want: &models.DeploymentCreateRequest{
+				Name:     "my_deployment_name",
+				Settings: &models.DeploymentCreateSettings{},
+				Metadata: &models.DeploymentCreateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentCreateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ccsTpl(), false), &models.ElasticsearchPayload{
+						Region:   ec.String("us-east-1"),
+						RefID:    ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{},
+						Plan: &models.ElasticsearchClusterPlan{
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version: "7.9.2",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("aws-cross-cluster-search-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+								{
+									ID:                      "hot_content",
+									ZoneCount:               1,
+									InstanceConfigurationID: "aws.ccs.r5d",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(1024),
+									},
+									NodeType: &models.ElasticsearchNodeType{
+										Data:   ec.Bool(true),
+										Ingest: ec.Bool(true),
+										Master: ec.Bool(true),
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Kibana: []*models.KibanaPayload{
+						{
+							ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+							Region:                    ec.String("us-east-1"),
+							RefID:                     ec.String("main-kibana"),
+							Plan: &models.KibanaClusterPlan{
+								Kibana: &models.KibanaConfiguration{},
+								ClusterTopology: []*models.KibanaClusterTopologyElement{
+									{
+										ZoneCount:               1,
+										InstanceConfigurationID: "aws.kibana.r5d",
+										Size: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "parses the resources with tags",
 			args: args{
 				d:      deploymentWithTags,
 				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
@@ -2907,7 +3389,7 @@ func Test_createResourceToModel(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := createResourceToModel(tt.args.d, tt.args.client)
+			got, err := CreateResourceToModel(tt.args.d, tt.args.client)
 			if tt.err != nil {
 				assert.EqualError(t, err, tt.err.Error())
 			} else {
@@ -3019,6 +3501,44 @@ func Test_updateResourceToModel(t *testing.T) {
 		Schema: newSchema(),
 	})
 
+	deploymentIOOptimizedToHotWarmWithDiffSize := util.NewResourceData(t, util.ResDataParams{
+		ID: mock.ValidClusterID,
+		State: map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.9.2",
+			"elasticsearch": []interface{}{map[string]interface{}{
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id":                        "coordinating",
+						"size":                      "0g",
+						"instance_configuration_id": "aws.coordinating.m5d",
+					},
+					map[string]interface{}{
+						"id":                        "hot_content",
+						"size":                      "16g",
+						"zone_count":                3,
+						"instance_configuration_id": "aws.data.highio.i3",
+						"node_type_data":            "true",
+						"node_type_ingest":          "true",
+						"node_type_master":          "true",
+					},
+				},
+			}},
+			"kibana": []interface{}{map[string]interface{}{}},
+		},
+		Change: map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"deployment_template_id": "aws-hot-warm-v2",
+			"region":                 "us-east-1",
+			"version":                "7.9.2",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+			"kibana":                 []interface{}{map[string]interface{}{}},
+		},
+		Schema: newSchema(),
+	})
+
 	emptyTpl := func() io.ReadCloser {
 		return fileAsResponseBody(t, "testdata/template-empty.json")
 	}
@@ -3162,6 +3682,43 @@ func Test_updateResourceToModel(t *testing.T) {
 		Schema: newSchema(),
 	})
 
+	deploymentWithAliasChange := util.NewResourceData(t, util.ResDataParams{
+		ID: mock.ValidClusterID,
+		State: map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"alias":                  "my-alias",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.10.1",
+			"elasticsearch": []interface{}{
+				map[string]interface{}{
+					"version": "7.10.1",
+					"topology": []interface{}{map[string]interface{}{
+						"id":   "hot_content",
+						"size": "8g",
+					}},
+				},
+			},
+		},
+		Change: map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"alias":                  "my-new-alias",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.10.1",
+			"elasticsearch": []interface{}{
+				map[string]interface{}{
+					"version": "7.10.1",
+					"topology": []interface{}{map[string]interface{}{
+						"id":   "hot_content",
+						"size": "8g",
+					}},
+				},
+			},
+		},
+		Schema: newSchema(),
+	})
+
 	type args struct {
 		d      *schema.ResourceData
 		client *api.API
@@ -3731,7 +4288,7 @@ func Test_updateResourceToModel(t *testing.T) {
 								InstanceConfigurationID: "aws.ccs.r5d",
 								Size: &models.TopologySize{
 									Resource: ec.String("memory"),
-									Value:    ec.Int32(1024),
+									Value:    ec.Int32(2048),
 								},
 								NodeType: &models.ElasticsearchNodeType{
 									Data:   ec.Bool(true),
@@ -3769,7 +4326,7 @@ func Test_updateResourceToModel(t *testing.T) {
 			},
 		},
 		// The behavior of this change should be:
-		// * Resets the Elasticsearch topology: from 16g (due to unsetTopology call on DT change).
+		// * Keeps the Elasticsearch hot_content size to 16g, since the tier is part of both templates.
 		// * Keeps the kibana toplogy size to 2g even though the topology element has been removed (saved value persists).
 		// * Removes all other non present resources
 		{
@@ -3803,8 +4360,8 @@ func Test_updateResourceToModel(t *testing.T) {
 								InstanceConfigurationID: "aws.ccs.r5d",
 								Size: &models.TopologySize{
 									Resource: ec.String("memory"),
-									// This field's value is reset.
-									Value: ec.Int32(1024),
+									// This field's value is kept.
+									Value: ec.Int32(16384),
 								},
 								NodeType: &models.ElasticsearchNodeType{
 									Data:   ec.Bool(true),
@@ -3842,6 +4399,119 @@ func Test_updateResourceToModel(t *testing.T) {
 			},
 		},
 		// The behavior of this change should be:
+		// * Keeps the hot_content size and zone count, since the tier is part of both templates.
+		// * Adds the warm tier with the hot-warm template defaults.
+		{
+			name: "topology change with sizes not default from io optimized to hot warm",
+			args: args{
+				d:      deploymentIOOptimizedToHotWarmWithDiffSize,
+				client: api.NewMock(mock.New200Response(hotWarmTpl())),
+			},
+			want: &models.DeploymentUpdateRequest{
+				Name:         "my_deployment_name",
+				PruneOrphans: ec.Bool(true),
+				Settings:     &models.DeploymentUpdateSettings{},
+				Metadata: &models.DeploymentUpdateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentUpdateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, hotWarmTpl(), false), &models.ElasticsearchPayload{
+						Region: ec.String("us-east-1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+						},
+						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(false),
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version: "7.9.2",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("aws-hot-warm-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+								{
+									ID:                      "hot_content",
+									ZoneCount:               3,
+									InstanceConfigurationID: "aws.data.highio.i3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										// This field's value is kept.
+										Value: ec.Int32(16384),
+									},
+									NodeType: &models.ElasticsearchNodeType{
+										Data:   ec.Bool(true),
+										Ingest: ec.Bool(true),
+										Master: ec.Bool(true),
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{"data": "hot"},
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
+								},
+								{
+									ID:                      "warm",
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highstorage.d2",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(4096),
+									},
+									NodeType: &models.ElasticsearchNodeType{
+										Data:   ec.Bool(true),
+										Ingest: ec.Bool(true),
+										Master: ec.Bool(false),
+									},
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{
+											"data": "warm",
+										},
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(0),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
+								},
+							},
+						},
+					}),
+					Kibana: []*models.KibanaPayload{{
+						ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+						Region:                    ec.String("us-east-1"),
+						RefID:                     ec.String("main-kibana"),
+						Plan: &models.KibanaClusterPlan{
+							Kibana: &models.KibanaConfiguration{},
+							ClusterTopology: []*models.KibanaClusterTopologyElement{
+								{
+									ZoneCount:               1,
+									InstanceConfigurationID: "aws.kibana.r5d",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(1024),
+									},
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+		// The behavior of this change should be:
 		// * Keeps all topology sizes as they were defined (saved value persists).
 		{
 			name: "topology change with sizes not default from explicit value to empty",
@@ -4510,6 +5180,140 @@ func Test_updateResourceToModel(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "enables autoscaling using the current sizes as the autoscaling minimums",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID: mock.ValidClusterID,
+					State: map[string]interface{}{
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "aws-io-optimized-v2",
+						"region":                 "us-east-1",
+						"version":                "7.12.1",
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"topology": []interface{}{map[string]interface{}{
+								"id":   "hot_content",
+								"size": "16g",
+							}},
+						}},
+					},
+					Change: map[string]interface{}{
+						"autoscale_size_as_min":  true,
+						"name":                   "my_deployment_name",
+						"deployment_template_id": "aws-io-optimized-v2",
+						"region":                 "us-east-1",
+						"version":                "7.12.1",
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"autoscale": "true",
+							"topology": []interface{}{
+								map[string]interface{}{
+									"id":   "hot_content",
+									"size": "16g",
+								},
+								map[string]interface{}{
+									"id":   "warm",
+									"size": "8g",
+								},
+							},
+						}},
+					},
+					Schema: newSchema(),
+				}),
+				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
+			},
+			want: &models.DeploymentUpdateRequest{
+				Name:         "my_deployment_name",
+				PruneOrphans: ec.Bool(true),
+				Settings:     &models.DeploymentUpdateSettings{},
+				Metadata: &models.DeploymentUpdateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentUpdateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ioOptimizedTpl(), true), &models.ElasticsearchPayload{
+						Region: ec.String("us-east-1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+						},
+						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(true),
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version: "7.12.1",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("aws-io-optimized-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+								{
+									ID: "hot_content",
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{"data": "hot"},
+									},
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highio.i3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(16384),
+									},
+									NodeRoles: []string{
+										"master",
+										"ingest",
+										"remote_cluster_client",
+										"data_hot",
+										"transform",
+										"data_content",
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(1024),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
+									AutoscalingMin: &models.TopologySize{
+										Value:    ec.Int32(16384),
+										Resource: ec.String("memory"),
+									},
+								},
+								{
+									ID: "warm",
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										NodeAttributes: map[string]string{"data": "warm"},
+									},
+									ZoneCount:               2,
+									InstanceConfigurationID: "aws.data.highstorage.d3",
+									Size: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(8192),
+									},
+									NodeRoles: []string{
+										"data_warm",
+										"remote_cluster_client",
+									},
+									TopologyElementControl: &models.TopologyElementControl{
+										Min: &models.TopologySize{
+											Resource: ec.String("memory"),
+											Value:    ec.Int32(0),
+										},
+									},
+									AutoscalingMax: &models.TopologySize{
+										Value:    ec.Int32(118784),
+										Resource: ec.String("memory"),
+									},
+									AutoscalingMin: &models.TopologySize{
+										Value:    ec.Int32(8192),
+										Resource: ec.String("memory"),
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		},
 		{
 			name: "updates topologies configuration",
 			args: args{
@@ -4764,6 +5568,70 @@ func Test_updateResourceToModel(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "parses the resources with an alias change",
+			args: args{
+				d:      deploymentWithAliasChange,
+				client: api.NewMock(mock.New200Response(ioOptimizedTpl())),
+			},
+			want: &models.DeploymentUpdateRequest{
+				Name:         "my_deployment_name",
+				Alias:        "my-new-alias",
+				PruneOrphans: ec.Bool(true),
+				Settings:     &models.DeploymentUpdateSettings{},
+				Metadata: &models.DeploymentUpdateMetadata{
+					Tags: []*models.MetadataItem{},
+				},
+				Resources: &models.DeploymentUpdateResources{
+					Elasticsearch: enrichWithEmptyTopologies(readerToESPayload(t, ioOptimizedTpl(), true), &models.ElasticsearchPayload{
+						Region: ec.String("us-east-1"),
+						RefID:  ec.String("main-elasticsearch"),
+						Settings: &models.ElasticsearchClusterSettings{
+							DedicatedMastersThreshold: 6,
+						},
+						Plan: &models.ElasticsearchClusterPlan{
+							AutoscalingEnabled: ec.Bool(false),
+							Elasticsearch: &models.ElasticsearchConfiguration{
+								Version: "7.10.1",
+							},
+							DeploymentTemplate: &models.DeploymentTemplateReference{
+								ID: ec.String("aws-io-optimized-v2"),
+							},
+							ClusterTopology: []*models.ElasticsearchClusterTopologyElement{{
+								ID: "hot_content",
+								Elasticsearch: &models.ElasticsearchConfiguration{
+									NodeAttributes: map[string]string{"data": "hot"},
+								},
+								ZoneCount:               2,
+								InstanceConfigurationID: "aws.data.highio.i3",
+								Size: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(8192),
+								},
+								NodeRoles: []string{
+									"master",
+									"ingest",
+									"remote_cluster_client",
+									"data_hot",
+									"transform",
+									"data_content",
+								},
+								TopologyElementControl: &models.TopologyElementControl{
+									Min: &models.TopologySize{
+										Resource: ec.String("memory"),
+										Value:    ec.Int32(1024),
+									},
+								},
+								AutoscalingMax: &models.TopologySize{
+									Value:    ec.Int32(118784),
+									Resource: ec.String("memory"),
+								},
+							}},
+						},
+					}),
+				},
+			},
+		},
 		{
 			name: "handles a snapshot_source block adding Strategy: partial",
 			args: args{
@@ -5031,31 +5899,320 @@ func Test_updateResourceToModel(t *testing.T) {
 			},
 		},
 		{
-			name: "topology change with invalid resources returns an error",
-			args: args{
-				d:      deploymentChangeToEmptyDT,
-				client: api.NewMock(mock.New200Response(emptyTpl())),
-			},
-			err: multierror.NewPrefixed("invalid configuration",
-				errors.New("kibana specified but deployment template is not configured for it. Use a different template if you wish to add kibana"),
-				errors.New("apm specified but deployment template is not configured for it. Use a different template if you wish to add apm"),
-				errors.New("enterprise_search specified but deployment template is not configured for it. Use a different template if you wish to add enterprise_search"),
-			),
+			name: "topology change with invalid resources returns an error",
+			args: args{
+				d:      deploymentChangeToEmptyDT,
+				client: api.NewMock(mock.New200Response(emptyTpl())),
+			},
+			err: multierror.NewPrefixed("invalid configuration",
+				errors.New("kibana specified but deployment template is not configured for it. Use a different template if you wish to add kibana"),
+				errors.New("apm specified but deployment template is not configured for it. Use a different template if you wish to add apm"),
+				errors.New("enterprise_search specified but deployment template is not configured for it. Use a different template if you wish to add enterprise_search"),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateResourceToModel(tt.args.d, tt.args.client)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_updateResourceToModelPlanStrategy(t *testing.T) {
+	hotWarmTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-aws-hot-warm-v2.json")
+	}
+	newRD := func(strategy string) *schema.ResourceData {
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State: map[string]interface{}{
+				"name":                   "my_deployment_name",
+				"deployment_template_id": "aws-hot-warm-v2",
+				"region":                 "us-east-1",
+				"version":                "7.9.2",
+				"elasticsearch": []interface{}{map[string]interface{}{
+					"plan_strategy": strategy,
+				}},
+			},
+		})
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		want     *models.TransientElasticsearchPlanConfiguration
+	}{
+		{
+			name: "leaves the transient block unset without a plan strategy",
+		},
+		{
+			name:     "sets the grow_and_shrink strategy",
+			strategy: "grow_and_shrink",
+			want: &models.TransientElasticsearchPlanConfiguration{
+				Strategy: &models.PlanStrategy{
+					GrowAndShrink: map[string]interface{}{},
+				},
+			},
+		},
+		{
+			name:     "sets the rolling strategy",
+			strategy: "rolling",
+			want: &models.TransientElasticsearchPlanConfiguration{
+				Strategy: &models.PlanStrategy{
+					Rolling: &models.RollingStrategyConfig{},
+				},
+			},
+		},
+		{
+			name:     "sets the rolling_grow_and_shrink strategy",
+			strategy: "rolling_grow_and_shrink",
+			want: &models.TransientElasticsearchPlanConfiguration{
+				Strategy: &models.PlanStrategy{
+					RollingGrowAndShrink: map[string]interface{}{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateResourceToModel(
+				newRD(tt.strategy), api.NewMock(mock.New200Response(hotWarmTpl())),
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if assert.Len(t, got.Resources.Elasticsearch, 1) {
+				assert.Equal(t, tt.want, got.Resources.Elasticsearch[0].Plan.Transient)
+			}
+		})
+	}
+}
+
+func Test_updateResourceToModelClearDockerImages(t *testing.T) {
+	hotWarmTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-aws-hot-warm-v2.json")
+	}
+	withConfig := func(cfg map[string]interface{}) []interface{} {
+		if cfg == nil {
+			return []interface{}{map[string]interface{}{}}
+		}
+		return []interface{}{map[string]interface{}{
+			"config": []interface{}{cfg},
+		}}
+	}
+	newState := func(cfg func(image string) map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"deployment_template_id": "aws-hot-warm-v2",
+			"region":                 "us-east-1",
+			"version":                "7.9.2",
+			"elasticsearch":          withConfig(cfg("docker.elastic.co/cloud-ci/elasticsearch:7.9.2")),
+			"kibana":                 withConfig(cfg("docker.elastic.co/cloud-ci/kibana:7.9.2")),
+			"apm":                    withConfig(cfg("docker.elastic.co/cloud-ci/apm:7.9.2")),
+			"enterprise_search":      withConfig(cfg("docker.elastic.co/cloud-ci/enterprise-search:7.9.2")),
+		}
+	}
+	images := func(image string) map[string]interface{} {
+		return map[string]interface{}{"docker_image": image}
+	}
+
+	tests := []struct {
+		name   string
+		change map[string]interface{}
+	}{
+		{
+			name: "clears the images when the config blocks are removed",
+			change: newState(func(string) map[string]interface{} {
+				return nil
+			}),
+		},
+		{
+			name: "clears the images when only the docker_image fields are removed",
+			change: newState(func(string) map[string]interface{} {
+				return map[string]interface{}{"user_settings_yaml": "some.setting: value"}
+			}),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := updateResourceToModel(tt.args.d, tt.args.client)
-			if tt.err != nil {
-				assert.EqualError(t, err, tt.err.Error())
-			} else {
-				assert.NoError(t, err)
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State:  newState(images),
+				Change: tt.change,
+			})
+			got, err := updateResourceToModel(d, api.NewMock(mock.New200Response(hotWarmTpl())))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			res := got.Resources
+			if assert.Len(t, res.Elasticsearch, 1) {
+				assert.Empty(t, res.Elasticsearch[0].Plan.Elasticsearch.DockerImage)
+			}
+			if assert.Len(t, res.Kibana, 1) {
+				assert.Empty(t, res.Kibana[0].Plan.Kibana.DockerImage)
+			}
+			if assert.Len(t, res.Apm, 1) {
+				assert.Empty(t, res.Apm[0].Plan.Apm.DockerImage)
+			}
+			if assert.Len(t, res.EnterpriseSearch, 1) {
+				assert.Empty(t, res.EnterpriseSearch[0].Plan.EnterpriseSearch.DockerImage)
+			}
+		})
+	}
+}
+
+func Test_updateResourceToModelUserSettings(t *testing.T) {
+	hotWarmTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-aws-hot-warm-v2.json")
+	}
+	newState := func(yml, overrideYml string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                   "my_deployment_name",
+			"deployment_template_id": "aws-hot-warm-v2",
+			"region":                 "us-east-1",
+			"version":                "7.9.2",
+			"elasticsearch": []interface{}{map[string]interface{}{
+				"config": []interface{}{map[string]interface{}{
+					"user_settings_yaml":          yml,
+					"user_settings_override_yaml": overrideYml,
+				}},
+			}},
+		}
+	}
+	tests := []struct {
+		name            string
+		change          map[string]interface{}
+		wantYaml        string
+		wantOverrideYml string
+	}{
+		{
+			name:            "keeps the override settings when only the user settings change",
+			change:          newState("action.auto_create_index: false", "xpack.security.audit.enabled: true"),
+			wantYaml:        "action.auto_create_index: false",
+			wantOverrideYml: "xpack.security.audit.enabled: true",
+		},
+		{
+			name:            "keeps the user settings when only the override settings change",
+			change:          newState("action.auto_create_index: true", "xpack.security.audit.enabled: false"),
+			wantYaml:        "action.auto_create_index: true",
+			wantOverrideYml: "xpack.security.audit.enabled: false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State:  newState("action.auto_create_index: true", "xpack.security.audit.enabled: true"),
+				Change: tt.change,
+			})
+			got, err := updateResourceToModel(d, api.NewMock(mock.New200Response(hotWarmTpl())))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if assert.Len(t, got.Resources.Elasticsearch, 1) {
+				cfg := got.Resources.Elasticsearch[0].Plan.Elasticsearch
+				assert.Equal(t, tt.wantYaml, cfg.UserSettingsYaml)
+				assert.Equal(t, tt.wantOverrideYml, cfg.UserSettingsOverrideYaml)
 			}
-			assert.Equal(t, tt.want, got)
 		})
 	}
 }
 
+func Test_updateResourceToModelMigrateToLatestHardware(t *testing.T) {
+	hotWarmTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-aws-hot-warm-v2.json")
+	}
+	withInstanceConfiguration := func(id string) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"topology": []interface{}{map[string]interface{}{
+				"instance_configuration_id": id,
+			}},
+		}}
+	}
+	newState := func(migrate bool) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                       "my_deployment_name",
+			"deployment_template_id":     "aws-hot-warm-v2",
+			"region":                     "us-east-1",
+			"version":                    "7.9.2",
+			"migrate_to_latest_hardware": migrate,
+			"elasticsearch":              []interface{}{map[string]interface{}{}},
+			"kibana":                     withInstanceConfiguration("aws.kibana.r4"),
+			"apm":                        withInstanceConfiguration("aws.apm.r4"),
+			"enterprise_search":          withInstanceConfiguration("aws.enterprisesearch.m4"),
+		}
+	}
+
+	t.Run("migrates the instance configurations when set", func(t *testing.T) {
+		d := util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State:  newState(false),
+			Change: newState(true),
+		})
+		got, err := updateResourceToModel(d, api.NewMock(mock.New200Response(hotWarmTpl())))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		res := got.Resources
+		if assert.Len(t, res.Kibana, 1) {
+			assert.Equal(t, "aws.kibana.r5d", res.Kibana[0].Plan.ClusterTopology[0].InstanceConfigurationID)
+		}
+		if assert.Len(t, res.Apm, 1) {
+			assert.Equal(t, "aws.apm.r5d", res.Apm[0].Plan.ClusterTopology[0].InstanceConfigurationID)
+		}
+		if assert.Len(t, res.EnterpriseSearch, 1) {
+			assert.Equal(t, "aws.enterprisesearch.m5d", res.EnterpriseSearch[0].Plan.ClusterTopology[0].InstanceConfigurationID)
+		}
+	})
+
+	t.Run("keeps the outdated instance configurations when not set", func(t *testing.T) {
+		d := util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State:  newState(false),
+			Change: newState(false),
+		})
+		_, err := updateResourceToModel(d, api.NewMock(mock.New200Response(hotWarmTpl())))
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `invalid instance_configuration_id: "aws.kibana.r4"`)
+		}
+	})
+}
+
+func Test_unsetInstanceConfigurations(t *testing.T) {
+	kibana := []interface{}{map[string]interface{}{
+		"ref_id": "main-kibana",
+		"topology": []interface{}{map[string]interface{}{
+			"instance_configuration_id": "aws.kibana.r4",
+			"size":                      "1g",
+		}},
+	}}
+	apm := []interface{}{map[string]interface{}{"ref_id": "main-apm"}}
+
+	unsetInstanceConfigurations(kibana, apm, nil)
+
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"ref_id": "main-kibana",
+		"topology": []interface{}{map[string]interface{}{
+			"size": "1g",
+		}},
+	}}, kibana)
+	assert.Equal(t, []interface{}{map[string]interface{}{"ref_id": "main-apm"}}, apm)
+}
+
 func Test_ensurePartialSnapshotStrategy(t *testing.T) {
 	type args struct {
 		ess []*models.ElasticsearchPayload
@@ -5109,6 +6266,52 @@ func Test_ensurePartialSnapshotStrategy(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "replaces the full strategy with partial",
+			args: args{ess: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{
+					Transient: &models.TransientElasticsearchPlanConfiguration{
+						RestoreSnapshot: &models.RestoreSnapshotConfiguration{
+							SourceClusterID: "some",
+							Strategy:        "full",
+						},
+					},
+				},
+			}}},
+			want: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{
+					Transient: &models.TransientElasticsearchPlanConfiguration{
+						RestoreSnapshot: &models.RestoreSnapshotConfiguration{
+							SourceClusterID: "some",
+							Strategy:        "partial",
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "leaves the recovery strategy as is",
+			args: args{ess: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{
+					Transient: &models.TransientElasticsearchPlanConfiguration{
+						RestoreSnapshot: &models.RestoreSnapshotConfiguration{
+							SourceClusterID: "some",
+							Strategy:        "recovery",
+						},
+					},
+				},
+			}}},
+			want: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{
+					Transient: &models.TransientElasticsearchPlanConfiguration{
+						RestoreSnapshot: &models.RestoreSnapshotConfiguration{
+							SourceClusterID: "some",
+							Strategy:        "recovery",
+						},
+					},
+				},
+			}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -5117,3 +6320,262 @@ func Test_ensurePartialSnapshotStrategy(t *testing.T) {
 		})
 	}
 }
+
+func Test_expandAutoscale(t *testing.T) {
+	newPayloads := func() []*models.ElasticsearchPayload {
+		return []*models.ElasticsearchPayload{{
+			Plan: &models.ElasticsearchClusterPlan{AutoscalingEnabled: ec.Bool(true)},
+		}}
+	}
+	type args struct {
+		d   *schema.ResourceData
+		ess []*models.ElasticsearchPayload
+	}
+	tests := []struct {
+		name string
+		args args
+		want []*models.ElasticsearchPayload
+	}{
+		{
+			name: "leaves the autoscaling setting untouched when autoscale isn't set",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"elasticsearch": []interface{}{map[string]interface{}{
+							"autoscale": "true",
+						}},
+					},
+				}),
+				ess: newPayloads(),
+			},
+			want: newPayloads(),
+		},
+		{
+			name: "disables autoscaling when autoscale is explicitly false",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"autoscale":     false,
+						"elasticsearch": []interface{}{map[string]interface{}{}},
+					},
+				}),
+				ess: newPayloads(),
+			},
+			want: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{AutoscalingEnabled: ec.Bool(false)},
+			}},
+		},
+		{
+			name: "enables autoscaling when autoscale is true",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"autoscale":     true,
+						"elasticsearch": []interface{}{map[string]interface{}{}},
+					},
+				}),
+				ess: []*models.ElasticsearchPayload{{
+					Plan: &models.ElasticsearchClusterPlan{},
+				}},
+			},
+			want: newPayloads(),
+		},
+		{
+			name: "enables autoscaling on all the elasticsearch resources",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"autoscale":     true,
+						"elasticsearch": []interface{}{map[string]interface{}{}},
+					},
+				}),
+				ess: []*models.ElasticsearchPayload{
+					{Plan: &models.ElasticsearchClusterPlan{}},
+					{Plan: &models.ElasticsearchClusterPlan{AutoscalingEnabled: ec.Bool(false)}},
+				},
+			},
+			want: []*models.ElasticsearchPayload{
+				{Plan: &models.ElasticsearchClusterPlan{AutoscalingEnabled: ec.Bool(true)}},
+				{Plan: &models.ElasticsearchClusterPlan{AutoscalingEnabled: ec.Bool(true)}},
+			},
+		},
+		{
+			name: "leaves the topology autoscaling limits untouched",
+			args: args{
+				d: util.NewResourceData(t, util.ResDataParams{
+					ID:     mock.ValidClusterID,
+					Schema: newSchema(),
+					State: map[string]interface{}{
+						"autoscale":     true,
+						"elasticsearch": []interface{}{map[string]interface{}{}},
+					},
+				}),
+				ess: []*models.ElasticsearchPayload{{
+					Plan: &models.ElasticsearchClusterPlan{
+						ClusterTopology: []*models.ElasticsearchClusterTopologyElement{{
+							ID: "ml",
+							AutoscalingMin: &models.TopologySize{
+								Value: ec.Int32(0), Resource: ec.String("memory"),
+							},
+							AutoscalingMax: &models.TopologySize{
+								Value: ec.Int32(61440), Resource: ec.String("memory"),
+							},
+						}},
+					},
+				}},
+			},
+			want: []*models.ElasticsearchPayload{{
+				Plan: &models.ElasticsearchClusterPlan{
+					AutoscalingEnabled: ec.Bool(true),
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{{
+						ID: "ml",
+						AutoscalingMin: &models.TopologySize{
+							Value: ec.Int32(0), Resource: ec.String("memory"),
+						},
+						AutoscalingMax: &models.TopologySize{
+							Value: ec.Int32(61440), Resource: ec.String("memory"),
+						},
+					}},
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expandAutoscale(tt.args.d, tt.args.ess)
+			assert.Equal(t, tt.want, tt.args.ess)
+		})
+	}
+}
+
+func Test_enrichElasticsearchTemplateTierNodeRoles(t *testing.T) {
+	newTemplate := func() *models.ElasticsearchPayload {
+		return &models.ElasticsearchPayload{
+			Plan: &models.ElasticsearchClusterPlan{
+				Elasticsearch: &models.ElasticsearchConfiguration{},
+				ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+					{
+						ID:        "hot_content",
+						NodeRoles: []string{"data_hot", "ingest"},
+						NodeType:  &models.ElasticsearchNodeType{Data: ec.Bool(true)},
+					},
+					{
+						ID:       "ml",
+						NodeType: &models.ElasticsearchNodeType{Ml: ec.Bool(true)},
+					},
+					{
+						ID:        "transform",
+						NodeRoles: []string{"transform"},
+					},
+					{ID: "warm"},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name         string
+		useNodeRoles bool
+		want         map[string][]string
+	}{
+		{
+			name:         "sets the default roles of the tiers without node_roles",
+			useNodeRoles: true,
+			want: map[string][]string{
+				"hot_content": {"data_hot", "ingest"},
+				"ml":          {"ml", "remote_cluster_client"},
+				"transform":   {"transform"},
+				"warm":        nil,
+			},
+		},
+		{
+			name: "leaves the node_roles unset on versions without node roles",
+			want: map[string][]string{
+				"hot_content": nil,
+				"ml":          nil,
+				"transform":   nil,
+				"warm":        nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := enrichElasticsearchTemplate(newTemplate(), "aws-io-optimized-v2", "7.12.0", tt.useNodeRoles)
+			roles := make(map[string][]string)
+			for _, topology := range got.Plan.ClusterTopology {
+				roles[topology.ID] = topology.NodeRoles
+			}
+			assert.Equal(t, tt.want, roles)
+		})
+	}
+}
+
+func Test_expandUpdateTags(t *testing.T) {
+	d := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State: map[string]interface{}{
+			"tags": map[string]interface{}{"owner": "elastic"},
+		},
+	})
+	assert.NoError(t, d.Set("system_tags", map[string]interface{}{"marketplace": "aws"}))
+
+	assert.Equal(t, []*models.MetadataItem{
+		{Key: ec.String("marketplace"), Value: ec.String("aws")},
+		{Key: ec.String("owner"), Value: ec.String("elastic")},
+	}, expandUpdateTags(d))
+}
+
+func Test_expandTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]interface{}
+		want []*models.MetadataItem
+	}{
+		{
+			name: "returns an empty list without tags, which clears them on update",
+			want: []*models.MetadataItem{},
+		},
+		{
+			name: "returns an empty list with empty tags",
+			tags: map[string]interface{}{},
+			want: []*models.MetadataItem{},
+		},
+		{
+			name: "sorts the tags by key",
+			tags: map[string]interface{}{
+				"team":  "cloud",
+				"cost":  "rnd",
+				"owner": "elastic",
+			},
+			want: []*models.MetadataItem{
+				{Key: ec.String("cost"), Value: ec.String("rnd")},
+				{Key: ec.String("owner"), Value: ec.String("elastic")},
+				{Key: ec.String("team"), Value: ec.String("cloud")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandTags(tt.tags)
+			assert.Equal(t, tt.want, got)
+
+			// The expanded tags are read back as they were set, and empty
+			// tags aren't persisted, matching a configuration without tags.
+			user, system := flattenTags(got, nil)
+			assert.Nil(t, system)
+			if len(tt.tags) == 0 {
+				assert.Nil(t, user)
+			} else {
+				assert.Equal(t, tt.tags, user)
+			}
+		})
+	}
+}
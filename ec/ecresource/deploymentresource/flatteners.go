@@ -18,9 +18,12 @@
 package deploymentresource
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
@@ -40,9 +43,20 @@ func modelToState(d *schema.ResourceData, res *models.DeploymentGetResponse, rem
 	}
 
 	if res.Metadata != nil {
-		if err := d.Set("tags", flattenTags(res.Metadata.Tags)); err != nil {
+		tags, systemTags := flattenTags(res.Metadata.Tags, isSystemTag(d))
+		if err := d.Set("tags", tags); err != nil {
 			return err
 		}
+
+		if err := d.Set("system_tags", systemTags); err != nil {
+			return err
+		}
+
+		if lastModified := flattenLastModified(res.Metadata); lastModified != "" {
+			if err := d.Set("last_modified", lastModified); err != nil {
+				return err
+			}
+		}
 	}
 
 	if res.Resources != nil {
@@ -59,12 +73,22 @@ func modelToState(d *schema.ResourceData, res *models.DeploymentGetResponse, rem
 			return err
 		}
 
+		// The plan history might not be part of the response, in which case
+		// the creation time which was previously read is kept.
+		if createdAt := flattenCreatedAt(res.Resources.Elasticsearch); createdAt != "" {
+			if err := d.Set("created_at", createdAt); err != nil {
+				return err
+			}
+		}
+
 		// We're reconciling the version and storing the lowest version of any
 		// of the deployment resources. This ensures that if an upgrade fails,
 		// the state version will be lower than the desired version, making
 		// retries possible. Once more resource types are added, the function
 		// needs to be modified to check those as well.
-		version, err := getLowestVersion(res.Resources)
+		// Resources with a "version" override are left out, since these
+		// don't follow the deployment version.
+		version, err := getLowestVersion(withoutVersionOverrides(d, res.Resources))
 		if err != nil {
 			// This code path is highly unlikely, but we're bubbling up the
 			// error in case one of the versions isn't parseable by semver.
@@ -74,15 +98,36 @@ func modelToState(d *schema.ResourceData, res *models.DeploymentGetResponse, rem
 			return err
 		}
 
+		versions := resourceVersions(res.Resources)
 		esFlattened, err := flattenEsResources(res.Resources.Elasticsearch, *res.Name, remotes)
 		if err != nil {
 			return err
 		}
+		priorEs := d.Get("elasticsearch").([]interface{})
+		setAutoscalingDisabled(esFlattened, priorEs)
+		setNodeAttributes(esFlattened, priorEs)
+		setPlanStrategy(esFlattened, priorEs)
+		setDedicatedMasters(esFlattened, priorEs)
+		setEquivalentUserSettings(esFlattened, priorEs)
+		setEsSecuritySettings(esFlattened, priorEs)
 		if err := d.Set("elasticsearch", esFlattened); err != nil {
 			return err
 		}
 
+		// The deployment level "autoscale" is only read once it has been
+		// set, so autoscaling being enabled or disabled outside of Terraform
+		// shows as a drift instead of being silently ignored.
+		if _, ok := d.GetOkExists("autoscale"); ok { //nolint:staticcheck
+			if autoscale := flattenAutoscale(res.Resources.Elasticsearch); autoscale != nil {
+				if err := d.Set("autoscale", *autoscale); err != nil {
+					return err
+				}
+			}
+		}
+
 		kibanaFlattened := flattenKibanaResources(res.Resources.Kibana, *res.Name)
+		setVersionOverride(d, "kibana", kibanaFlattened, versions["kibana"])
+		setEquivalentUserSettings(kibanaFlattened, d.Get("kibana").([]interface{}))
 		if len(kibanaFlattened) > 0 {
 			if err := d.Set("kibana", kibanaFlattened); err != nil {
 				return err
@@ -90,20 +135,31 @@ func modelToState(d *schema.ResourceData, res *models.DeploymentGetResponse, rem
 		}
 
 		apmFlattened := flattenApmResources(res.Resources.Apm, *res.Name)
+		setVersionOverride(d, "apm", apmFlattened, versions["apm"])
+		setEquivalentUserSettings(apmFlattened, d.Get("apm").([]interface{}))
 		if len(apmFlattened) > 0 {
+			setApmSecretToken(apmFlattened, d.Get("apm_secret_token").(string))
 			if err := d.Set("apm", apmFlattened); err != nil {
 				return err
 			}
 		}
 
 		integrationsServerFlattened := flattenIntegrationsServerResources(res.Resources.IntegrationsServer, *res.Name)
+		setVersionOverride(d, "integrations_server", integrationsServerFlattened, versions["integrations_server"])
+		setEquivalentUserSettings(integrationsServerFlattened, d.Get("integrations_server").([]interface{}))
 		if len(integrationsServerFlattened) > 0 {
 			if err := d.Set("integrations_server", integrationsServerFlattened); err != nil {
 				return err
 			}
 		}
 
+		if err := clearInactiveApmKind(d, apmFlattened, integrationsServerFlattened); err != nil {
+			return err
+		}
+
 		enterpriseSearchFlattened := flattenEssResources(res.Resources.EnterpriseSearch, *res.Name)
+		setVersionOverride(d, "enterprise_search", enterpriseSearchFlattened, versions["enterprise_search"])
+		setEquivalentUserSettings(enterpriseSearchFlattened, d.Get("enterprise_search").([]interface{}))
 		if len(enterpriseSearchFlattened) > 0 {
 			if err := d.Set("enterprise_search", enterpriseSearchFlattened); err != nil {
 				return err
@@ -116,7 +172,7 @@ func modelToState(d *schema.ResourceData, res *models.DeploymentGetResponse, rem
 			}
 		}
 
-		if observability := flattenObservability(res.Settings); len(observability) > 0 {
+		if observability := flattenObservability(res.Settings, d.Id()); len(observability) > 0 {
 			if err := d.Set("observability", observability); err != nil {
 				return err
 			}
@@ -161,6 +217,48 @@ func getDeploymentTemplateID(res *models.DeploymentResources) (string, error) {
 	return deploymentTemplateID, nil
 }
 
+// clearInactiveApmKind removes the "apm" or the "integrations_server" block
+// from the state when only the other resource kind exists in the deployment,
+// such as once the APM resource has been replaced by an Integrations Server
+// one. This prevents the computed endpoints of the inactive resource kind
+// from being kept in the state.
+func clearInactiveApmKind(d *schema.ResourceData, apm, integrationsServer []interface{}) error {
+	if len(apm) > 0 && len(integrationsServer) == 0 {
+		if prior, _ := d.Get("integrations_server").([]interface{}); len(prior) > 0 {
+			return d.Set("integrations_server", nil)
+		}
+	}
+
+	if len(integrationsServer) > 0 && len(apm) == 0 {
+		if prior, _ := d.Get("apm").([]interface{}); len(prior) > 0 {
+			return d.Set("apm", nil)
+		}
+	}
+
+	return nil
+}
+
+// setElasticsearchCredentials sets the Elasticsearch username and password in
+// the Terraform state, leaving the current ones untouched when these are
+// unset or empty. It's used for any API response which returns the
+// Elasticsearch credentials, such as the ones of a password reset.
+func setElasticsearchCredentials(d *schema.ResourceData, username, password *string) error {
+	var merr = multierror.NewPrefixed("failed setting elasticsearch credentials")
+	if username != nil && *username != "" {
+		if err := d.Set("elasticsearch_username", *username); err != nil {
+			merr = merr.Append(err)
+		}
+	}
+
+	if password != nil && *password != "" {
+		if err := d.Set("elasticsearch_password", *password); err != nil {
+			merr = merr.Append(err)
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
 // parseCredentials parses the Create or Update response Resources populating
 // credential settings in the Terraform state if the keys are found, currently
 // populates the following credentials in plain text:
@@ -170,16 +268,8 @@ func parseCredentials(d *schema.ResourceData, resources []*models.DeploymentReso
 	for _, res := range resources {
 		// Parse ES credentials
 		if creds := res.Credentials; creds != nil {
-			if creds.Username != nil && *creds.Username != "" {
-				if err := d.Set("elasticsearch_username", *creds.Username); err != nil {
-					merr = merr.Append(err)
-				}
-			}
-
-			if creds.Password != nil && *creds.Password != "" {
-				if err := d.Set("elasticsearch_password", *creds.Password); err != nil {
-					merr = merr.Append(err)
-				}
+			if err := setElasticsearchCredentials(d, creds.Username, creds.Password); err != nil {
+				merr = merr.Append(err)
 			}
 		}
 
@@ -189,6 +279,15 @@ func parseCredentials(d *schema.ResourceData, resources []*models.DeploymentReso
 				merr = merr.Append(err)
 			}
 		}
+
+		if res.Kind != nil && *res.Kind == "apm" && res.SecretToken != "" {
+			if apm, ok := d.Get("apm").([]interface{}); ok && len(apm) > 0 {
+				setApmSecretToken(apm, res.SecretToken)
+				if err := d.Set("apm", apm); err != nil {
+					merr = merr.Append(err)
+				}
+			}
+		}
 	}
 
 	return merr.ErrorOrNil()
@@ -306,15 +405,240 @@ func hasRunningResources(res *models.DeploymentGetResponse) bool {
 	return hasRunning
 }
 
-func flattenTags(tags []*models.MetadataItem) map[string]interface{} {
-	if len(tags) == 0 {
+// flattenTags parses the deployment tags into the user managed tags and the
+// system tags, which are the ones that isSystem returns true for. The system
+// tags are kept apart so these don't cause a diff on the user managed "tags".
+// The tags are keyed by their name, so the order in which the API returns
+// them doesn't matter.
+func flattenTags(tags []*models.MetadataItem, isSystem func(key string) bool) (user, system map[string]interface{}) {
+	for _, tag := range tags {
+		if tag == nil || tag.Key == nil || tag.Value == nil {
+			continue
+		}
+
+		if isSystem != nil && isSystem(*tag.Key) {
+			if system == nil {
+				system = make(map[string]interface{})
+			}
+			system[*tag.Key] = *tag.Value
+			continue
+		}
+
+		if user == nil {
+			user = make(map[string]interface{})
+		}
+		user[*tag.Key] = *tag.Value
+	}
+
+	return user, system
+}
+
+// isSystemTag returns a function which returns true for the tags injected by
+// Elastic Cloud. These are the tags kept in "system_tags" and, when the
+// deployment has just been created, the tags which weren't configured, since
+// Elastic Cloud injects them on creation. The configured tags are never system
+// tags, so tags added outside of Terraform later on show as a diff.
+func isSystemTag(d *schema.ResourceData) func(key string) bool {
+	system, _ := d.Get("system_tags").(map[string]interface{})
+	configured, _ := d.Get("tags").(map[string]interface{})
+	created := d.IsNewResource()
+	return func(key string) bool {
+		if _, ok := configured[key]; ok {
+			return false
+		}
+		_, ok := system[key]
+		return ok || created
+	}
+}
+
+// flattenCreatedAt returns the time the deployment was created, which is the
+// earliest attempt start time of the Elasticsearch resources plans, formatted
+// as RFC3339. An empty string is returned when no plan attempts are found.
+func flattenCreatedAt(resources []*models.ElasticsearchResourceInfo) string {
+	var createdAt time.Time
+	for _, res := range resources {
+		if res.Info == nil || res.Info.PlanInfo == nil {
+			continue
+		}
+
+		plans := append([]*models.ElasticsearchClusterPlanInfo{
+			res.Info.PlanInfo.Current,
+		}, res.Info.PlanInfo.History...)
+		for _, plan := range plans {
+			if plan == nil {
+				continue
+			}
+
+			start := time.Time(plan.AttemptStartTime)
+			if start.Unix() <= 0 {
+				continue
+			}
+
+			if createdAt.IsZero() || start.Before(createdAt) {
+				createdAt = start
+			}
+		}
+	}
+
+	if createdAt.IsZero() {
+		return ""
+	}
+
+	return createdAt.UTC().Format(time.RFC3339)
+}
+
+// flattenLastModified returns the most recent time either the deployment
+// metadata or any of its resource plans were changed, formatted as RFC3339.
+// An empty string is returned when neither is set.
+func flattenLastModified(metadata *models.DeploymentMetadata) string {
+	var lastModified time.Time
+	if metadata.LastModified != nil {
+		lastModified = time.Time(*metadata.LastModified)
+	}
+
+	if planModified := time.Time(metadata.LastResourcePlanModified); planModified.After(lastModified) {
+		lastModified = planModified
+	}
+
+	if lastModified.Unix() <= 0 {
+		return ""
+	}
+
+	return lastModified.UTC().Format(time.RFC3339)
+}
+
+// flattenResourceIDs returns the ID of each of the deployment resources keyed
+// by its ref_id, so these can be referenced without traversing the nested
+// resource blocks.
+func flattenResourceIDs(res *models.DeploymentResources) map[string]interface{} {
+	ids := make(map[string]interface{})
+	if res == nil {
+		return ids
+	}
+
+	add := func(refID, id *string) {
+		if refID != nil && *refID != "" && id != nil && *id != "" {
+			ids[*refID] = *id
+		}
+	}
+
+	for _, r := range res.Elasticsearch {
+		add(r.RefID, r.ID)
+	}
+	for _, r := range res.Kibana {
+		add(r.RefID, r.ID)
+	}
+	for _, r := range res.Apm {
+		add(r.RefID, r.ID)
+	}
+	for _, r := range res.IntegrationsServer {
+		add(r.RefID, r.ID)
+	}
+	for _, r := range res.EnterpriseSearch {
+		add(r.RefID, r.ID)
+	}
+
+	return ids
+}
+
+// flattenUserSettings sets the user settings of any of the deployment resource
+// kinds in m, as read back from the API, so that drift is uniformly detected.
+// Empty settings are left out of m.
+func flattenUserSettings(m map[string]interface{}, yml, overrideYml string, js, overrideJs interface{}) {
+	if yml != "" {
+		m["user_settings_yaml"] = yml
+	}
+
+	if overrideYml != "" {
+		m["user_settings_override_yaml"] = overrideYml
+	}
+
+	if js != nil {
+		if b, _ := json.Marshal(js); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
+			m["user_settings_json"] = string(b)
+		}
+	}
+
+	if overrideJs != nil {
+		if b, _ := json.Marshal(overrideJs); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
+			m["user_settings_override_json"] = string(b)
+		}
+	}
+}
+
+// setEquivalentUserSettings keeps the YAML user settings of the prior state in
+// the flattened resources when they're equivalent to the ones read back from
+// the API, since the API canonicalizes the settings, such as dropping the
+// quotes from durations and byte sizes, which would otherwise show up as a
+// perpetual diff.
+func setEquivalentUserSettings(flattened, prior []interface{}) {
+	forEachPrior(flattened, prior, "ref_id", func(m, priorM map[string]interface{}) {
+		cfg := firstConfig(m)
+		priorCfg := firstConfig(priorM)
+		if cfg == nil || priorCfg == nil {
+			return
+		}
+
+		for _, key := range []string{"user_settings_yaml", "user_settings_override_yaml"} {
+			yml, _ := cfg[key].(string)
+			priorYml, _ := priorCfg[key].(string)
+			if yml != "" && priorYml != "" && suppressEquivalentYaml(key, priorYml, yml, nil) {
+				cfg[key] = priorYml
+			}
+		}
+	})
+}
+
+// forEachPrior calls fn with each of the flattened elements and the element
+// of the prior state which has the same key value, such as the "ref_id" of
+// the resources or the "id" of the topology elements, or nil when there's
+// none. Matching the elements by key keeps the prior values attached to the
+// right element when the elements are reordered, added or removed.
+func forEachPrior(flattened, prior []interface{}, key string, fn func(m, priorM map[string]interface{})) {
+	priorByKey := make(map[string]map[string]interface{}, len(prior))
+	for _, raw := range prior {
+		if priorM, ok := raw.(map[string]interface{}); ok {
+			if k, _ := priorM[key].(string); k != "" {
+				priorByKey[k] = priorM
+			}
+		}
+	}
+
+	for _, raw := range flattened {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		k, _ := m[key].(string)
+		fn(m, priorByKey[k])
+	}
+}
+
+// forEachPriorTopology calls fn with each of the flattened Elasticsearch
+// topology elements and the prior state element of the same resource and
+// topology id, or nil when there's none.
+func forEachPriorTopology(es, prior []interface{}, fn func(topology, priorTopology map[string]interface{})) {
+	forEachPrior(es, prior, "ref_id", func(m, priorM map[string]interface{}) {
+		topologies, _ := m["topology"].([]interface{})
+		priorTopologies, _ := priorM["topology"].([]interface{})
+		forEachPrior(topologies, priorTopologies, "id", fn)
+	})
+}
+
+// firstConfig returns the "config" block of a flattened resource, or nil when
+// the resource has none.
+func firstConfig(raw interface{}) map[string]interface{} {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
 		return nil
 	}
 
-	result := make(map[string]interface{}, len(tags))
-	for _, tag := range tags {
-		result[*tag.Key] = *tag.Value
+	cfgs, ok := m["config"].([]interface{})
+	if !ok || len(cfgs) == 0 {
+		return nil
 	}
 
-	return result
+	cfg, _ := cfgs[0].(map[string]interface{})
+	return cfg
 }
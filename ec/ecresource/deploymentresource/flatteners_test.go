@@ -20,10 +20,12 @@ package deploymentresource
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/go-openapi/strfmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 
@@ -37,9 +39,16 @@ func Test_modelToState(t *testing.T) {
 	deploymentLowerVersionSchemaArg := schema.TestResourceDataRaw(t, newSchema(), nil)
 	deploymentLowerVersionSchemaArg.SetId(mock.ValidClusterID)
 
+	// The sample observability destination is the deployment itself.
+	wantDeploymentState := newSampleLegacyDeployment()
+	wantDeploymentState["observability"].([]interface{})[0].(map[string]interface{})["self"] = true
+	wantDeploymentState["elasticsearch"].([]interface{})[0].(map[string]interface{})["topology"].([]interface{})[0].(map[string]interface{})["size_resource"] = "memory"
+	for _, kind := range []string{"elasticsearch", "kibana", "apm", "enterprise_search"} {
+		wantDeploymentState[kind].([]interface{})[0].(map[string]interface{})["kind"] = kind
+	}
 	wantDeployment := util.NewResourceData(t, util.ResDataParams{
 		ID:     mock.ValidClusterID,
-		State:  newSampleLegacyDeployment(),
+		State:  wantDeploymentState,
 		Schema: newSchema(),
 	})
 
@@ -50,6 +59,7 @@ func Test_modelToState(t *testing.T) {
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
 			"alias":                  "my-deployment",
+			"created_at":             "2020-10-13T10:34:03Z",
 			"deployment_template_id": "azure-io-optimized",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d",
@@ -59,8 +69,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
 				"region":                       "azure-eastus2",
+				"kind":                         "apm",
 				"resource_id":                  "1235d8c911b74dd6a03c2a7b37fd68ab",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://1235d8c911b74dd6a03c2a7b37fd68ab.apm.eastus2.azure.elastic-cloud.com:9200",
 				"https_endpoint":               "https://1235d8c911b74dd6a03c2a7b37fd68ab.apm.eastus2.azure.elastic-cloud.com:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -77,6 +87,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://1238f19957874af69306787dca662154.eastus2.azure.elastic-cloud.com:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "azure-eastus2",
+				"kind":           "elasticsearch",
 				"resource_id":    "1238f19957874af69306787dca662154",
 				"topology": []interface{}{map[string]interface{}{
 					"id":                        "hot_content",
@@ -94,8 +105,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "azure-eastus2",
+				"kind":                         "kibana",
 				"resource_id":                  "1235cd4a4c7f464bbcfd795f3638b769",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://1235cd4a4c7f464bbcfd795f3638b769.eastus2.azure.elastic-cloud.com:9200",
 				"https_endpoint":               "https://1235cd4a4c7f464bbcfd795f3638b769.eastus2.azure.elastic-cloud.com:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -116,6 +127,7 @@ func Test_modelToState(t *testing.T) {
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
 			"alias":                  "my-deployment",
+			"created_at":             "2020-10-14T05:02:24Z",
 			"deployment_template_id": "aws-io-optimized-v2",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d",
@@ -124,9 +136,10 @@ func Test_modelToState(t *testing.T) {
 			"apm": []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"secret_token":                 "yMpNQNOBVxZhlgFnBY",
 				"region":                       "aws-eu-central-1",
+				"kind":                         "apm",
 				"resource_id":                  "12328579b3bf40c8b58c1a0ed5a4bd8b",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12328579b3bf40c8b58c1a0ed5a4bd8b.apm.eu-central-1.aws.cloud.es.io:80",
 				"https_endpoint":               "https://12328579b3bf40c8b58c1a0ed5a4bd8b.apm.eu-central-1.aws.cloud.es.io:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -143,6 +156,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://1239f7ee7196439ba2d105319ac5eba7.eu-central-1.aws.cloud.es.io:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "aws-eu-central-1",
+				"kind":           "elasticsearch",
 				"resource_id":    "1239f7ee7196439ba2d105319ac5eba7",
 				"topology": []interface{}{map[string]interface{}{
 					"id":                        "hot_content",
@@ -160,8 +174,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "aws-eu-central-1",
+				"kind":                         "kibana",
 				"resource_id":                  "123dcfda06254ca789eb287e8b73ff4c",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://123dcfda06254ca789eb287e8b73ff4c.eu-central-1.aws.cloud.es.io:9200",
 				"https_endpoint":               "https://123dcfda06254ca789eb287e8b73ff4c.eu-central-1.aws.cloud.es.io:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -185,10 +199,12 @@ func Test_modelToState(t *testing.T) {
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
 			"alias":                  "my-deployment",
+			"created_at":             "2020-10-14T05:02:24Z",
 			"deployment_template_id": "aws-io-optimized-v2",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d",
 			"region":                 "aws-eu-central-1",
+			"system_tags":            map[string]interface{}{},
 			"tags": map[string]interface{}{
 				"aaa":   "bbb",
 				"cost":  "rnd",
@@ -198,9 +214,10 @@ func Test_modelToState(t *testing.T) {
 			"apm": []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"secret_token":                 "yMpNQNOBVxZhlgFnBY",
 				"region":                       "aws-eu-central-1",
+				"kind":                         "apm",
 				"resource_id":                  "12328579b3bf40c8b58c1a0ed5a4bd8b",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12328579b3bf40c8b58c1a0ed5a4bd8b.apm.eu-central-1.aws.cloud.es.io:80",
 				"https_endpoint":               "https://12328579b3bf40c8b58c1a0ed5a4bd8b.apm.eu-central-1.aws.cloud.es.io:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -217,6 +234,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://1239f7ee7196439ba2d105319ac5eba7.eu-central-1.aws.cloud.es.io:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "aws-eu-central-1",
+				"kind":           "elasticsearch",
 				"resource_id":    "1239f7ee7196439ba2d105319ac5eba7",
 				"topology": []interface{}{map[string]interface{}{
 					"id":                        "hot_content",
@@ -234,8 +252,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "aws-eu-central-1",
+				"kind":                         "kibana",
 				"resource_id":                  "123dcfda06254ca789eb287e8b73ff4c",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://123dcfda06254ca789eb287e8b73ff4c.eu-central-1.aws.cloud.es.io:9200",
 				"https_endpoint":               "https://123dcfda06254ca789eb287e8b73ff4c.eu-central-1.aws.cloud.es.io:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -248,6 +266,10 @@ func Test_modelToState(t *testing.T) {
 		},
 		Schema: newSchema(),
 	})
+	// The read always sets the system tags, even when there are none.
+	if err := wantAwsIOOptimizedDeploymentTags.Set("system_tags", nil); err != nil {
+		t.Fatal(err)
+	}
 
 	gcpIOOptimizedRes := openDeploymentGet(t, "testdata/deployment-gcp-io-optimized.json")
 	gcpIOOptimizedRD := schema.TestResourceDataRaw(t, newSchema(), nil)
@@ -256,6 +278,7 @@ func Test_modelToState(t *testing.T) {
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
 			"alias":                  "my-deployment",
+			"created_at":             "2020-10-14T05:31:22Z",
 			"deployment_template_id": "gcp-io-optimized",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d",
@@ -264,9 +287,10 @@ func Test_modelToState(t *testing.T) {
 			"apm": []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"secret_token":                 "7g6LZFbwU6aCCVoLjw",
 				"region":                       "gcp-asia-east1",
+				"kind":                         "apm",
 				"resource_id":                  "12307c6c304949b8a9f3682b80900879",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12307c6c304949b8a9f3682b80900879.apm.asia-east1.gcp.elastic-cloud.com:80",
 				"https_endpoint":               "https://12307c6c304949b8a9f3682b80900879.apm.asia-east1.gcp.elastic-cloud.com:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -283,6 +307,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://123695e76d914005bf90b717e668ad4b.asia-east1.gcp.elastic-cloud.com:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "gcp-asia-east1",
+				"kind":           "elasticsearch",
 				"resource_id":    "123695e76d914005bf90b717e668ad4b",
 				"topology": []interface{}{map[string]interface{}{
 					"id":                        "hot_content",
@@ -300,8 +325,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "gcp-asia-east1",
+				"kind":                         "kibana",
 				"resource_id":                  "12365046781e4d729a07df64fe67c8c6",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12365046781e4d729a07df64fe67c8c6.asia-east1.gcp.elastic-cloud.com:9200",
 				"https_endpoint":               "https://12365046781e4d729a07df64fe67c8c6.asia-east1.gcp.elastic-cloud.com:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -325,6 +350,7 @@ func Test_modelToState(t *testing.T) {
 	wantGcpHotWarmDeployment := util.NewResourceData(t, util.ResDataParams{
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
+			"created_at":             "2020-10-14T05:43:55Z",
 			"deployment_template_id": "gcp-hot-warm",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d-hot-warm",
@@ -333,9 +359,10 @@ func Test_modelToState(t *testing.T) {
 			"apm": []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"secret_token":                 "al0DOoO2S8MKswdJ7W",
 				"region":                       "gcp-us-central1",
+				"kind":                         "apm",
 				"resource_id":                  "1234b68b0b9347f1b49b1e01b33bf4a4",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://1234b68b0b9347f1b49b1e01b33bf4a4.apm.us-central1.gcp.cloud.es.io:80",
 				"https_endpoint":               "https://1234b68b0b9347f1b49b1e01b33bf4a4.apm.us-central1.gcp.cloud.es.io:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -352,6 +379,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://123e837db6ee4391bb74887be35a7a91.us-central1.gcp.cloud.es.io:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "gcp-us-central1",
+				"kind":           "elasticsearch",
 				"resource_id":    "123e837db6ee4391bb74887be35a7a91",
 				"topology": []interface{}{
 					map[string]interface{}{
@@ -382,8 +410,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "gcp-us-central1",
+				"kind":                         "kibana",
 				"resource_id":                  "12372cc60d284e7e96b95ad14727c23d",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12372cc60d284e7e96b95ad14727c23d.us-central1.gcp.cloud.es.io:9200",
 				"https_endpoint":               "https://12372cc60d284e7e96b95ad14727c23d.us-central1.gcp.cloud.es.io:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -402,6 +430,7 @@ func Test_modelToState(t *testing.T) {
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
 			"alias":                  "",
+			"created_at":             "2020-10-14T05:31:22Z",
 			"deployment_template_id": "gcp-io-optimized",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d",
@@ -410,9 +439,10 @@ func Test_modelToState(t *testing.T) {
 			"apm": []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"secret_token":                 "7g6LZFbwU6aCCVoLjw",
 				"region":                       "gcp-asia-east1",
+				"kind":                         "apm",
 				"resource_id":                  "12307c6c304949b8a9f3682b80900879",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12307c6c304949b8a9f3682b80900879.apm.asia-east1.gcp.elastic-cloud.com:80",
 				"https_endpoint":               "https://12307c6c304949b8a9f3682b80900879.apm.asia-east1.gcp.elastic-cloud.com:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -429,6 +459,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://123695e76d914005bf90b717e668ad4b.asia-east1.gcp.elastic-cloud.com:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "gcp-asia-east1",
+				"kind":           "elasticsearch",
 				"resource_id":    "123695e76d914005bf90b717e668ad4b",
 				"topology": []interface{}{
 					map[string]interface{}{
@@ -471,8 +502,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "gcp-asia-east1",
+				"kind":                         "kibana",
 				"resource_id":                  "12365046781e4d729a07df64fe67c8c6",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12365046781e4d729a07df64fe67c8c6.asia-east1.gcp.elastic-cloud.com:9200",
 				"https_endpoint":               "https://12365046781e4d729a07df64fe67c8c6.asia-east1.gcp.elastic-cloud.com:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -493,6 +524,7 @@ func Test_modelToState(t *testing.T) {
 	wantGcpHotWarmNodeRolesDeployment := util.NewResourceData(t, util.ResDataParams{
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
+			"created_at":             "2020-10-14T05:43:55Z",
 			"deployment_template_id": "gcp-hot-warm",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "up2d-hot-warm",
@@ -501,9 +533,10 @@ func Test_modelToState(t *testing.T) {
 			"apm": []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-apm",
+				"secret_token":                 "al0DOoO2S8MKswdJ7W",
 				"region":                       "gcp-us-central1",
+				"kind":                         "apm",
 				"resource_id":                  "1234b68b0b9347f1b49b1e01b33bf4a4",
-				"version":                      "7.11.0",
 				"http_endpoint":                "http://1234b68b0b9347f1b49b1e01b33bf4a4.apm.us-central1.gcp.cloud.es.io:80",
 				"https_endpoint":               "https://1234b68b0b9347f1b49b1e01b33bf4a4.apm.us-central1.gcp.cloud.es.io:443",
 				"topology": []interface{}{map[string]interface{}{
@@ -520,6 +553,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://123e837db6ee4391bb74887be35a7a91.us-central1.gcp.cloud.es.io:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "gcp-us-central1",
+				"kind":           "elasticsearch",
 				"resource_id":    "123e837db6ee4391bb74887be35a7a91",
 				"topology": []interface{}{
 					map[string]interface{}{
@@ -554,8 +588,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "gcp-us-central1",
+				"kind":                         "kibana",
 				"resource_id":                  "12372cc60d284e7e96b95ad14727c23d",
-				"version":                      "7.11.0",
 				"http_endpoint":                "http://12372cc60d284e7e96b95ad14727c23d.us-central1.gcp.cloud.es.io:9200",
 				"https_endpoint":               "https://12372cc60d284e7e96b95ad14727c23d.us-central1.gcp.cloud.es.io:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -576,6 +610,7 @@ func Test_modelToState(t *testing.T) {
 	wantAWSCCSDeployment := util.NewResourceData(t, util.ResDataParams{
 		ID: mock.ValidClusterID,
 		State: map[string]interface{}{
+			"created_at":             "2020-10-14T06:37:45Z",
 			"deployment_template_id": "aws-cross-cluster-search-v2",
 			"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 			"name":                   "ccs",
@@ -588,6 +623,7 @@ func Test_modelToState(t *testing.T) {
 				"https_endpoint": "https://1230b3ae633b4f51a432d50971f7f1c1.eu-west-1.aws.found.io:9243",
 				"ref_id":         "main-elasticsearch",
 				"region":         "eu-west-1",
+				"kind":           "elasticsearch",
 				"resource_id":    "1230b3ae633b4f51a432d50971f7f1c1",
 				"remote_cluster": []interface{}{
 					map[string]interface{}{
@@ -617,8 +653,8 @@ func Test_modelToState(t *testing.T) {
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-kibana",
 				"region":                       "eu-west-1",
+				"kind":                         "kibana",
 				"resource_id":                  "12317425e9e14491b74ee043db3402eb",
-				"version":                      "7.9.2",
 				"http_endpoint":                "http://12317425e9e14491b74ee043db3402eb.eu-west-1.aws.found.io:9200",
 				"https_endpoint":               "https://12317425e9e14491b74ee043db3402eb.eu-west-1.aws.found.io:9243",
 				"topology": []interface{}{map[string]interface{}{
@@ -965,6 +1001,7 @@ func Test_modelToState(t *testing.T) {
 					"version":                "7.6.2",
 					"elasticsearch": []interface{}{map[string]interface{}{
 						"ref_id":      "main-elasticsearch",
+						"kind":        "elasticsearch",
 						"resource_id": mock.ValidClusterID,
 						"region":      "us-east-1",
 						"config": []interface{}{map[string]interface{}{
@@ -977,6 +1014,7 @@ func Test_modelToState(t *testing.T) {
 							"id":                        "hot_content",
 							"instance_configuration_id": "aws.data.highio.i3",
 							"size":                      "2g",
+							"size_resource":             "memory",
 							"node_type_data":            "true",
 							"node_type_ingest":          "true",
 							"node_type_master":          "true",
@@ -987,8 +1025,8 @@ func Test_modelToState(t *testing.T) {
 					"kibana": []interface{}{map[string]interface{}{
 						"elasticsearch_cluster_ref_id": "main-elasticsearch",
 						"ref_id":                       "main-kibana",
+						"kind":                         "kibana",
 						"resource_id":                  mock.ValidClusterID,
-						"version":                      "7.7.0",
 						"region":                       "us-east-1",
 						"topology": []interface{}{
 							map[string]interface{}{
@@ -1022,6 +1060,7 @@ func Test_modelToState(t *testing.T) {
 				ID: mock.ValidClusterID,
 				State: map[string]interface{}{
 					"alias":                  "my-deployment",
+					"created_at":             "2020-10-14T05:02:24Z",
 					"deployment_template_id": "aws-io-optimized-v2",
 					"id":                     "123b7b540dfc967a7a649c18e2fce4ed",
 					"name":                   "up2d",
@@ -1030,9 +1069,10 @@ func Test_modelToState(t *testing.T) {
 					"apm": []interface{}{map[string]interface{}{
 						"elasticsearch_cluster_ref_id": "main-elasticsearch",
 						"ref_id":                       "main-apm",
+						"secret_token":                 "yMpNQNOBVxZhlgFnBY",
 						"region":                       "aws-eu-central-1",
+						"kind":                         "apm",
 						"resource_id":                  "12328579b3bf40c8b58c1a0ed5a4bd8b",
-						"version":                      "7.9.2",
 						"http_endpoint":                "http://12328579b3bf40c8b58c1a0ed5a4bd8b.apm.eu-central-1.aws.cloud.es.io:80",
 						"https_endpoint":               "https://12328579b3bf40c8b58c1a0ed5a4bd8b.apm.eu-central-1.aws.cloud.es.io:443",
 						"topology": []interface{}{map[string]interface{}{
@@ -1075,6 +1115,7 @@ func Test_modelToState(t *testing.T) {
 						"https_endpoint": "https://1239f7ee7196439ba2d105319ac5eba7.eu-central-1.aws.cloud.es.io:9243",
 						"ref_id":         "main-elasticsearch",
 						"region":         "aws-eu-central-1",
+						"kind":           "elasticsearch",
 						"resource_id":    "1239f7ee7196439ba2d105319ac5eba7",
 						"topology": []interface{}{map[string]interface{}{
 							"id":                        "hot_content",
@@ -1092,8 +1133,8 @@ func Test_modelToState(t *testing.T) {
 						"elasticsearch_cluster_ref_id": "main-elasticsearch",
 						"ref_id":                       "main-kibana",
 						"region":                       "aws-eu-central-1",
+						"kind":                         "kibana",
 						"resource_id":                  "123dcfda06254ca789eb287e8b73ff4c",
-						"version":                      "7.9.2",
 						"http_endpoint":                "http://123dcfda06254ca789eb287e8b73ff4c.eu-central-1.aws.cloud.es.io:9200",
 						"https_endpoint":               "https://123dcfda06254ca789eb287e8b73ff4c.eu-central-1.aws.cloud.es.io:9243",
 						"topology": []interface{}{map[string]interface{}{
@@ -1185,6 +1226,7 @@ func Test_modelToState(t *testing.T) {
 					"region":                 "aws-eu-central-1",
 					"version":                "7.13.1",
 					"elasticsearch": []interface{}{map[string]interface{}{
+						"kind":   "elasticsearch",
 						"region": "aws-eu-central-1",
 						"ref_id": "main-elasticsearch",
 						"topology": []interface{}{map[string]interface{}{
@@ -1275,6 +1317,7 @@ func Test_modelToState(t *testing.T) {
 					"region":                 "aws-eu-central-1",
 					"version":                "7.13.1",
 					"elasticsearch": []interface{}{map[string]interface{}{
+						"kind":   "elasticsearch",
 						"region": "aws-eu-central-1",
 						"ref_id": "main-elasticsearch",
 						"topology": []interface{}{map[string]interface{}{
@@ -1424,6 +1467,7 @@ func Test_modelToState(t *testing.T) {
 					"region":                 "aws-eu-central-1",
 					"version":                "7.14.1",
 					"elasticsearch": []interface{}{map[string]interface{}{
+						"kind":   "elasticsearch",
 						"region": "aws-eu-central-1",
 						"ref_id": "main-elasticsearch",
 						"config": []interface{}{map[string]interface{}{
@@ -1440,6 +1484,7 @@ func Test_modelToState(t *testing.T) {
 						}},
 					}},
 					"kibana": []interface{}{map[string]interface{}{
+						"kind":   "kibana",
 						"region": "aws-eu-central-1",
 						"ref_id": "main-kibana",
 						"config": []interface{}{map[string]interface{}{
@@ -1453,6 +1498,7 @@ func Test_modelToState(t *testing.T) {
 						}},
 					}},
 					"apm": []interface{}{map[string]interface{}{
+						"kind":   "apm",
 						"region": "aws-eu-central-1",
 						"ref_id": "main-apm",
 						"config": []interface{}{map[string]interface{}{
@@ -1466,6 +1512,7 @@ func Test_modelToState(t *testing.T) {
 						}},
 					}},
 					"enterprise_search": []interface{}{map[string]interface{}{
+						"kind":   "enterprise_search",
 						"region": "aws-eu-central-1",
 						"ref_id": "main-enterprise_search",
 						"config": []interface{}{map[string]interface{}{
@@ -1535,6 +1582,187 @@ func Test_modelToState(t *testing.T) {
 	}
 }
 
+func Test_modelToStateAutoscale(t *testing.T) {
+	newRes := func(enabled bool) *models.DeploymentGetResponse {
+		return &models.DeploymentGetResponse{
+			Name: ec.String("my_deployment_name"),
+			Resources: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{{
+					RefID:  ec.String("main-elasticsearch"),
+					Region: ec.String("us-east-1"),
+					Info: &models.ElasticsearchClusterInfo{
+						ClusterID: ec.String(mock.ValidClusterID),
+						Status:    ec.String("started"),
+						PlanInfo: &models.ElasticsearchClusterPlansInfo{
+							Current: &models.ElasticsearchClusterPlanInfo{
+								Plan: &models.ElasticsearchClusterPlan{
+									AutoscalingEnabled: ec.Bool(enabled),
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										Version: "7.10.1",
+									},
+									DeploymentTemplate: &models.DeploymentTemplateReference{
+										ID: ec.String("aws-io-optimized-v2"),
+									},
+								},
+							},
+						},
+					},
+				}},
+			},
+		}
+	}
+	newRD := func(autoscale interface{}) *schema.ResourceData {
+		state := map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.10.1",
+			"elasticsearch": []interface{}{map[string]interface{}{
+				"kind":      "elasticsearch",
+				"autoscale": "false",
+			}},
+		}
+		if autoscale != nil {
+			state["autoscale"] = autoscale
+		}
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			State:  state,
+			Schema: newSchema(),
+		})
+	}
+	tests := []struct {
+		name          string
+		d             *schema.ResourceData
+		res           *models.DeploymentGetResponse
+		wantAutoscale interface{}
+		wantEs        string
+	}{
+		{
+			name:          "shows autoscaling enabled outside of terraform as a drift",
+			d:             newRD(false),
+			res:           newRes(true),
+			wantAutoscale: true,
+			wantEs:        "true",
+		},
+		{
+			name:          "shows autoscaling disabled outside of terraform as a drift",
+			d:             newRD(true),
+			res:           newRes(false),
+			wantAutoscale: false,
+			wantEs:        "false",
+		},
+		{
+			name:   "doesn't set the deployment autoscale when it isn't set",
+			d:      newRD(nil),
+			res:    newRes(true),
+			wantEs: "true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, modelToState(tt.d, tt.res, models.RemoteResources{}))
+
+			autoscale, ok := tt.d.GetOkExists("autoscale") //nolint:staticcheck
+			if tt.wantAutoscale != nil {
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantAutoscale, autoscale)
+			} else {
+				assert.False(t, ok)
+			}
+			assert.Equal(t, tt.wantEs, tt.d.Get("elasticsearch.0.autoscale"))
+		})
+	}
+}
+
+func Test_modelToStateDockerImage(t *testing.T) {
+	const (
+		configured = "docker.elastic.co/cloud-ci/elasticsearch:7.15.0-SNAPSHOT-abc123"
+		rotated    = "docker.elastic.co/cloud-ci/elasticsearch:7.15.0-SNAPSHOT-def456"
+	)
+	newRes := func(image string) *models.DeploymentGetResponse {
+		return &models.DeploymentGetResponse{
+			Name: ec.String("my_deployment_name"),
+			Resources: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{{
+					RefID:  ec.String("main-elasticsearch"),
+					Region: ec.String("us-east-1"),
+					Info: &models.ElasticsearchClusterInfo{
+						ClusterID: ec.String(mock.ValidClusterID),
+						Status:    ec.String("started"),
+						PlanInfo: &models.ElasticsearchClusterPlansInfo{
+							Current: &models.ElasticsearchClusterPlanInfo{
+								Plan: &models.ElasticsearchClusterPlan{
+									Elasticsearch: &models.ElasticsearchConfiguration{
+										Version:     "7.15.0",
+										DockerImage: image,
+									},
+									DeploymentTemplate: &models.DeploymentTemplateReference{
+										ID: ec.String("aws-io-optimized-v2"),
+									},
+								},
+							},
+						},
+					},
+				}},
+			},
+		}
+	}
+	newRD := func(image string) *schema.ResourceData {
+		es := map[string]interface{}{}
+		if image != "" {
+			es["config"] = []interface{}{map[string]interface{}{
+				"docker_image": image,
+			}}
+		}
+		return util.NewResourceData(t, util.ResDataParams{
+			ID: mock.ValidClusterID,
+			State: map[string]interface{}{
+				"deployment_template_id": "aws-io-optimized-v2",
+				"region":                 "us-east-1",
+				"version":                "7.15.0",
+				"elasticsearch":          []interface{}{es},
+			},
+			Schema: newSchema(),
+		})
+	}
+	tests := []struct {
+		name string
+		d    *schema.ResourceData
+		res  *models.DeploymentGetResponse
+		want string
+	}{
+		{
+			name: "reads the running docker image",
+			d:    newRD(configured),
+			res:  newRes(configured),
+			want: configured,
+		},
+		{
+			name: "shows a docker image rotated outside of terraform as a drift",
+			d:    newRD(configured),
+			res:  newRes(rotated),
+			want: rotated,
+		},
+		{
+			name: "reads a docker image set outside of terraform",
+			d:    newRD(""),
+			res:  newRes(rotated),
+			want: rotated,
+		},
+		{
+			name: "reads a docker image unset outside of terraform",
+			d:    newRD(configured),
+			res:  newRes(""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, modelToState(tt.d, tt.res, models.RemoteResources{}))
+			assert.Equal(t, tt.want, tt.d.State().Attributes["elasticsearch.0.config.0.docker_image"])
+		})
+	}
+}
+
 func Test_getDeploymentTemplateID(t *testing.T) {
 	type args struct {
 		res *models.DeploymentResources
@@ -1659,6 +1887,22 @@ func Test_parseCredentials(t *testing.T) {
 		Schema: newSchema(),
 	})
 
+	apmDeploymentRD := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		State:  newSampleLegacyDeployment(),
+		Schema: newSchema(),
+	})
+
+	apmRawData := newSampleLegacyDeployment()
+	apmRawData["apm_secret_token"] = "some-secret-token"
+	apmRawData["apm"].([]interface{})[0].(map[string]interface{})["secret_token"] = "some-secret-token"
+
+	wantApmDeploymentRD := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		State:  apmRawData,
+		Schema: newSchema(),
+	})
+
 	type args struct {
 		d         *schema.ResourceData
 		resources []*models.DeploymentResource
@@ -1683,6 +1927,18 @@ func Test_parseCredentials(t *testing.T) {
 			},
 			want: wantDeploymentRD,
 		},
+		{
+			name: "Parses the apm secret token into the apm resource",
+			args: args{
+				d: apmDeploymentRD,
+				resources: []*models.DeploymentResource{{
+					Kind:        ec.String("apm"),
+					RefID:       ec.String("main-apm"),
+					SecretToken: "some-secret-token",
+				}},
+			},
+			want: wantApmDeploymentRD,
+		},
 		{
 			name: "when no credentials are passed, it doesn't overwrite them",
 			args: args{
@@ -1712,6 +1968,55 @@ func Test_parseCredentials(t *testing.T) {
 	}
 }
 
+func Test_setElasticsearchCredentials(t *testing.T) {
+	newRD := func() *schema.ResourceData {
+		return util.NewResourceData(t, util.ResDataParams{
+			ID: mock.ValidClusterID,
+			State: map[string]interface{}{
+				"elasticsearch_username": "elastic",
+				"elasticsearch_password": "old-password",
+			},
+			Schema: newSchema(),
+		})
+	}
+	tests := []struct {
+		name         string
+		username     *string
+		password     *string
+		wantUsername string
+		wantPassword string
+	}{
+		{
+			name:         "sets the credentials",
+			username:     ec.String("admin"),
+			password:     ec.String("new-password"),
+			wantUsername: "admin",
+			wantPassword: "new-password",
+		},
+		{
+			name:         "only rotates the password",
+			password:     ec.String("new-password"),
+			wantUsername: "elastic",
+			wantPassword: "new-password",
+		},
+		{
+			name:         "leaves the credentials untouched when these are empty",
+			username:     ec.String(""),
+			password:     ec.String(""),
+			wantUsername: "elastic",
+			wantPassword: "old-password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newRD()
+			assert.NoError(t, setElasticsearchCredentials(d, tt.username, tt.password))
+			assert.Equal(t, tt.wantUsername, d.Get("elasticsearch_username"))
+			assert.Equal(t, tt.wantPassword, d.Get("elasticsearch_password"))
+		})
+	}
+}
+
 func Test_hasRunningResources(t *testing.T) {
 	type args struct {
 		res *models.DeploymentGetResponse
@@ -1784,3 +2089,417 @@ func Test_hasRunningResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_flattenCreatedAt(t *testing.T) {
+	created := strfmt.DateTime(time.Date(2020, 10, 13, 10, 34, 3, 0, time.UTC))
+	updated := strfmt.DateTime(time.Date(2021, 2, 1, 8, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name      string
+		resources []*models.ElasticsearchResourceInfo
+		want      string
+	}{
+		{
+			name: "returns an empty string when there's no resources",
+		},
+		{
+			name: "returns an empty string when there's no plan attempts",
+			resources: []*models.ElasticsearchResourceInfo{{
+				Info: &models.ElasticsearchClusterInfo{PlanInfo: &models.ElasticsearchClusterPlansInfo{
+					Current: &models.ElasticsearchClusterPlanInfo{},
+				}},
+			}},
+		},
+		{
+			name: "returns the current plan attempt start time",
+			resources: []*models.ElasticsearchResourceInfo{{
+				Info: &models.ElasticsearchClusterInfo{PlanInfo: &models.ElasticsearchClusterPlansInfo{
+					Current: &models.ElasticsearchClusterPlanInfo{AttemptStartTime: created},
+				}},
+			}},
+			want: "2020-10-13T10:34:03Z",
+		},
+		{
+			name: "returns the earliest plan attempt start time from the plan history",
+			resources: []*models.ElasticsearchResourceInfo{{
+				Info: &models.ElasticsearchClusterInfo{PlanInfo: &models.ElasticsearchClusterPlansInfo{
+					Current: &models.ElasticsearchClusterPlanInfo{AttemptStartTime: updated},
+					History: []*models.ElasticsearchClusterPlanInfo{
+						{AttemptStartTime: created},
+						{AttemptStartTime: updated},
+					},
+				}},
+			}},
+			want: "2020-10-13T10:34:03Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flattenCreatedAt(tt.resources))
+		})
+	}
+}
+
+func Test_flattenLastModified(t *testing.T) {
+	modified := strfmt.DateTime(time.Date(2020, 10, 13, 10, 34, 3, 0, time.UTC))
+	planModified := strfmt.DateTime(time.Date(2021, 2, 1, 8, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name     string
+		metadata *models.DeploymentMetadata
+		want     string
+	}{
+		{
+			name:     "returns an empty string when there's no timestamps",
+			metadata: &models.DeploymentMetadata{},
+		},
+		{
+			name:     "returns the metadata last modified time",
+			metadata: &models.DeploymentMetadata{LastModified: &modified},
+			want:     "2020-10-13T10:34:03Z",
+		},
+		{
+			name: "returns the resource plan last modified time when it's more recent",
+			metadata: &models.DeploymentMetadata{
+				LastModified:             &modified,
+				LastResourcePlanModified: planModified,
+			},
+			want: "2021-02-01T08:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flattenLastModified(tt.metadata))
+		})
+	}
+}
+
+func Test_flattenTags(t *testing.T) {
+	isMarketplace := func(key string) bool { return key == "marketplace" }
+	tests := []struct {
+		name       string
+		tags       []*models.MetadataItem
+		isSystem   func(string) bool
+		want       map[string]interface{}
+		wantSystem map[string]interface{}
+	}{
+		{
+			name: "returns nil when there's no tags",
+		},
+		{
+			name: "flattens the user tags",
+			tags: []*models.MetadataItem{
+				{Key: ec.String("cost"), Value: ec.String("rnd")},
+				{Key: ec.String("owner"), Value: ec.String("elastic")},
+			},
+			want: map[string]interface{}{"cost": "rnd", "owner": "elastic"},
+		},
+		{
+			name: "flattens the user tags regardless of their order",
+			tags: []*models.MetadataItem{
+				{Key: ec.String("team"), Value: ec.String("cloud")},
+				{Key: ec.String("owner"), Value: ec.String("elastic")},
+				{Key: ec.String("cost"), Value: ec.String("rnd")},
+			},
+			want: map[string]interface{}{"cost": "rnd", "owner": "elastic", "team": "cloud"},
+		},
+		{
+			name: "skips incomplete tags",
+			tags: []*models.MetadataItem{
+				{Key: ec.String("cost"), Value: ec.String("rnd")},
+				{Key: ec.String("owner")},
+				nil,
+			},
+			want: map[string]interface{}{"cost": "rnd"},
+		},
+		{
+			name: "keeps the system tags apart",
+			tags: []*models.MetadataItem{
+				{Key: ec.String("cost"), Value: ec.String("rnd")},
+				{Key: ec.String("marketplace"), Value: ec.String("aws")},
+				{Key: ec.String("owner"), Value: ec.String("elastic")},
+			},
+			isSystem:   isMarketplace,
+			want:       map[string]interface{}{"cost": "rnd", "owner": "elastic"},
+			wantSystem: map[string]interface{}{"marketplace": "aws"},
+		},
+		{
+			name: "returns nil user tags when there's only system tags",
+			tags: []*models.MetadataItem{
+				{Key: ec.String("marketplace"), Value: ec.String("aws")},
+			},
+			isSystem:   isMarketplace,
+			wantSystem: map[string]interface{}{"marketplace": "aws"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotSystem := flattenTags(tt.tags, tt.isSystem)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantSystem, gotSystem)
+		})
+	}
+}
+
+func Test_modelToStateSystemTags(t *testing.T) {
+	res := openDeploymentGet(t, "testdata/deployment-aws-io-optimized-system-tags.json")
+	configured := map[string]interface{}{"aaa": "bbb", "cost": "rnd", "owner": "elastic"}
+	tests := []struct {
+		name       string
+		created    bool
+		system     map[string]interface{}
+		want       map[string]interface{}
+		wantSystem map[string]interface{}
+	}{
+		{
+			name:       "keeps the tags injected on creation as system tags",
+			created:    true,
+			want:       configured,
+			wantSystem: map[string]interface{}{"marketplace": "aws"},
+		},
+		{
+			name:       "keeps the known system tags apart",
+			system:     map[string]interface{}{"marketplace": "aws"},
+			want:       configured,
+			wantSystem: map[string]interface{}{"marketplace": "aws"},
+		},
+		{
+			name: "shows the tags added outside of terraform",
+			want: map[string]interface{}{"aaa": "bbb", "cost": "rnd", "marketplace": "aws", "owner": "elastic"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State:  map[string]interface{}{"tags": configured},
+			})
+			if tt.created {
+				d.MarkNewResource()
+			}
+			if tt.system != nil {
+				assert.NoError(t, d.Set("system_tags", tt.system))
+			}
+
+			assert.NoError(t, modelToState(d, res, models.RemoteResources{}))
+			assert.Equal(t, tt.want, d.Get("tags"))
+			if tt.wantSystem == nil {
+				tt.wantSystem = map[string]interface{}{}
+			}
+			assert.Equal(t, tt.wantSystem, d.Get("system_tags"))
+		})
+	}
+}
+
+func Test_setEquivalentUserSettings(t *testing.T) {
+	newResource := func(yml string) []interface{} {
+		return []interface{}{map[string]interface{}{
+			"ref_id": "main-kibana",
+			"config": []interface{}{map[string]interface{}{
+				"user_settings_yaml": yml,
+			}},
+		}}
+	}
+	tests := []struct {
+		name      string
+		flattened []interface{}
+		prior     []interface{}
+		want      []interface{}
+	}{
+		{
+			name:      "keeps the prior quoted duration",
+			flattened: newResource("xpack.monitoring.collection.interval: 30s"),
+			prior:     newResource(`xpack.monitoring.collection.interval: "30s"`),
+			want:      newResource(`xpack.monitoring.collection.interval: "30s"`),
+		},
+		{
+			name:      "keeps the prior unquoted byte size",
+			flattened: newResource(`indices.memory.index_buffer_size: "512mb"`),
+			prior:     newResource("indices.memory.index_buffer_size: 512mb"),
+			want:      newResource("indices.memory.index_buffer_size: 512mb"),
+		},
+		{
+			name:      "keeps the settings read from the API when they differ",
+			flattened: newResource("xpack.monitoring.collection.interval: 10s"),
+			prior:     newResource(`xpack.monitoring.collection.interval: "30s"`),
+			want:      newResource("xpack.monitoring.collection.interval: 10s"),
+		},
+		{
+			name:      "keeps the settings read from the API without prior state",
+			flattened: newResource("xpack.monitoring.collection.interval: 30s"),
+			want:      newResource("xpack.monitoring.collection.interval: 30s"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEquivalentUserSettings(tt.flattened, tt.prior)
+			assert.Equal(t, tt.want, tt.flattened)
+		})
+	}
+}
+
+func Test_forEachPrior(t *testing.T) {
+	tests := []struct {
+		name      string
+		flattened []interface{}
+		prior     []interface{}
+		want      map[string]interface{}
+	}{
+		{
+			name: "matches the elements by key regardless of their position",
+			flattened: []interface{}{
+				map[string]interface{}{"id": "hot_content"},
+				map[string]interface{}{"id": "warm"},
+			},
+			prior: []interface{}{
+				map[string]interface{}{"id": "warm", "size": "4g"},
+				map[string]interface{}{"id": "hot_content", "size": "8g"},
+			},
+			want: map[string]interface{}{"hot_content": "8g", "warm": "4g"},
+		},
+		{
+			name: "calls fn with nil for the elements without a prior one",
+			flattened: []interface{}{
+				map[string]interface{}{"id": "hot_content"},
+				map[string]interface{}{"id": "cold"},
+			},
+			prior: []interface{}{
+				map[string]interface{}{"id": "warm", "size": "4g"},
+				map[string]interface{}{"id": "hot_content", "size": "8g"},
+			},
+			want: map[string]interface{}{"hot_content": "8g", "cold": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]interface{})
+			forEachPrior(tt.flattened, tt.prior, "id", func(m, priorM map[string]interface{}) {
+				got[m["id"].(string)] = priorM["size"]
+			})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_flattenResourceIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		res  *models.DeploymentResources
+		want map[string]interface{}
+	}{
+		{
+			name: "returns an empty map without resources",
+			want: map[string]interface{}{},
+		},
+		{
+			name: "returns the resource ids keyed by ref_id",
+			res: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{
+					{RefID: ec.String("main-elasticsearch"), ID: ec.String("1239f7ee7196439ba2d105319ac5eba7")},
+					{RefID: ec.String("secondary-elasticsearch"), ID: ec.String("2229f7ee7196439ba2d105319ac5eba7")},
+				},
+				Kibana: []*models.KibanaResourceInfo{
+					{RefID: ec.String("main-kibana"), ID: ec.String("3339f7ee7196439ba2d105319ac5eba7")},
+				},
+				Apm: []*models.ApmResourceInfo{
+					{RefID: ec.String("main-apm"), ID: ec.String("4449f7ee7196439ba2d105319ac5eba7")},
+				},
+				IntegrationsServer: []*models.IntegrationsServerResourceInfo{
+					{RefID: ec.String("main-integrations_server"), ID: ec.String("5559f7ee7196439ba2d105319ac5eba7")},
+				},
+				EnterpriseSearch: []*models.EnterpriseSearchResourceInfo{
+					{RefID: ec.String("main-enterprise_search"), ID: ec.String("6669f7ee7196439ba2d105319ac5eba7")},
+					{RefID: ec.String("no-id-enterprise_search")},
+				},
+			},
+			want: map[string]interface{}{
+				"main-elasticsearch":       "1239f7ee7196439ba2d105319ac5eba7",
+				"secondary-elasticsearch":  "2229f7ee7196439ba2d105319ac5eba7",
+				"main-kibana":              "3339f7ee7196439ba2d105319ac5eba7",
+				"main-apm":                 "4449f7ee7196439ba2d105319ac5eba7",
+				"main-integrations_server": "5559f7ee7196439ba2d105319ac5eba7",
+				"main-enterprise_search":   "6669f7ee7196439ba2d105319ac5eba7",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, flattenResourceIDs(tt.res))
+		})
+	}
+}
+
+func Test_clearInactiveApmKind(t *testing.T) {
+	apm := []interface{}{map[string]interface{}{
+		"ref_id":         "main-apm",
+		"https_endpoint": "https://apm.example.com:443",
+	}}
+	integrationsServer := []interface{}{map[string]interface{}{
+		"ref_id":         "main-integrations_server",
+		"https_endpoint": "https://integrations.example.com:443",
+	}}
+	tests := []struct {
+		name                   string
+		state                  map[string]interface{}
+		apm                    []interface{}
+		integrationsServer     []interface{}
+		wantApm                int
+		wantIntegrationsServer int
+	}{
+		{
+			name: "clears apm when only the integrations server exists",
+			state: map[string]interface{}{
+				"apm":                 apm,
+				"integrations_server": integrationsServer,
+			},
+			integrationsServer:     integrationsServer,
+			wantIntegrationsServer: 1,
+		},
+		{
+			name: "clears the integrations server when only apm exists",
+			state: map[string]interface{}{
+				"apm":                 apm,
+				"integrations_server": integrationsServer,
+			},
+			apm:     apm,
+			wantApm: 1,
+		},
+		{
+			name: "keeps both when both exist",
+			state: map[string]interface{}{
+				"apm":                 apm,
+				"integrations_server": integrationsServer,
+			},
+			apm:                    apm,
+			integrationsServer:     integrationsServer,
+			wantApm:                1,
+			wantIntegrationsServer: 1,
+		},
+		{
+			name: "keeps both when neither exists",
+			state: map[string]interface{}{
+				"apm":                 apm,
+				"integrations_server": integrationsServer,
+			},
+			wantApm:                1,
+			wantIntegrationsServer: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				State:  tt.state,
+				Schema: newSchema(),
+			})
+			assert.NoError(t, clearInactiveApmKind(d, tt.apm, tt.integrationsServer))
+			assert.Len(t, d.Get("apm"), tt.wantApm)
+			assert.Len(t, d.Get("integrations_server"), tt.wantIntegrationsServer)
+			if tt.wantApm == 0 {
+				assert.Empty(t, d.State().Attributes["apm.0.https_endpoint"])
+			}
+			if tt.wantIntegrationsServer == 0 {
+				assert.Empty(t, d.State().Attributes["integrations_server.0.https_endpoint"])
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// checkHardwareMigration returns a warning for each Elasticsearch topology
+// element of the prior state whose instance_configuration_id differs from the
+// deployment template default for the same tier, since the element is
+// migrated to the template instance configuration by the deployment update.
+// It's only called when the deployment is updated, so the deployment template
+// isn't obtained on every read. Failures to obtain the deployment template are
+// only logged, since the check is merely informational.
+func checkHardwareMigration(d *schema.ResourceData, client *api.API) diag.Diagnostics {
+	prior, _ := d.GetChange("elasticsearch")
+	es, _ := prior.([]interface{})
+	if len(es) == 0 || es[0] == nil {
+		return nil
+	}
+
+	tplID := d.Get("deployment_template_id").(string)
+	if tplID == "" {
+		return nil
+	}
+
+	template, err := getDeploymentTemplate(client, tplID, d.Get("region").(string))
+	if err != nil {
+		log.Printf("[DEBUG] skipping the hardware migration check: %s", err)
+		return nil
+	}
+
+	tplInstanceConfigurations := make(map[string]string)
+	if tpl := esResource(template); tpl.Plan != nil {
+		for _, t := range tpl.Plan.ClusterTopology {
+			if t != nil && t.InstanceConfigurationID != "" {
+				tplInstanceConfigurations[t.ID] = t.InstanceConfigurationID
+			}
+		}
+	}
+
+	var diags diag.Diagnostics
+	topologies, _ := es[0].(map[string]interface{})["topology"].([]interface{})
+	for _, rawTop := range topologies {
+		topology, ok := rawTop.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		id, _ := topology["id"].(string)
+		current, _ := topology["instance_configuration_id"].(string)
+		latest := tplInstanceConfigurations[id]
+		if current == "" || latest == "" || current == latest {
+			continue
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary: fmt.Sprintf(
+				`elasticsearch topology %s migrated to the latest hardware: "%s" to "%s"`,
+				id, current, latest,
+			),
+			Detail: fmt.Sprintf(
+				`The "%s" deployment template uses the "%s" instance configuration for the %s topology element. The element is migrated from "%s" by the deployment update.`,
+				tplID, latest, id, current,
+			),
+		})
+	}
+
+	return diags
+}
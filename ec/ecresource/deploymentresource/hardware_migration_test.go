@@ -0,0 +1,118 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_checkHardwareMigration(t *testing.T) {
+	tpl := parseDeploymentTemplate(t, "testdata/template-aws-io-optimized-v2.json")
+	newState := func(warmIC string) map[string]interface{} {
+		return map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.11.1",
+			"elasticsearch": []interface{}{map[string]interface{}{
+				"topology": []interface{}{
+					map[string]interface{}{
+						"id":                        "hot_content",
+						"instance_configuration_id": "aws.data.highio.i3",
+						"size":                      "8g",
+						"zone_count":                2,
+					},
+					map[string]interface{}{
+						"id":                        "warm",
+						"instance_configuration_id": warmIC,
+						"size":                      "2g",
+						"zone_count":                1,
+					},
+				},
+			}},
+		}
+	}
+	tests := []struct {
+		name   string
+		state  map[string]interface{}
+		change map[string]interface{}
+		client *api.API
+		want   diag.Diagnostics
+	}{
+		{
+			name:   "returns no diagnostics when the instance configurations are up to date",
+			state:  newState("aws.data.highstorage.d3"),
+			client: api.NewMock(mock.New200StructResponse(tpl)),
+		},
+		{
+			name:   "returns a warning for an outdated instance configuration",
+			state:  newState("aws.data.highstorage.d2"),
+			client: api.NewMock(mock.New200StructResponse(tpl)),
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `elasticsearch topology warm migrated to the latest hardware: "aws.data.highstorage.d2" to "aws.data.highstorage.d3"`,
+				Detail:   `The "aws-io-optimized-v2" deployment template uses the "aws.data.highstorage.d3" instance configuration for the warm topology element. The element is migrated from "aws.data.highstorage.d2" by the deployment update.`,
+			}},
+		},
+		{
+			name:   "returns a warning for the outdated instance configuration of the prior state",
+			state:  newState("aws.data.highstorage.d2"),
+			change: newState("aws.data.highstorage.d3"),
+			client: api.NewMock(mock.New200StructResponse(tpl)),
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `elasticsearch topology warm migrated to the latest hardware: "aws.data.highstorage.d2" to "aws.data.highstorage.d3"`,
+				Detail:   `The "aws-io-optimized-v2" deployment template uses the "aws.data.highstorage.d3" instance configuration for the warm topology element. The element is migrated from "aws.data.highstorage.d2" by the deployment update.`,
+			}},
+		},
+		{
+			name:  "returns no diagnostics when the deployment template can't be obtained",
+			state: newState("aws.data.highstorage.d2"),
+			client: api.NewMock(mock.NewErrorResponse(500, mock.APIError{
+				Code: "some", Message: "message",
+			})),
+		},
+		{
+			name:   "returns no diagnostics without an elasticsearch resource",
+			state:  map[string]interface{}{"deployment_template_id": "aws-io-optimized-v2"},
+			client: api.NewMock(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := tt.change
+			if change == nil {
+				change = tt.state
+			}
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				State:  tt.state,
+				Change: change,
+				Schema: newSchema(),
+			})
+			got := checkHardwareMigration(d, tt.client)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -101,26 +101,36 @@ func Test_importFunc(t *testing.T) {
 			want: map[string]string{
 				"id": "320b7b540dfc967a7a649c18e2fce4ed",
 
-				"name":                   "my_deployment_name",
-				"region":                 "us-east-1",
-				"version":                "7.9.2",
-				"deployment_template_id": "aws-cross-cluster-search-v2",
+				"name":                           "my_deployment_name",
+				"region":                         "us-east-1",
+				"version":                        "7.9.2",
+				"deployment_template_id":         "aws-cross-cluster-search-v2",
+				"migrate_to_latest_hardware":     "false",
+				"traffic_filter_include_default": "true",
+				"autoscale_size_as_min":          "false",
+				"verify_docker_images":           "false",
 
-				"elasticsearch.#":                   "1",
-				"elasticsearch.0.autoscale":         "",
-				"elasticsearch.0.cloud_id":          "",
-				"elasticsearch.0.snapshot_source.#": "0",
-				"elasticsearch.0.config.#":          "0",
-				"elasticsearch.0.extension.#":       "0",
-				"elasticsearch.0.http_endpoint":     "",
-				"elasticsearch.0.https_endpoint":    "",
-				"elasticsearch.0.ref_id":            "main-elasticsearch",
-				"elasticsearch.0.region":            "",
-				"elasticsearch.0.remote_cluster.#":  "0",
-				"elasticsearch.0.resource_id":       "",
-				"elasticsearch.0.topology.#":        "0",
-				"elasticsearch.0.trust_account.#":   "0",
-				"elasticsearch.0.trust_external.#":  "0",
+				"elasticsearch.#":                               "1",
+				"elasticsearch.0.autoscale":                     "",
+				"elasticsearch.0.cloud_id":                      "",
+				"elasticsearch.0.snapshot_source.#":             "0",
+				"elasticsearch.0.config.#":                      "0",
+				"elasticsearch.0.extension.#":                   "0",
+				"elasticsearch.0.http_endpoint":                 "",
+				"elasticsearch.0.https_endpoint":                "",
+				"elasticsearch.0.kind":                          "",
+				"elasticsearch.0.ref_id":                        "main-elasticsearch",
+				"elasticsearch.0.region":                        "",
+				"elasticsearch.0.remote_cluster.#":              "0",
+				"elasticsearch.0.resource_id":                   "",
+				"elasticsearch.0.trust_self":                    "false",
+				"elasticsearch.0.plan_strategy":                 "",
+				"elasticsearch.0.include_remote_cluster_client": "true",
+				"elasticsearch.0.dedicated_masters":             "false",
+				"elasticsearch.0.dedicated_masters_threshold":   "0",
+				"elasticsearch.0.topology.#":                    "0",
+				"elasticsearch.0.trust_account.#":               "0",
+				"elasticsearch.0.trust_external.#":              "0",
 			},
 		},
 		{
@@ -149,26 +159,36 @@ func Test_importFunc(t *testing.T) {
 			want: map[string]string{
 				"id": "320b7b540dfc967a7a649c18e2fce4ed",
 
-				"name":                   "my_deployment_name",
-				"region":                 "us-east-1",
-				"version":                "5.6.1",
-				"deployment_template_id": "aws-cross-cluster-search-v2",
+				"name":                           "my_deployment_name",
+				"region":                         "us-east-1",
+				"version":                        "5.6.1",
+				"deployment_template_id":         "aws-cross-cluster-search-v2",
+				"migrate_to_latest_hardware":     "false",
+				"traffic_filter_include_default": "true",
+				"autoscale_size_as_min":          "false",
+				"verify_docker_images":           "false",
 
-				"elasticsearch.#":                   "1",
-				"elasticsearch.0.autoscale":         "",
-				"elasticsearch.0.cloud_id":          "",
-				"elasticsearch.0.snapshot_source.#": "0",
-				"elasticsearch.0.config.#":          "0",
-				"elasticsearch.0.extension.#":       "0",
-				"elasticsearch.0.http_endpoint":     "",
-				"elasticsearch.0.https_endpoint":    "",
-				"elasticsearch.0.ref_id":            "main-elasticsearch",
-				"elasticsearch.0.region":            "",
-				"elasticsearch.0.remote_cluster.#":  "0",
-				"elasticsearch.0.resource_id":       "",
-				"elasticsearch.0.topology.#":        "0",
-				"elasticsearch.0.trust_account.#":   "0",
-				"elasticsearch.0.trust_external.#":  "0",
+				"elasticsearch.#":                               "1",
+				"elasticsearch.0.autoscale":                     "",
+				"elasticsearch.0.cloud_id":                      "",
+				"elasticsearch.0.snapshot_source.#":             "0",
+				"elasticsearch.0.config.#":                      "0",
+				"elasticsearch.0.extension.#":                   "0",
+				"elasticsearch.0.http_endpoint":                 "",
+				"elasticsearch.0.https_endpoint":                "",
+				"elasticsearch.0.kind":                          "",
+				"elasticsearch.0.ref_id":                        "main-elasticsearch",
+				"elasticsearch.0.region":                        "",
+				"elasticsearch.0.remote_cluster.#":              "0",
+				"elasticsearch.0.resource_id":                   "",
+				"elasticsearch.0.trust_self":                    "false",
+				"elasticsearch.0.plan_strategy":                 "",
+				"elasticsearch.0.include_remote_cluster_client": "true",
+				"elasticsearch.0.dedicated_masters":             "false",
+				"elasticsearch.0.dedicated_masters_threshold":   "0",
+				"elasticsearch.0.topology.#":                    "0",
+				"elasticsearch.0.trust_account.#":               "0",
+				"elasticsearch.0.trust_external.#":              "0",
 			},
 		},
 		{
@@ -197,26 +217,36 @@ func Test_importFunc(t *testing.T) {
 			want: map[string]string{
 				"id": "320b7b540dfc967a7a649c18e2fce4ed",
 
-				"name":                   "my_deployment_name",
-				"region":                 "us-east-1",
-				"version":                "6.5.1",
-				"deployment_template_id": "aws-cross-cluster-search-v2",
+				"name":                           "my_deployment_name",
+				"region":                         "us-east-1",
+				"version":                        "6.5.1",
+				"deployment_template_id":         "aws-cross-cluster-search-v2",
+				"migrate_to_latest_hardware":     "false",
+				"traffic_filter_include_default": "true",
+				"autoscale_size_as_min":          "false",
+				"verify_docker_images":           "false",
 
-				"elasticsearch.#":                   "1",
-				"elasticsearch.0.autoscale":         "",
-				"elasticsearch.0.cloud_id":          "",
-				"elasticsearch.0.snapshot_source.#": "0",
-				"elasticsearch.0.config.#":          "0",
-				"elasticsearch.0.extension.#":       "0",
-				"elasticsearch.0.http_endpoint":     "",
-				"elasticsearch.0.https_endpoint":    "",
-				"elasticsearch.0.ref_id":            "main-elasticsearch",
-				"elasticsearch.0.region":            "",
-				"elasticsearch.0.remote_cluster.#":  "0",
-				"elasticsearch.0.resource_id":       "",
-				"elasticsearch.0.topology.#":        "0",
-				"elasticsearch.0.trust_account.#":   "0",
-				"elasticsearch.0.trust_external.#":  "0",
+				"elasticsearch.#":                               "1",
+				"elasticsearch.0.autoscale":                     "",
+				"elasticsearch.0.cloud_id":                      "",
+				"elasticsearch.0.snapshot_source.#":             "0",
+				"elasticsearch.0.config.#":                      "0",
+				"elasticsearch.0.extension.#":                   "0",
+				"elasticsearch.0.http_endpoint":                 "",
+				"elasticsearch.0.https_endpoint":                "",
+				"elasticsearch.0.kind":                          "",
+				"elasticsearch.0.ref_id":                        "main-elasticsearch",
+				"elasticsearch.0.region":                        "",
+				"elasticsearch.0.remote_cluster.#":              "0",
+				"elasticsearch.0.resource_id":                   "",
+				"elasticsearch.0.trust_self":                    "false",
+				"elasticsearch.0.plan_strategy":                 "",
+				"elasticsearch.0.include_remote_cluster_client": "true",
+				"elasticsearch.0.dedicated_masters":             "false",
+				"elasticsearch.0.dedicated_masters_threshold":   "0",
+				"elasticsearch.0.topology.#":                    "0",
+				"elasticsearch.0.trust_account.#":               "0",
+				"elasticsearch.0.trust_external.#":              "0",
 			},
 		},
 	}
@@ -18,9 +18,6 @@
 package deploymentresource
 
 import (
-	"bytes"
-	"encoding/json"
-
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
@@ -31,7 +28,13 @@ func flattenIntegrationsServerResources(in []*models.IntegrationsServerResourceI
 	var result = make([]interface{}, 0, len(in))
 	for _, res := range in {
 		var m = make(map[string]interface{})
-		if util.IsCurrentIntegrationsServerPlanEmpty(res) || isIntegrationsServerResourceStopped(res) {
+		if util.IsCurrentIntegrationsServerPlanEmpty(res) {
+			continue
+		}
+
+		// Resources which have been scaled to zero are kept in the state so
+		// that their configuration block doesn't cause a perpetual diff.
+		if isIntegrationsServerResourceStopped(res) && !isIntegrationsServerPlanScaledToZero(res.Info.PlanInfo.Current.Plan) {
 			continue
 		}
 
@@ -41,10 +44,16 @@ func flattenIntegrationsServerResources(in []*models.IntegrationsServerResourceI
 
 		if res.Info.ID != nil && *res.Info.ID != "" {
 			m["resource_id"] = *res.Info.ID
+		} else if res.ID != nil && *res.ID != "" {
+			m["resource_id"] = *res.ID
 		}
 
-		if res.Region != nil {
+		m["kind"] = "integrations_server"
+
+		if res.Region != nil && *res.Region != "" {
 			m["region"] = *res.Region
+		} else if res.Info.Region != "" {
+			m["region"] = res.Info.Region
 		}
 
 		plan := res.Info.PlanInfo.Current.Plan
@@ -71,10 +80,15 @@ func flattenIntegrationsServerResources(in []*models.IntegrationsServerResourceI
 }
 
 func flattenIntegrationsServerTopology(plan *models.IntegrationsServerPlan) []interface{} {
+	var scaledToZero = isIntegrationsServerPlanScaledToZero(plan)
 	var result = make([]interface{}, 0, len(plan.ClusterTopology))
 	for _, topology := range plan.ClusterTopology {
 		var m = make(map[string]interface{})
-		if topology.Size == nil || topology.Size.Value == nil || *topology.Size.Value == 0 {
+		if topology.Size == nil || topology.Size.Value == nil {
+			continue
+		}
+
+		if *topology.Size.Value == 0 && !scaledToZero {
 			continue
 		}
 
@@ -83,7 +97,7 @@ func flattenIntegrationsServerTopology(plan *models.IntegrationsServerPlan) []in
 		}
 
 		if topology.Size != nil {
-			m["size"] = util.MemoryToState(*topology.Size.Value)
+			m["size"] = util.SizeToState(*topology.Size.Value)
 			m["size_resource"] = *topology.Size.Resource
 		}
 
@@ -101,25 +115,9 @@ func flattenIntegrationsServerConfig(cfg *models.IntegrationsServerConfiguration
 		return nil
 	}
 
-	if cfg.UserSettingsYaml != "" {
-		m["user_settings_yaml"] = cfg.UserSettingsYaml
-	}
-
-	if cfg.UserSettingsOverrideYaml != "" {
-		m["user_settings_override_yaml"] = cfg.UserSettingsOverrideYaml
-	}
-
-	if o := cfg.UserSettingsJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_json"] = string(b)
-		}
-	}
-
-	if o := cfg.UserSettingsOverrideJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_override_json"] = string(b)
-		}
-	}
+	flattenUserSettings(m, cfg.UserSettingsYaml, cfg.UserSettingsOverrideYaml,
+		cfg.UserSettingsJSON, cfg.UserSettingsOverrideJSON,
+	)
 
 	if cfg.DockerImage != "" {
 		m["docker_image"] = cfg.DockerImage
@@ -97,6 +97,7 @@ func Test_flattenIntegrationsServerResource(t *testing.T) {
 				map[string]interface{}{
 					"elasticsearch_cluster_ref_id": "main-elasticsearch",
 					"ref_id":                       "main-integrations_server",
+					"kind":                         "integrations_server",
 					"resource_id":                  mock.ValidClusterID,
 					"region":                       "some-region",
 					"http_endpoint":                "http://integrations_serverresource.cloud.elastic.co:9200",
@@ -207,6 +208,7 @@ func Test_flattenIntegrationsServerResource(t *testing.T) {
 			want: []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-integrations_server",
+				"kind":                         "integrations_server",
 				"resource_id":                  mock.ValidClusterID,
 				"region":                       "some-region",
 				"http_endpoint":                "http://integrations_serverresource.cloud.elastic.co:9200",
@@ -278,6 +280,7 @@ func Test_flattenIntegrationsServerResource(t *testing.T) {
 			want: []interface{}{map[string]interface{}{
 				"elasticsearch_cluster_ref_id": "main-elasticsearch",
 				"ref_id":                       "main-integrations_server",
+				"kind":                         "integrations_server",
 				"resource_id":                  mock.ValidClusterID,
 				"region":                       "some-region",
 				"http_endpoint":                "http://integrations_serverresource.cloud.elastic.co:9200",
@@ -306,3 +309,48 @@ func Test_flattenIntegrationsServerResource(t *testing.T) {
 		})
 	}
 }
+
+func Test_flattenIntegrationsServerConfig(t *testing.T) {
+	type args struct {
+		cfg *models.IntegrationsServerConfiguration
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "flattens no config when empty",
+			args: args{cfg: &models.IntegrationsServerConfiguration{}},
+		},
+		{
+			name: "flattens the user settings set server side",
+			args: args{cfg: &models.IntegrationsServerConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON:         map[string]interface{}{"some.setting": "value"},
+				UserSettingsOverrideJSON: map[string]interface{}{},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+			}},
+		},
+		{
+			name: "flattens the custom docker image set server side",
+			args: args{cfg: &models.IntegrationsServerConfiguration{
+				DockerImage: "docker.elastic.co/cloud-ci/integrations-server:8.0.0-SNAPSHOT",
+			}},
+			want: []interface{}{map[string]interface{}{
+				"docker_image": "docker.elastic.co/cloud-ci/integrations-server:8.0.0-SNAPSHOT",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenIntegrationsServerConfig(tt.args.cfg)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
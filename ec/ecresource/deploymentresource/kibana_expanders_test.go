@@ -86,6 +86,45 @@ func Test_expandKibanaResources(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "parses a kibana resource scaled to zero",
+			args: args{
+				tpl: tpl(),
+				ess: []interface{}{
+					map[string]interface{}{
+						"ref_id":                       "main-kibana",
+						"resource_id":                  mock.ValidClusterID,
+						"region":                       "some-region",
+						"elasticsearch_cluster_ref_id": "somerefid",
+						"topology": []interface{}{map[string]interface{}{
+							"instance_configuration_id": "aws.kibana.r5d",
+							"size":                      "0g",
+							"zone_count":                1,
+						}},
+					},
+				},
+			},
+			want: []*models.KibanaPayload{
+				{
+					ElasticsearchClusterRefID: ec.String("somerefid"),
+					Region:                    ec.String("some-region"),
+					RefID:                     ec.String("main-kibana"),
+					Plan: &models.KibanaClusterPlan{
+						Kibana: &models.KibanaConfiguration{},
+						ClusterTopology: []*models.KibanaClusterTopologyElement{
+							{
+								ZoneCount:               1,
+								InstanceConfigurationID: "aws.kibana.r5d",
+								Size: &models.TopologySize{
+									Resource: ec.String("memory"),
+									Value:    ec.Int32(0),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "parses a kibana resource with incorrect instance_configuration_id",
 			args: args{
@@ -258,3 +297,74 @@ func Test_expandKibanaResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_expandKibanaConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []interface{}
+		want *models.KibanaConfiguration
+		err  error
+	}{
+		{
+			name: "expands all the user settings",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+				"user_settings_override_json": `{"some.setting":"override"}`,
+			}},
+			want: &models.KibanaConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON: map[string]interface{}{
+					"some.setting": "value",
+				},
+				UserSettingsOverrideJSON: map[string]interface{}{
+					"some.setting": "override",
+				},
+			},
+		},
+		{
+			name: "expands the plugin settings from the user settings",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_yaml": "xpack.reporting.enabled: false\nxpack.fleet.enabled: true",
+				"user_settings_json": `{"xpack.reporting.enabled":false}`,
+			}},
+			want: &models.KibanaConfiguration{
+				UserSettingsYaml: "xpack.reporting.enabled: false\nxpack.fleet.enabled: true",
+				UserSettingsJSON: map[string]interface{}{
+					"xpack.reporting.enabled": false,
+				},
+			},
+		},
+		{
+			name: "leaves the JSON user settings unset when empty",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_json":          "",
+				"user_settings_override_json": "",
+			}},
+			want: &models.KibanaConfiguration{},
+		},
+		{
+			name: "fails expanding an invalid user_settings_override_json",
+			raw: []interface{}{map[string]interface{}{
+				"user_settings_override_json": `{"some.setting":}`,
+			}},
+			want: &models.KibanaConfiguration{},
+			err:  errors.New("failed expanding kibana user_settings_override_json: invalid character '}' looking for beginning of value"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.KibanaConfiguration
+			err := expandKibanaConfig(tt.raw, &got)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, &got)
+		})
+	}
+}
@@ -18,9 +18,6 @@
 package deploymentresource
 
 import (
-	"bytes"
-	"encoding/json"
-
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
@@ -31,7 +28,13 @@ func flattenKibanaResources(in []*models.KibanaResourceInfo, name string) []inte
 	result := make([]interface{}, 0, len(in))
 	for _, res := range in {
 		m := make(map[string]interface{})
-		if util.IsCurrentKibanaPlanEmpty(res) || isKibanaResourceStopped(res) {
+		if util.IsCurrentKibanaPlanEmpty(res) {
+			continue
+		}
+
+		// Resources which have been scaled to zero are kept in the state so
+		// that their configuration block doesn't cause a perpetual diff.
+		if isKibanaResourceStopped(res) && !isKibanaPlanScaledToZero(res.Info.PlanInfo.Current.Plan) {
 			continue
 		}
 
@@ -39,12 +42,20 @@ func flattenKibanaResources(in []*models.KibanaResourceInfo, name string) []inte
 			m["ref_id"] = *res.RefID
 		}
 
+		// Imported deployments may lack some of the resource info fields, so
+		// the resource level ones are used as a fallback.
 		if res.Info.ClusterID != nil && *res.Info.ClusterID != "" {
 			m["resource_id"] = *res.Info.ClusterID
+		} else if res.ID != nil && *res.ID != "" {
+			m["resource_id"] = *res.ID
 		}
 
-		if res.Region != nil {
+		m["kind"] = "kibana"
+
+		if res.Region != nil && *res.Region != "" {
 			m["region"] = *res.Region
+		} else if res.Info.Region != "" {
+			m["region"] = res.Info.Region
 		}
 
 		plan := res.Info.PlanInfo.Current.Plan
@@ -71,10 +82,15 @@ func flattenKibanaResources(in []*models.KibanaResourceInfo, name string) []inte
 }
 
 func flattenKibanaTopology(plan *models.KibanaClusterPlan) []interface{} {
+	var scaledToZero = isKibanaPlanScaledToZero(plan)
 	var result = make([]interface{}, 0, len(plan.ClusterTopology))
 	for _, topology := range plan.ClusterTopology {
 		var m = make(map[string]interface{})
-		if topology.Size == nil || topology.Size.Value == nil || *topology.Size.Value == 0 {
+		if topology.Size == nil || topology.Size.Value == nil {
+			continue
+		}
+
+		if *topology.Size.Value == 0 && !scaledToZero {
 			continue
 		}
 
@@ -83,7 +99,7 @@ func flattenKibanaTopology(plan *models.KibanaClusterPlan) []interface{} {
 		}
 
 		if topology.Size != nil {
-			m["size"] = util.MemoryToState(*topology.Size.Value)
+			m["size"] = util.SizeToState(*topology.Size.Value)
 			m["size_resource"] = *topology.Size.Resource
 
 		}
@@ -102,25 +118,9 @@ func flattenKibanaConfig(cfg *models.KibanaConfiguration) []interface{} {
 		return nil
 	}
 
-	if cfg.UserSettingsYaml != "" {
-		m["user_settings_yaml"] = cfg.UserSettingsYaml
-	}
-
-	if cfg.UserSettingsOverrideYaml != "" {
-		m["user_settings_override_yaml"] = cfg.UserSettingsOverrideYaml
-	}
-
-	if o := cfg.UserSettingsJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_json"] = string(b)
-		}
-	}
-
-	if o := cfg.UserSettingsOverrideJSON; o != nil {
-		if b, _ := json.Marshal(o); len(b) > 0 && !bytes.Equal([]byte("{}"), b) {
-			m["user_settings_override_json"] = string(b)
-		}
-	}
+	flattenUserSettings(m, cfg.UserSettingsYaml, cfg.UserSettingsOverrideYaml,
+		cfg.UserSettingsJSON, cfg.UserSettingsOverrideJSON,
+	)
 
 	if cfg.DockerImage != "" {
 		m["docker_image"] = cfg.DockerImage
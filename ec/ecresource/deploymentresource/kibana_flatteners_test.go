@@ -179,6 +179,7 @@ func Test_flattenKibanaResources(t *testing.T) {
 				map[string]interface{}{
 					"elasticsearch_cluster_ref_id": "main-elasticsearch",
 					"ref_id":                       "main-kibana",
+					"kind":                         "kibana",
 					"resource_id":                  mock.ValidClusterID,
 					"region":                       "some-region",
 					"http_endpoint":                "http://kibanaresource.cloud.elastic.co:9200",
@@ -195,6 +196,7 @@ func Test_flattenKibanaResources(t *testing.T) {
 				map[string]interface{}{
 					"elasticsearch_cluster_ref_id": "main-elasticsearch",
 					"ref_id":                       "main-kibana",
+					"kind":                         "kibana",
 					"resource_id":                  mock.ValidClusterID,
 					"region":                       "some-region",
 					"http_endpoint":                "http://kibanaresource.cloud.elastic.co:9200",
@@ -214,6 +216,133 @@ func Test_flattenKibanaResources(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "parses the kibana resource id and region from the resource when missing from the info",
+			args: args{in: []*models.KibanaResourceInfo{
+				{
+					ID:                        &mock.ValidClusterID,
+					RefID:                     ec.String("main-kibana"),
+					ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+					Info: &models.KibanaClusterInfo{
+						ClusterName: ec.String("some-kibana-name"),
+						Region:      "some-region",
+						Status:      ec.String("started"),
+						PlanInfo: &models.KibanaClusterPlansInfo{
+							Current: &models.KibanaClusterPlanInfo{
+								Plan: &models.KibanaClusterPlan{
+									Kibana: &models.KibanaConfiguration{
+										Version: "7.7.0",
+									},
+									ClusterTopology: []*models.KibanaClusterTopologyElement{
+										{
+											ZoneCount:               1,
+											InstanceConfigurationID: "aws.kibana.r4",
+											Size: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(1024),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}},
+			want: []interface{}{
+				map[string]interface{}{
+					"elasticsearch_cluster_ref_id": "main-elasticsearch",
+					"ref_id":                       "main-kibana",
+					"kind":                         "kibana",
+					"resource_id":                  mock.ValidClusterID,
+					"region":                       "some-region",
+					"topology": []interface{}{
+						map[string]interface{}{
+							"instance_configuration_id": "aws.kibana.r4",
+							"size":                      "1g",
+							"size_resource":             "memory",
+							"zone_count":                int32(1),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "skips a stopped kibana resource",
+			args: args{in: []*models.KibanaResourceInfo{
+				{
+					RefID: ec.String("main-kibana"),
+					Info: &models.KibanaClusterInfo{
+						ClusterID: &mock.ValidClusterID,
+						Status:    ec.String("stopped"),
+						PlanInfo: &models.KibanaClusterPlansInfo{
+							Current: &models.KibanaClusterPlanInfo{
+								Plan: &models.KibanaClusterPlan{
+									ClusterTopology: []*models.KibanaClusterTopologyElement{
+										{
+											ZoneCount:               1,
+											InstanceConfigurationID: "aws.kibana.r4",
+											Size: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(1024),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}},
+			want: []interface{}{},
+		},
+		{
+			name: "keeps a stopped kibana resource which has been scaled to zero",
+			args: args{in: []*models.KibanaResourceInfo{
+				{
+					RefID:                     ec.String("main-kibana"),
+					ElasticsearchClusterRefID: ec.String("main-elasticsearch"),
+					Region:                    ec.String("some-region"),
+					Info: &models.KibanaClusterInfo{
+						ClusterID: &mock.ValidClusterID,
+						Status:    ec.String("stopped"),
+						PlanInfo: &models.KibanaClusterPlansInfo{
+							Current: &models.KibanaClusterPlanInfo{
+								Plan: &models.KibanaClusterPlan{
+									ClusterTopology: []*models.KibanaClusterTopologyElement{
+										{
+											ZoneCount:               1,
+											InstanceConfigurationID: "aws.kibana.r4",
+											Size: &models.TopologySize{
+												Resource: ec.String("memory"),
+												Value:    ec.Int32(0),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}},
+			want: []interface{}{
+				map[string]interface{}{
+					"elasticsearch_cluster_ref_id": "main-elasticsearch",
+					"ref_id":                       "main-kibana",
+					"kind":                         "kibana",
+					"resource_id":                  mock.ValidClusterID,
+					"region":                       "some-region",
+					"topology": []interface{}{
+						map[string]interface{}{
+							"instance_configuration_id": "aws.kibana.r4",
+							"size":                      "0g",
+							"size_resource":             "memory",
+							"zone_count":                int32(1),
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -222,3 +351,48 @@ func Test_flattenKibanaResources(t *testing.T) {
 		})
 	}
 }
+
+func Test_flattenKibanaConfig(t *testing.T) {
+	type args struct {
+		cfg *models.KibanaConfiguration
+	}
+	tests := []struct {
+		name string
+		args args
+		want []interface{}
+	}{
+		{
+			name: "flattens no config when empty",
+			args: args{cfg: &models.KibanaConfiguration{}},
+		},
+		{
+			name: "flattens the user settings set server side",
+			args: args{cfg: &models.KibanaConfiguration{
+				UserSettingsYaml:         "some.setting: value",
+				UserSettingsOverrideYaml: "some.setting: override",
+				UserSettingsJSON:         map[string]interface{}{"some.setting": "value"},
+				UserSettingsOverrideJSON: map[string]interface{}{},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"user_settings_yaml":          "some.setting: value",
+				"user_settings_override_yaml": "some.setting: override",
+				"user_settings_json":          `{"some.setting":"value"}`,
+			}},
+		},
+		{
+			name: "flattens the custom docker image set server side",
+			args: args{cfg: &models.KibanaConfiguration{
+				DockerImage: "docker.elastic.co/cloud-ci/kibana:7.15.0-SNAPSHOT",
+			}},
+			want: []interface{}{map[string]interface{}{
+				"docker_image": "docker.elastic.co/cloud-ci/kibana:7.15.0-SNAPSHOT",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flattenKibanaConfig(tt.args.cfg)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -18,17 +18,24 @@
 package deploymentresource
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/plan"
 	"github.com/elastic/cloud-sdk-go/pkg/util"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
-// flattenObservability parses a deployment's observability settings.
-func flattenObservability(settings *models.DeploymentSettings) []interface{} {
+// flattenObservability parses a deployment's observability settings. When the
+// destination is the deployment itself, "self" is set to true.
+func flattenObservability(settings *models.DeploymentSettings, deploymentID string) []interface{} {
 	if settings == nil || settings.Observability == nil {
 		return nil
 	}
@@ -53,10 +60,157 @@ func flattenObservability(settings *models.DeploymentSettings) []interface{} {
 		return nil
 	}
 
+	// When the logs and metrics are shipped to different Elasticsearch
+	// resources of the destination deployment, each ref ID is set.
+	if obs := settings.Observability; obs.Metrics != nil && obs.Logging != nil {
+		logsRefID, metricsRefID := obs.Logging.Destination.RefID, obs.Metrics.Destination.RefID
+		if logsRefID != nil && metricsRefID != nil && *logsRefID != *metricsRefID {
+			m["logs_ref_id"] = *logsRefID
+			m["metrics_ref_id"] = *metricsRefID
+		}
+	}
+
+	if depID, ok := m["deployment_id"].(*string); ok && depID != nil {
+		if deploymentID != "" && *depID == deploymentID {
+			m["self"] = true
+		}
+	}
+
 	return []interface{}{m}
 }
 
-func expandObservability(raw []interface{}, client *api.API) (*models.DeploymentObservabilitySettings, error) {
+// flattenLegacyMonitoring sets "observability" from the legacy monitoring
+// settings of the Elasticsearch resource when the deployment doesn't have any
+// observability settings, which happens with older deployments. The legacy
+// settings only ship the metrics to an Elasticsearch cluster, which is
+// resolved to its deployment and ref_id only when it differs from the one in
+// the state. Failures to resolve it are only logged, leaving "observability"
+// untouched.
+func flattenLegacyMonitoring(d *schema.ResourceData, res *models.DeploymentGetResponse, client *api.API) error {
+	if len(flattenObservability(res.Settings, d.Id())) > 0 {
+		return nil
+	}
+
+	prior, _ := d.Get("observability.0.legacy_monitoring_target").(string)
+	target := legacyMonitoringTarget(res.Resources)
+	if target == prior {
+		return nil
+	}
+
+	if target == "" {
+		return d.Set("observability", nil)
+	}
+
+	deploymentID, refID, err := lookupElasticsearchResource(client, target)
+	if err != nil {
+		log.Printf("[DEBUG] skipping the legacy monitoring settings: %s", err)
+		return nil
+	}
+
+	m := map[string]interface{}{
+		"deployment_id":            deploymentID,
+		"ref_id":                   refID,
+		"metrics":                  true,
+		"logs":                     false,
+		"legacy_monitoring_target": target,
+	}
+	if deploymentID == d.Id() {
+		m["self"] = true
+	}
+
+	return d.Set("observability", []interface{}{m})
+}
+
+// hasLegacyMonitoring returns true when the observability settings in the
+// state have been read from the legacy monitoring settings.
+func hasLegacyMonitoring(d *schema.ResourceData) bool {
+	target, _ := d.Get("observability.0.legacy_monitoring_target").(string)
+	return target != ""
+}
+
+// suppressLegacyMonitoring suppresses the removal of the observability
+// settings read from the legacy monitoring settings when the configuration
+// doesn't set any, since these would otherwise show as a perpetual diff.
+func suppressLegacyMonitoring(_, _, _ string, d *schema.ResourceData) bool {
+	if !hasLegacyMonitoring(d) {
+		return false
+	}
+
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return false
+	}
+
+	observability := config.GetAttr("observability")
+	return observability.IsKnown() &&
+		(observability.IsNull() || observability.LengthInt() == 0)
+}
+
+// legacyMonitoringTarget returns the ID of the Elasticsearch cluster which the
+// legacy monitoring settings of the Elasticsearch resource ship the metrics
+// to, or an empty string when these aren't set.
+func legacyMonitoringTarget(res *models.DeploymentResources) string {
+	if res == nil {
+		return ""
+	}
+
+	for _, es := range res.Elasticsearch {
+		if es == nil || es.Info == nil || es.Info.Settings == nil {
+			continue
+		}
+		if m := es.Info.Settings.Monitoring; m != nil && m.TargetClusterID != nil {
+			return *m.TargetClusterID
+		}
+	}
+
+	return ""
+}
+
+// lookupElasticsearchResource returns the deployment ID and ref_id of the
+// Elasticsearch resource with the resourceID.
+func lookupElasticsearchResource(client *api.API, resourceID string) (string, string, error) {
+	res, err := deploymentapi.Search(deploymentapi.SearchParams{
+		API:     client,
+		Request: plan.NewReverseLookupQuery(resourceID, util.Elasticsearch),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, dep := range res.Deployments {
+		if dep == nil || dep.ID == nil || dep.Resources == nil {
+			continue
+		}
+		for _, es := range dep.Resources.Elasticsearch {
+			if es.ID != nil && *es.ID == resourceID && es.RefID != nil {
+				return *dep.ID, *es.RefID, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf(`elasticsearch resource "%s" not found`, resourceID)
+}
+
+// observabilityTargetsSelf returns true when the observability settings ship
+// the logs and metrics to the deployment itself.
+func observabilityTargetsSelf(raw []interface{}) bool {
+	for _, rawObs := range raw {
+		obs, ok := rawObs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if self, ok := obs["self"].(bool); ok && self {
+			return true
+		}
+	}
+	return false
+}
+
+// expandObservability expands the flattened observability settings. When
+// "self" is set, the destination is the deployment with the deploymentID,
+// which is empty before the deployment has been created. In that case, no
+// settings are returned and these are applied with a follow-up update.
+func expandObservability(raw []interface{}, deploymentID string, client *api.API) (*models.DeploymentObservabilitySettings, error) {
 	if len(raw) == 0 {
 		return nil, nil
 	}
@@ -67,12 +221,31 @@ func expandObservability(raw []interface{}, client *api.API) (*models.Deployment
 		var obs = rawObs.(map[string]interface{})
 
 		depID, ok := obs["deployment_id"]
+		if self, _ := obs["self"].(bool); self {
+			if deploymentID == "" {
+				return nil, nil
+			}
+			depID, ok = deploymentID, true
+		}
+
 		if !ok {
 			return nil, nil
 		}
 
+		if depID == "" {
+			return nil, errors.New(`observability: one of "deployment_id" or "self" must be set`)
+		}
+
+		logsRefID, _ := obs["logs_ref_id"].(string)
+		metricsRefID, _ := obs["metrics_ref_id"].(string)
+		logs, _ := obs["logs"].(bool)
+		metrics, _ := obs["metrics"].(bool)
+
+		// The ref_id is only discovered when any of the enabled destinations
+		// doesn't override it.
 		refID, ok := obs["ref_id"]
-		if !ok || refID == "" {
+		needsRefID := (logs && logsRefID == "") || (metrics && metricsRefID == "")
+		if (!ok || refID == "") && needsRefID {
 			params := deploymentapi.PopulateRefIDParams{
 				Kind:         util.Elasticsearch,
 				API:          client,
@@ -87,20 +260,28 @@ func expandObservability(raw []interface{}, client *api.API) (*models.Deployment
 			refID = *params.RefID
 		}
 
-		if logging := obs["logs"]; logging.(bool) {
+		if logsRefID == "" {
+			logsRefID, _ = refID.(string)
+		}
+
+		if metricsRefID == "" {
+			metricsRefID, _ = refID.(string)
+		}
+
+		if logs {
 			req.Logging = &models.DeploymentLoggingSettings{
 				Destination: &models.AbsoluteRefID{
 					DeploymentID: ec.String(depID.(string)),
-					RefID:        ec.String(refID.(string)),
+					RefID:        ec.String(logsRefID),
 				},
 			}
 		}
 
-		if metrics := obs["metrics"]; metrics.(bool) {
+		if metrics {
 			req.Metrics = &models.DeploymentMetricsSettings{
 				Destination: &models.AbsoluteRefID{
 					DeploymentID: ec.String(depID.(string)),
-					RefID:        ec.String(refID.(string)),
+					RefID:        ec.String(metricsRefID),
 				},
 			}
 		}
@@ -108,3 +289,81 @@ func expandObservability(raw []interface{}, client *api.API) (*models.Deployment
 
 	return &req, nil
 }
+
+// newObservabilitySelfUpdate returns the update request which sets the
+// observability settings of a deployment which ships the logs and metrics to
+// itself, since its ID is only known once it has been created. Only the
+// observability settings are part of the request, leaving the rest of the
+// deployment untouched.
+func newObservabilitySelfUpdate(d *schema.ResourceData, deploymentID string, client *api.API) (*models.DeploymentUpdateRequest, error) {
+	observability, err := expandObservability(
+		d.Get("observability").([]interface{}), deploymentID, client,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DeploymentUpdateRequest{
+		PruneOrphans: ec.Bool(false),
+		Settings: &models.DeploymentUpdateSettings{
+			Observability: observability,
+		},
+	}, nil
+}
+
+// updateObservabilitySelf sets the observability settings of the newly
+// created deployment which ships the logs and metrics to itself and waits for
+// the resulting plan to finish.
+func updateObservabilitySelf(ctx context.Context, d *schema.ResourceData, client *api.API, deploymentID string) error {
+	req, err := newObservabilitySelfUpdate(d, deploymentID, client)
+	if err != nil {
+		return err
+	}
+
+	if _, err := deploymentapi.Update(deploymentapi.UpdateParams{
+		API:          client,
+		DeploymentID: deploymentID,
+		Request:      req,
+	}); err != nil {
+		return err
+	}
+
+	return WaitForPlanCompletion(ctx, client, deploymentID)
+}
+
+// checkObservabilityDestination returns a warning when the observability
+// destination deployment isn't healthy, since the shipped logs and metrics
+// may be lost. Any errors obtaining the destination are ignored, since the
+// destination is validated when the observability settings are expanded.
+func checkObservabilityDestination(raw []interface{}, client *api.API) diag.Diagnostics {
+	for _, rawObs := range raw {
+		obs, ok := rawObs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if self, _ := obs["self"].(bool); self {
+			continue
+		}
+
+		depID, _ := obs["deployment_id"].(string)
+		if depID == "" {
+			continue
+		}
+
+		res, err := deploymentapi.Get(deploymentapi.GetParams{
+			API: client, DeploymentID: depID,
+		})
+		if err != nil || res.Healthy == nil || *res.Healthy {
+			continue
+		}
+
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf(`observability destination deployment "%s" is unhealthy`, depID),
+			Detail:   "The shipped logs and metrics may be lost until the destination deployment is healthy.",
+		}}
+	}
+
+	return nil
+}
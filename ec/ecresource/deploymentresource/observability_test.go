@@ -18,18 +18,27 @@
 package deploymentresource
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
 
 func TestFlattenObservability(t *testing.T) {
 	type args struct {
-		settings *models.DeploymentSettings
+		settings     *models.DeploymentSettings
+		deploymentID string
 	}
 	tests := []struct {
 		name string
@@ -109,10 +118,59 @@ func TestFlattenObservability(t *testing.T) {
 				"metrics":       true,
 			}},
 		},
+		{
+			name: "flattens observability settings with different logs and metrics ref ids",
+			args: args{settings: &models.DeploymentSettings{
+				Observability: &models.DeploymentObservabilitySettings{
+					Logging: &models.DeploymentLoggingSettings{
+						Destination: &models.AbsoluteRefID{
+							DeploymentID: &mock.ValidClusterID,
+							RefID:        ec.String("logs-elasticsearch"),
+						},
+					},
+					Metrics: &models.DeploymentMetricsSettings{
+						Destination: &models.AbsoluteRefID{
+							DeploymentID: &mock.ValidClusterID,
+							RefID:        ec.String("metrics-elasticsearch"),
+						},
+					},
+				},
+			}},
+			want: []interface{}{map[string]interface{}{
+				"deployment_id":  &mock.ValidClusterID,
+				"ref_id":         ec.String("logs-elasticsearch"),
+				"logs_ref_id":    "logs-elasticsearch",
+				"metrics_ref_id": "metrics-elasticsearch",
+				"logs":           true,
+				"metrics":        true,
+			}},
+		},
+		{
+			name: "flattens observability settings targeting the deployment itself",
+			args: args{
+				deploymentID: mock.ValidClusterID,
+				settings: &models.DeploymentSettings{
+					Observability: &models.DeploymentObservabilitySettings{
+						Logging: &models.DeploymentLoggingSettings{
+							Destination: &models.AbsoluteRefID{
+								DeploymentID: &mock.ValidClusterID,
+								RefID:        ec.String("main-elasticsearch"),
+							},
+						},
+					},
+				},
+			},
+			want: []interface{}{map[string]interface{}{
+				"deployment_id": &mock.ValidClusterID,
+				"ref_id":        ec.String("main-elasticsearch"),
+				"logs":          true,
+				"self":          true,
+			}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := flattenObservability(tt.args.settings)
+			got := flattenObservability(tt.args.settings, tt.args.deploymentID)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -120,13 +178,15 @@ func TestFlattenObservability(t *testing.T) {
 
 func TestExpandObservability(t *testing.T) {
 	type args struct {
-		v []interface{}
+		v            []interface{}
+		deploymentID string
 		*api.API
 	}
 	tests := []struct {
 		name string
 		args args
 		want *models.DeploymentObservabilitySettings
+		err  error
 	}{
 		{
 			name: "empty returns an empty request",
@@ -259,10 +319,431 @@ func TestExpandObservability(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "expands observability settings with different logs and metrics ref ids",
+			args: args{
+				v: []interface{}{map[string]interface{}{
+					"deployment_id":  mock.ValidClusterID,
+					"logs_ref_id":    "logs-elasticsearch",
+					"metrics_ref_id": "metrics-elasticsearch",
+					"metrics":        true,
+					"logs":           true,
+				}},
+			},
+			want: &models.DeploymentObservabilitySettings{
+				Logging: &models.DeploymentLoggingSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: &mock.ValidClusterID,
+						RefID:        ec.String("logs-elasticsearch"),
+					},
+				},
+				Metrics: &models.DeploymentMetricsSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: &mock.ValidClusterID,
+						RefID:        ec.String("metrics-elasticsearch"),
+					},
+				},
+			},
+		},
+		{
+			name: "expands the metrics ref id override along the ref id",
+			args: args{
+				v: []interface{}{map[string]interface{}{
+					"deployment_id":  mock.ValidClusterID,
+					"ref_id":         "main-elasticsearch",
+					"metrics_ref_id": "metrics-elasticsearch",
+					"metrics":        true,
+					"logs":           true,
+				}},
+			},
+			want: &models.DeploymentObservabilitySettings{
+				Logging: &models.DeploymentLoggingSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: &mock.ValidClusterID,
+						RefID:        ec.String("main-elasticsearch"),
+					},
+				},
+				Metrics: &models.DeploymentMetricsSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: &mock.ValidClusterID,
+						RefID:        ec.String("metrics-elasticsearch"),
+					},
+				},
+			},
+		},
+		{
+			name: "expands observability settings targeting the deployment itself",
+			args: args{
+				deploymentID: mock.ValidClusterID,
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": "",
+					"self":          true,
+					"ref_id":        "main-elasticsearch",
+					"metrics":       true,
+					"logs":          true,
+				}},
+			},
+			want: &models.DeploymentObservabilitySettings{
+				Logging: &models.DeploymentLoggingSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: &mock.ValidClusterID,
+						RefID:        ec.String("main-elasticsearch"),
+					},
+				},
+				Metrics: &models.DeploymentMetricsSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: &mock.ValidClusterID,
+						RefID:        ec.String("main-elasticsearch"),
+					},
+				},
+			},
+		},
+		{
+			name: "skips observability settings targeting the deployment itself before it's created",
+			args: args{
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": "",
+					"self":          true,
+					"metrics":       true,
+					"logs":          true,
+				}},
+			},
+		},
+		{
+			name: "fails when neither deployment_id nor self are set",
+			args: args{
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": "",
+					"self":          false,
+					"metrics":       true,
+					"logs":          true,
+				}},
+			},
+			err: errors.New(`observability: one of "deployment_id" or "self" must be set`),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, _ := expandObservability(tt.args.v, tt.args.API)
+			got, err := expandObservability(tt.args.v, tt.args.deploymentID, tt.args.API)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_newObservabilitySelfUpdate(t *testing.T) {
+	state := newSampleLegacyDeployment()
+	state["observability"] = []interface{}{map[string]interface{}{
+		"self":    true,
+		"ref_id":  "main-elasticsearch",
+		"logs":    true,
+		"metrics": true,
+	}}
+	d := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+	})
+	destination := &models.AbsoluteRefID{
+		DeploymentID: ec.String(mock.ValidClusterID),
+		RefID:        ec.String("main-elasticsearch"),
+	}
+
+	got, err := newObservabilitySelfUpdate(d, mock.ValidClusterID, api.NewMock())
+	assert.NoError(t, err)
+	assert.Equal(t, &models.DeploymentUpdateRequest{
+		PruneOrphans: ec.Bool(false),
+		Settings: &models.DeploymentUpdateSettings{
+			Observability: &models.DeploymentObservabilitySettings{
+				Logging: &models.DeploymentLoggingSettings{Destination: destination},
+				Metrics: &models.DeploymentMetricsSettings{Destination: destination},
+			},
+		},
+	}, got)
+}
+
+func Test_checkObservabilityDestination(t *testing.T) {
+	destination := func(healthy bool) *api.API {
+		return api.NewMock(mock.New200Response(
+			mock.NewStructBody(models.DeploymentGetResponse{
+				Healthy: ec.Bool(healthy),
+				ID:      ec.String(mock.ValidClusterID),
+			}),
+		))
+	}
+
+	type args struct {
+		v []interface{}
+		*api.API
+	}
+	tests := []struct {
+		name string
+		args args
+		want diag.Diagnostics
+	}{
+		{
+			name: "empty observability settings return no warnings",
+		},
+		{
+			name: "healthy destination returns no warnings",
+			args: args{
+				API: destination(true),
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": mock.ValidClusterID,
+				}},
+			},
+		},
+		{
+			name: "destination targeting the deployment itself isn't checked",
+			args: args{
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": "",
+					"self":          true,
+				}},
+			},
+		},
+		{
+			name: "destination which can't be obtained returns no warnings",
+			args: args{
+				API: api.NewMock(mock.NewErrorResponse(404, mock.APIError{
+					Code: "deployment.missing", Message: "deployment not found",
+				})),
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": mock.ValidClusterID,
+				}},
+			},
+		},
+		{
+			name: "unhealthy destination returns a warning",
+			args: args{
+				API: destination(false),
+				v: []interface{}{map[string]interface{}{
+					"deployment_id": mock.ValidClusterID,
+				}},
+			},
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `observability destination deployment "` + mock.ValidClusterID + `" is unhealthy`,
+				Detail:   "The shipped logs and metrics may be lost until the destination deployment is healthy.",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkObservabilityDestination(tt.args.v, tt.args.API)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_flattenLegacyMonitoring(t *testing.T) {
+	newResponse := func(target string, observability *models.DeploymentObservabilitySettings) *models.DeploymentGetResponse {
+		res := &models.DeploymentGetResponse{
+			Settings: &models.DeploymentSettings{Observability: observability},
+			Resources: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{{
+					Info: &models.ElasticsearchClusterInfo{
+						Settings: &models.ElasticsearchClusterSettings{},
+					},
+				}},
+			},
+		}
+		if target != "" {
+			res.Resources.Elasticsearch[0].Info.Settings.Monitoring = &models.ManagedMonitoringSettings{
+				TargetClusterID: ec.String(target),
+			}
+		}
+		return res
+	}
+	searchResponse := func(deploymentID string) mock.Response {
+		return mock.New200StructResponse(models.DeploymentsSearchResponse{
+			Deployments: []*models.DeploymentSearchResponse{{
+				ID: ec.String(deploymentID),
+				Resources: &models.DeploymentResources{
+					Elasticsearch: []*models.ElasticsearchResourceInfo{{
+						ID:    ec.String("monitoring-cluster-id"),
+						RefID: ec.String("main-elasticsearch"),
+					}},
+				},
+			}},
+		})
+	}
+	legacyObservability := []interface{}{map[string]interface{}{
+		"deployment_id":            "monitoring-deployment-id",
+		"ref_id":                   "main-elasticsearch",
+		"logs_ref_id":              "",
+		"metrics_ref_id":           "",
+		"self":                     false,
+		"metrics":                  true,
+		"logs":                     false,
+		"legacy_monitoring_target": "monitoring-cluster-id",
+	}}
+	tests := []struct {
+		name   string
+		prior  []interface{}
+		res    *models.DeploymentGetResponse
+		client *api.API
+		want   []interface{}
+	}{
+		{
+			name:   "maps the legacy monitoring settings to the metrics observability settings",
+			res:    newResponse("monitoring-cluster-id", nil),
+			client: api.NewMock(searchResponse("monitoring-deployment-id")),
+			want: []interface{}{map[string]interface{}{
+				"deployment_id":            "monitoring-deployment-id",
+				"ref_id":                   "main-elasticsearch",
+				"logs_ref_id":              "",
+				"metrics_ref_id":           "",
+				"self":                     false,
+				"metrics":                  true,
+				"logs":                     false,
+				"legacy_monitoring_target": "monitoring-cluster-id",
+			}},
+		},
+		{
+			name:   "sets self when the legacy monitoring settings target the deployment itself",
+			res:    newResponse("monitoring-cluster-id", nil),
+			client: api.NewMock(searchResponse(mock.ValidClusterID)),
+			want: []interface{}{map[string]interface{}{
+				"deployment_id":            mock.ValidClusterID,
+				"ref_id":                   "main-elasticsearch",
+				"logs_ref_id":              "",
+				"metrics_ref_id":           "",
+				"self":                     true,
+				"metrics":                  true,
+				"logs":                     false,
+				"legacy_monitoring_target": "monitoring-cluster-id",
+			}},
+		},
+		{
+			name: "ignores the legacy monitoring settings when the observability settings are set",
+			res: newResponse("monitoring-cluster-id", &models.DeploymentObservabilitySettings{
+				Metrics: &models.DeploymentMetricsSettings{
+					Destination: &models.AbsoluteRefID{
+						DeploymentID: ec.String("other-deployment-id"),
+						RefID:        ec.String("main-elasticsearch"),
+					},
+				},
+			}),
+			client: api.NewMock(),
+			want:   []interface{}{},
+		},
+		{
+			name:   "does nothing without legacy monitoring settings",
+			res:    newResponse("", nil),
+			client: api.NewMock(),
+			want:   []interface{}{},
+		},
+		{
+			name:   "doesn't look the cluster up when the legacy monitoring target hasn't changed",
+			prior:  legacyObservability,
+			res:    newResponse("monitoring-cluster-id", nil),
+			client: api.NewMock(),
+			want:   legacyObservability,
+		},
+		{
+			name:   "removes the observability settings once the legacy monitoring settings are removed",
+			prior:  legacyObservability,
+			res:    newResponse("", nil),
+			client: api.NewMock(),
+			want:   []interface{}{},
+		},
+		{
+			name: "leaves the observability settings untouched when the cluster can't be found",
+			res:  newResponse("monitoring-cluster-id", nil),
+			client: api.NewMock(mock.New200StructResponse(models.DeploymentsSearchResponse{
+				Deployments: []*models.DeploymentSearchResponse{},
+			})),
+			want: []interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State:  map[string]interface{}{},
+			})
+			if tt.prior != nil {
+				assert.NoError(t, d.Set("observability", tt.prior))
+			}
+			assert.NoError(t, flattenLegacyMonitoring(d, tt.res, tt.client))
+			assert.Equal(t, tt.want, d.Get("observability"))
+		})
+	}
+}
+
+func Test_suppressLegacyMonitoring(t *testing.T) {
+	legacyState := map[string]string{
+		"observability.#":                          "1",
+		"observability.0.deployment_id":            "monitoring-deployment-id",
+		"observability.0.ref_id":                   "main-elasticsearch",
+		"observability.0.metrics":                  "true",
+		"observability.0.logs":                     "false",
+		"observability.0.legacy_monitoring_target": "monitoring-cluster-id",
+	}
+	configured := map[string]interface{}{"observability": []interface{}{
+		map[string]interface{}{"deployment_id": "another-deployment-id"},
+	}}
+	tests := []struct {
+		name  string
+		state map[string]string
+		cfg   map[string]interface{}
+		want  bool
+	}{
+		{
+			name:  "suppresses the removal of the observability read from the legacy monitoring settings",
+			state: legacyState,
+			cfg:   map[string]interface{}{},
+		},
+		{
+			name:  "shows the changes of the observability read from the legacy monitoring settings",
+			state: legacyState,
+			cfg:   configured,
+			want:  true,
+		},
+		{
+			name: "shows the removal of the configured observability",
+			state: map[string]string{
+				"observability.#":               "1",
+				"observability.0.deployment_id": "monitoring-deployment-id",
+				"observability.0.metrics":       "true",
+				"observability.0.logs":          "true",
+			},
+			cfg:  map[string]interface{}{},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &schema.Resource{Schema: newSchema()}
+			// The raw configuration is set by Terraform when planning, which
+			// is used to tell whether the configuration sets "observability".
+			cfgAttrs := map[string]string{"observability.#": "0"}
+			if obs, ok := tt.cfg["observability"].([]interface{}); ok {
+				cfgAttrs["observability.#"] = "1"
+				cfgAttrs["observability.0.deployment_id"] = obs[0].(map[string]interface{})["deployment_id"].(string)
+			}
+			rawConfig, err := (&terraform.InstanceState{Attributes: cfgAttrs}).
+				AttrsAsObjectValue(r.CoreConfigSchema().ImpliedType())
+			assert.NoError(t, err)
+
+			state := &terraform.InstanceState{ID: "123", Attributes: tt.state, RawConfig: rawConfig}
+			diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(tt.cfg), nil)
+			assert.NoError(t, err)
+
+			var got bool
+			if diff != nil {
+				for k, attr := range diff.Attributes {
+					if strings.HasPrefix(k, "observability.") && attr.Old != attr.New {
+						got = true
+					}
+				}
+			}
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -0,0 +1,69 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+)
+
+// dumpPayloadEnv is the environment variable which enables writing the
+// deployment create and update request payloads to a temporary file, so these
+// can be attached to support tickets.
+const dumpPayloadEnv = "EC_DUMP_PAYLOAD"
+
+// dumpPayload writes the JSON payload of a deployment request to a temporary
+// file and logs its path when the EC_DUMP_PAYLOAD environment variable is set
+// to true. Any failures are only logged, since the dump is a debugging aid.
+func dumpPayload(operation string, payload interface{}) {
+	if enabled, _ := strconv.ParseBool(os.Getenv(dumpPayloadEnv)); !enabled {
+		return
+	}
+
+	path, err := writePayload("", operation, payload)
+	if err != nil {
+		log.Printf("[WARN] failed writing the deployment %s request payload: %s", operation, err)
+		return
+	}
+
+	log.Printf("[INFO] deployment %s request payload written to %s", operation, path)
+}
+
+// writePayload writes the JSON payload to a new "ec-deployment-<operation>-*.json"
+// file in dir, or in the default directory for temporary files when dir is
+// empty, returning the file path.
+func writePayload(dir, operation string, payload interface{}) (string, error) {
+	b, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(dir, "ec-deployment-"+operation+"-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return "", err
+	}
+
+	return f.Name(), f.Close()
+}
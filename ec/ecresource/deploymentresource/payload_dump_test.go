@@ -0,0 +1,49 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writePayload(t *testing.T) {
+	dir := t.TempDir()
+	req := &models.DeploymentCreateRequest{
+		Name: "my_deployment_name",
+		Metadata: &models.DeploymentCreateMetadata{
+			Tags: []*models.MetadataItem{},
+		},
+	}
+
+	path, err := writePayload(dir, "create", req)
+	assert.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.Regexp(t, `^ec-deployment-create-\d+\.json$`, filepath.Base(path))
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "my_deployment_name", "resources": null, "metadata": {"tags": []}}`, string(b))
+
+	_, err = writePayload(filepath.Join(dir, "missing"), "update", req)
+	assert.Error(t, err)
+}
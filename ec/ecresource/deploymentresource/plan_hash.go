@@ -0,0 +1,67 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// planHash returns a stable hash of the resolved deployment resources payload
+// of a create or update request. The payload is serialized to JSON, which
+// encodes the struct fields in their declaration order and sorts the map
+// keys, so equal payloads always produce the same hash.
+func planHash(resources interface{}) (string, error) {
+	b, err := json.Marshal(resources)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setPlanHash sets the "plan_hash" of the resolved deployment resources payload.
+func setPlanHash(d *schema.ResourceData, resources interface{}) error {
+	hash, err := planHash(resources)
+	if err != nil {
+		return err
+	}
+	return d.Set("plan_hash", hash)
+}
+
+// checkPlanHash marks the "plan_hash" as unknown when the diff contains any
+// change which results in a new plan, so the change shows in the plan.
+func checkPlanHash(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	for _, key := range d.GetChangedKeysPrefix("") {
+		if key == "plan_hash" || strings.HasPrefix(key, "tags") || !isDeploymentAttribute(key) {
+			continue
+		}
+		return d.SetNewComputed("plan_hash")
+	}
+	return nil
+}
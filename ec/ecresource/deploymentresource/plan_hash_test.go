@@ -0,0 +1,119 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_planHash(t *testing.T) {
+	newResources := func(size int32, userSettings map[string]interface{}) *models.DeploymentUpdateResources {
+		return &models.DeploymentUpdateResources{
+			Elasticsearch: []*models.ElasticsearchPayload{{
+				RefID:  ec.String("main-elasticsearch"),
+				Region: ec.String("us-east-1"),
+				Plan: &models.ElasticsearchClusterPlan{
+					Elasticsearch: &models.ElasticsearchConfiguration{
+						Version:          "7.10.1",
+						UserSettingsJSON: userSettings,
+					},
+					ClusterTopology: []*models.ElasticsearchClusterTopologyElement{{
+						ID:        "hot_content",
+						ZoneCount: 1,
+						Size: &models.TopologySize{
+							Resource: ec.String("memory"),
+							Value:    ec.Int32(size),
+						},
+					}},
+				},
+			}},
+		}
+	}
+
+	type args struct {
+		a interface{}
+		b interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "equal payloads have the same hash",
+			args: args{
+				a: newResources(4096, nil),
+				b: newResources(4096, nil),
+			},
+			want: true,
+		},
+		{
+			name: "map key ordering doesn't change the hash",
+			args: args{
+				a: newResources(4096, map[string]interface{}{
+					"a": "b", "c": map[string]interface{}{"d": "e", "f": "g"},
+				}),
+				b: newResources(4096, map[string]interface{}{
+					"c": map[string]interface{}{"f": "g", "d": "e"}, "a": "b",
+				}),
+			},
+			want: true,
+		},
+		{
+			name: "create and update payloads with the same resources have the same hash",
+			args: args{
+				a: &models.DeploymentCreateResources{
+					Elasticsearch: newResources(4096, nil).Elasticsearch,
+				},
+				b: newResources(4096, nil),
+			},
+			want: true,
+		},
+		{
+			name: "a size change changes the hash",
+			args: args{
+				a: newResources(4096, nil),
+				b: newResources(8192, nil),
+			},
+			want: false,
+		},
+		{
+			name: "a user settings change changes the hash",
+			args: args{
+				a: newResources(4096, map[string]interface{}{"a": "b"}),
+				b: newResources(4096, map[string]interface{}{"a": "c"}),
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := planHash(tt.args.a)
+			assert.NoError(t, err)
+			assert.Len(t, a, 64)
+
+			b, err := planHash(tt.args.b)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, a == b)
+		})
+	}
+}
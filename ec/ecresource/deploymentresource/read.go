@@ -20,6 +20,7 @@ package deploymentresource
 import (
 	"context"
 	"errors"
+	"log"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/apierror"
@@ -34,9 +35,12 @@ import (
 )
 
 // Read queries the remote deployment state and updates the local state.
-func readResource(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+func readResource(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*api.API)
 
+	// The plan history is only needed to obtain the creation time, which
+	// doesn't change once it's been read.
+	createdAt, _ := d.Get("created_at").(string)
 	res, err := deploymentapi.Get(deploymentapi.GetParams{
 		API: client, DeploymentID: d.Id(),
 		QueryParams: deputil.QueryParams{
@@ -44,10 +48,12 @@ func readResource(_ context.Context, d *schema.ResourceData, meta interface{}) d
 			ShowPlans:        true,
 			ShowMetadata:     true,
 			ShowPlanDefaults: true,
+			ShowPlanHistory:  createdAt == "",
 		},
 	})
 	if err != nil {
 		if deploymentNotFound(err) {
+			log.Printf("[WARN] deployment %s not found, removing it from the state", d.Id())
 			d.SetId("")
 			return nil
 		}
@@ -74,10 +80,34 @@ func readResource(_ context.Context, d *schema.ResourceData, meta interface{}) d
 		remotes = &models.RemoteResources{}
 	}
 
+	priorEs, _ := d.Get("elasticsearch").([]interface{})
+	priorFilters, _ := d.Get("traffic_filter").(*schema.Set)
 	if err := modelToState(d, res, *remotes); err != nil {
 		diags = append(diags, diag.FromErr(err)...)
 	}
 
+	if err := flattenTrustSelf(ctx, d, priorEs, client); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+
+	if err := flattenTrafficFilterDefaults(d, priorFilters, client); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+
+	if err := flattenLegacyMonitoring(d, res, client); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+
+	if err := d.Set("resource_ids", flattenResourceIDs(res.Resources)); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+
+	// The hardware migration is an action rather than a setting, so it's
+	// reset once the deployment is read after it's been applied.
+	if err := d.Set("migrate_to_latest_hardware", false); err != nil {
+		diags = append(diags, diag.FromErr(err)...)
+	}
+
 	return diags
 }
 
@@ -18,7 +18,12 @@
 package deploymentresource
 
 import (
+	"bytes"
 	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
 	"testing"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
@@ -148,6 +153,28 @@ func Test_readResource(t *testing.T) {
 	}
 }
 
+func Test_readResourceNotFoundWarning(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	d := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		State:  newSampleLegacyDeployment(),
+		Schema: newSchema(),
+	})
+	client := api.NewMock(mock.NewErrorResponse(404, mock.APIError{
+		Code: "deployments.deployment_not_found", Message: "deployment not found",
+	}))
+
+	diags := readResource(context.Background(), d, client)
+	assert.Nil(t, diags)
+	assert.Empty(t, d.Id())
+	assert.Contains(t, buf.String(),
+		"[WARN] deployment "+mock.ValidClusterID+" not found, removing it from the state",
+	)
+}
+
 func Test_deploymentNotFound(t *testing.T) {
 	type args struct {
 		err error
@@ -195,3 +222,62 @@ func Test_deploymentNotFound(t *testing.T) {
 		})
 	}
 }
+
+func Test_readResourcePlanHistory(t *testing.T) {
+	newQuery := func(history string) url.Values {
+		return url.Values{
+			"convert_legacy_plans": []string{"false"},
+			"show_metadata":        []string{"true"},
+			"show_plan_defaults":   []string{"true"},
+			"show_plan_history":    []string{history},
+			"show_plan_logs":       []string{"false"},
+			"show_plans":           []string{"true"},
+			"show_settings":        []string{"true"},
+			"show_system_alerts":   []string{"5"},
+		}
+	}
+	tests := []struct {
+		name      string
+		createdAt string
+		query     url.Values
+	}{
+		{
+			name:  "obtains the plan history when the creation time isn't known",
+			query: newQuery("true"),
+		},
+		{
+			name:      "doesn't obtain the plan history when the creation time is known",
+			createdAt: "2020-10-13T10:34:03Z",
+			query:     newQuery("false"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newSampleLegacyDeployment()
+			if tt.createdAt != "" {
+				state["created_at"] = tt.createdAt
+			}
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				State:  state,
+				Schema: newSchema(),
+			})
+			client := api.NewMock(mock.Response{
+				Response: http.Response{
+					StatusCode: 404,
+					Body:       mock.NewStringBody(`{}`),
+				},
+				Assert: &mock.RequestAssertion{
+					Host:   api.DefaultMockHost,
+					Header: api.DefaultReadMockHeaders,
+					Method: "GET",
+					Path:   "/api/v1/deployments/" + mock.ValidClusterID,
+					Query:  tt.query,
+				},
+			})
+
+			assert.Nil(t, readResource(context.Background(), d, client))
+			assert.Empty(t, d.Id())
+		})
+	}
+}
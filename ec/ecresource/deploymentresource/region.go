@@ -0,0 +1,61 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// RegionSettings holds the provider settings which default the deployment
+// region.
+type RegionSettings struct {
+	// Region is used as the deployment region when the deployment doesn't
+	// set any. No default is used when unset.
+	Region string
+}
+
+// checkRegion returns a CustomizeDiff function which defaults the region of
+// new deployments which don't set any to the provider settings region,
+// returning an error when neither sets it.
+func checkRegion(settings *RegionSettings) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		if d.Id() != "" {
+			return nil
+		}
+
+		if region := d.Get("region").(string); region != "" {
+			return nil
+		}
+
+		// The region may be set from a value which isn't known yet.
+		if config := d.GetRawConfig(); !config.IsNull() && config.IsKnown() {
+			if !config.GetAttr("region").IsNull() {
+				return nil
+			}
+		}
+
+		if settings == nil || settings.Region == "" {
+			return errors.New(`"region" must be set, either in the deployment or in the provider configuration`)
+		}
+
+		return d.SetNew("region", settings.Region)
+	}
+}
@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_checkRegion(t *testing.T) {
+	newConfig := func(region string) map[string]interface{} {
+		config := map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"version":                "7.12.0",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+		}
+		if region != "" {
+			config["region"] = region
+		}
+		return config
+	}
+	existing := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		State:  newConfig("eu-west-1"),
+		Schema: newSchema(),
+	}).State()
+	tests := []struct {
+		name     string
+		settings *RegionSettings
+		state    *terraform.InstanceState
+		config   map[string]interface{}
+		want     string
+		err      error
+	}{
+		{
+			name:     "keeps the deployment region",
+			settings: &RegionSettings{Region: "us-east-1"},
+			config:   newConfig("eu-west-1"),
+			want:     "eu-west-1",
+		},
+		{
+			name:     "defaults the region to the provider region",
+			settings: &RegionSettings{Region: "us-east-1"},
+			config:   newConfig(""),
+			want:     "us-east-1",
+		},
+		{
+			name:     "fails without any region",
+			settings: &RegionSettings{},
+			config:   newConfig(""),
+			err:      errors.New(`"region" must be set, either in the deployment or in the provider configuration`),
+		},
+		{
+			name:   "fails without any region or provider settings",
+			config: newConfig(""),
+			err:    errors.New(`"region" must be set, either in the deployment or in the provider configuration`),
+		},
+		{
+			name:     "keeps the region of existing deployments",
+			settings: &RegionSettings{Region: "us-east-1"},
+			state:    existing,
+			config:   newConfig(""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := schema.Resource{Schema: newSchema(), CustomizeDiff: checkRegion(tt.settings)}
+			diff, err := res.Diff(
+				context.Background(), tt.state, terraform.NewResourceConfigRaw(tt.config), nil,
+			)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if tt.want == "" {
+				assert.Nil(t, diff.Attributes["region"])
+				return
+			}
+			if assert.NotNil(t, diff.Attributes["region"]) {
+				assert.Equal(t, tt.want, diff.Attributes["region"].New)
+			}
+		})
+	}
+}
@@ -20,19 +20,37 @@ package deploymentresource
 import (
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
-// Resource returns the ec_deployment resource schema.
-func Resource() *schema.Resource {
+// Resource returns the ec_deployment resource schema. The version, tracking
+// and region settings are read when the deployment version is validated, when
+// the pending plans are tracked and when the deployment region is defaulted,
+// so these can be set once the provider has been configured.
+func Resource(versions *VersionSettings, tracking *TrackingSettings, regions *RegionSettings) *schema.Resource {
 	return &schema.Resource{
-		CreateContext: createResource,
+		CreateContext: withDeploymentContext(withPollInterval(tracking, createResource)),
 		ReadContext:   readResource,
-		UpdateContext: updateResource,
-		DeleteContext: deleteResource,
+		UpdateContext: withDeploymentContext(withPollInterval(tracking, updateResource)),
+		DeleteContext: withDeploymentContext(withPollInterval(tracking, deleteResource)),
 
 		Schema: newSchema(),
 
+		CustomizeDiff: customdiff.All(
+			checkRefIDs,
+			checkRegion(regions),
+			checkVersion(versions),
+			checkVersionOverrides(versions),
+			checkSecuritySettings,
+			checkApmIntegrationsServer,
+			checkUserSettings(defaultUserSettingsValidators...),
+			checkWaitFor,
+			checkTrafficFilterExclusions,
+			checkPlanHash,
+			checkEssNodeTypes,
+		),
+
 		Description: "Elastic Cloud Deployment resource",
 		Importer: &schema.ResourceImporter{
 			StateContext: importFunc,
@@ -0,0 +1,481 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+// roundTripSeed is the seed of the randomly generated deployments, fixed so
+// that failures can be reproduced.
+const roundTripSeed = 2069
+
+// roundTripIterations is the number of randomly generated deployments which
+// are round-tripped on top of the seed corpus.
+const roundTripIterations = 100
+
+// Test_roundTrip builds the create payload of each deployment, flattens the
+// deployment response which the API would return for it and asserts that the
+// configured attributes don't differ from the flattened ones, catching any
+// asymmetry between the expanders and the flatteners.
+func Test_roundTrip(t *testing.T) {
+	ioOptimizedTpl := func() io.ReadCloser {
+		return fileAsResponseBody(t, "testdata/template-aws-io-optimized-v2.json")
+	}
+
+	corpus := newRoundTripCorpus()
+	r := rand.New(rand.NewSource(roundTripSeed))
+	for i := 0; i < roundTripIterations; i++ {
+		corpus = append(corpus, roundTripCase{
+			name:  fmt.Sprintf("random deployment %d (seed %d)", i, roundTripSeed),
+			state: newRandomDeployment(r),
+		})
+	}
+
+	for _, tt := range corpus {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				State:  tt.state,
+				Schema: newSchema(),
+			})
+			want := d.State().Attributes
+
+			req, err := CreateResourceToModel(d, api.NewMock(mock.New200Response(ioOptimizedTpl())))
+			if err != nil {
+				t.Fatalf("failed building the create payload: %v", err)
+			}
+
+			if err := modelToState(d, newRoundTripResponse(req), models.RemoteResources{}); err != nil {
+				t.Fatalf("failed flattening the deployment: %v", err)
+			}
+
+			if diffs := roundTripDiff(want, d.State().Attributes); len(diffs) > 0 {
+				t.Errorf("the state doesn't round-trip:\n%s", strings.Join(diffs, "\n"))
+			}
+		})
+	}
+}
+
+type roundTripCase struct {
+	name  string
+	state map[string]interface{}
+}
+
+// newRoundTripCorpus returns the seed corpus of representative deployments.
+func newRoundTripCorpus() []roundTripCase {
+	return []roundTripCase{
+		{
+			name:  "minimal deployment",
+			state: newRoundTripDeployment(newRoundTripElasticsearch(roundTripHotTier("4g", 2))),
+		},
+		{
+			name: "elasticsearch and kibana user settings",
+			state: newRoundTripDeployment(
+				withRoundTripConfig(newRoundTripElasticsearch(roundTripHotTier("8g", 2)), map[string]interface{}{
+					"user_settings_yaml":          "action.auto_create_index: true",
+					"user_settings_override_yaml": "indices.query.bool.max_clause_count: 2048",
+					"user_settings_json":          `{"action.destructive_requires_name":true}`,
+					"user_settings_override_json": `{"xpack.security.loginAssistanceMessage":"hello"}`,
+				}),
+				withRoundTripKibana(map[string]interface{}{
+					"user_settings_yaml": "csp.warnLegacyBrowsers: true",
+				}),
+			),
+		},
+		{
+			name: "hot and warm tiers",
+			state: newRoundTripDeployment(newRoundTripElasticsearch(
+				roundTripHotTier("2g", 3), roundTripTier("warm", "4g", 2),
+			)),
+		},
+		{
+			name: "account and external trusts",
+			state: newRoundTripDeployment(withRoundTripTrusts(
+				newRoundTripElasticsearch(roundTripHotTier("4g", 2)),
+				[]interface{}{
+					map[string]interface{}{"account_id": "111111", "trust_all": true},
+					map[string]interface{}{
+						"account_id": "222222", "trust_all": false,
+						"trust_allowlist": []interface{}{"abc", "def"},
+					},
+				},
+				[]interface{}{map[string]interface{}{
+					"relationship_id": "external-relationship", "trust_all": true,
+				}},
+			)),
+		},
+		{
+			name: "bundle and plugin extensions",
+			state: newRoundTripDeployment(withRoundTripExtensions(
+				newRoundTripElasticsearch(roundTripHotTier("4g", 2)),
+				roundTripExtension("my-bundle", "bundle", "7.*", "repo://1234"),
+				roundTripExtension("my-plugin", "plugin", "7.10.1", "repo://5678"),
+			)),
+		},
+		{
+			name: "observability shipped to another deployment",
+			state: withRoundTripObservability(
+				newRoundTripDeployment(newRoundTripElasticsearch(roundTripHotTier("4g", 2))),
+				"a9e1a1e6f0c64bd2a2cef4e5dd7a6f09", true, true,
+			),
+		},
+		{
+			name: "everything at once",
+			state: withRoundTripObservability(
+				newRoundTripDeployment(
+					withRoundTripExtensions(
+						withRoundTripTrusts(
+							withRoundTripConfig(
+								newRoundTripElasticsearch(roundTripHotTier("8g", 3), roundTripTier("warm", "8g", 2)),
+								map[string]interface{}{"user_settings_yaml": "action.auto_create_index: false"},
+							),
+							[]interface{}{map[string]interface{}{"account_id": "333333", "trust_all": true}},
+							nil,
+						),
+						roundTripExtension("my-bundle", "bundle", "*", "repo://4321"),
+					),
+					withRoundTripKibana(nil),
+				),
+				"a9e1a1e6f0c64bd2a2cef4e5dd7a6f09", false, true,
+			),
+		},
+	}
+}
+
+// newRandomDeployment returns a random, valid combination of the deployment
+// settings which are part of the seed corpus.
+func newRandomDeployment(r *rand.Rand) map[string]interface{} {
+	sizes := []string{"1g", "2g", "4g", "8g", "15g"}
+	tiers := []interface{}{roundTripHotTier(sizes[r.Intn(len(sizes))], 1+r.Intn(3))}
+	if r.Intn(2) == 0 {
+		tiers = append(tiers, roundTripTier("warm", sizes[1+r.Intn(len(sizes)-1)], 1+r.Intn(3)))
+	}
+	es := newRoundTripElasticsearch(tiers...)
+
+	esSettings := map[string][]string{
+		"user_settings_yaml":          {"action.auto_create_index: true", "script.painless.regex.enabled: false"},
+		"user_settings_override_yaml": {"indices.query.bool.max_clause_count: 4096"},
+		"user_settings_json":          {`{"action.destructive_requires_name":true}`},
+		"user_settings_override_json": {`{"xpack.security.loginAssistanceMessage":"hi"}`},
+	}
+	if config := randomRoundTripSettings(r, esSettings); len(config) > 0 {
+		es = withRoundTripConfig(es, config)
+	}
+
+	if r.Intn(2) == 0 {
+		var accounts []interface{}
+		for i, n := 0, 1+r.Intn(2); i < n; i++ {
+			account := map[string]interface{}{
+				"account_id": strconv.Itoa(100000 + r.Intn(900000)),
+				"trust_all":  r.Intn(2) == 0,
+			}
+			if !account["trust_all"].(bool) {
+				account["trust_allowlist"] = []interface{}{fmt.Sprintf("cluster-%d", r.Intn(100))}
+			}
+			accounts = append(accounts, account)
+		}
+		var external []interface{}
+		if r.Intn(2) == 0 {
+			external = append(external, map[string]interface{}{
+				"relationship_id": fmt.Sprintf("relationship-%d", r.Intn(100)),
+				"trust_all":       true,
+			})
+		}
+		es = withRoundTripTrusts(es, accounts, external)
+	}
+
+	if r.Intn(2) == 0 {
+		var extensions []interface{}
+		if r.Intn(2) == 0 {
+			extensions = append(extensions, roundTripExtension(
+				fmt.Sprintf("bundle-%d", r.Intn(100)), "bundle", "7.*", fmt.Sprintf("repo://%d", r.Intn(10000)),
+			))
+		}
+		extensions = append(extensions, roundTripExtension(
+			fmt.Sprintf("plugin-%d", r.Intn(100)), "plugin", "7.10.1", fmt.Sprintf("repo://%d", r.Intn(10000)),
+		))
+		es = withRoundTripExtensions(es, extensions...)
+	}
+
+	var opts []func(map[string]interface{})
+	if r.Intn(2) == 0 {
+		kibanaSettings := map[string][]string{
+			"user_settings_yaml":          {"csp.warnLegacyBrowsers: true"},
+			"user_settings_override_yaml": {"xpack.reporting.enabled: false"},
+		}
+		opts = append(opts, withRoundTripKibana(randomRoundTripSettings(r, kibanaSettings)))
+	}
+
+	deployment := newRoundTripDeployment(es, opts...)
+	if r.Intn(2) == 0 {
+		logs := r.Intn(2) == 0
+		deployment = withRoundTripObservability(
+			deployment, "a9e1a1e6f0c64bd2a2cef4e5dd7a6f09", logs, !logs || r.Intn(2) == 0,
+		)
+	}
+
+	return deployment
+}
+
+// randomRoundTripSettings picks a random subset of the settings, choosing one
+// of the values of each of the picked settings.
+func randomRoundTripSettings(r *rand.Rand, settings map[string][]string) map[string]interface{} {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	config := make(map[string]interface{})
+	for _, k := range keys {
+		if r.Intn(2) == 0 {
+			config[k] = settings[k][r.Intn(len(settings[k]))]
+		}
+	}
+	return config
+}
+
+func newRoundTripDeployment(es map[string]interface{}, opts ...func(map[string]interface{})) map[string]interface{} {
+	deployment := map[string]interface{}{
+		"name":                   "my_deployment_name",
+		"alias":                  "my-deployment",
+		"deployment_template_id": "aws-io-optimized-v2",
+		"region":                 "us-east-1",
+		"version":                "7.10.1",
+		"elasticsearch":          []interface{}{es},
+	}
+	for _, opt := range opts {
+		opt(deployment)
+	}
+	return deployment
+}
+
+func newRoundTripElasticsearch(tiers ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"ref_id":   "main-elasticsearch",
+		"region":   "us-east-1",
+		"topology": tiers,
+	}
+}
+
+func roundTripHotTier(size string, zones int) map[string]interface{} {
+	return roundTripTier("hot_content", size, zones)
+}
+
+func roundTripTier(id, size string, zones int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            id,
+		"size":          size,
+		"size_resource": "memory",
+		"zone_count":    zones,
+	}
+}
+
+func roundTripExtension(name, kind, version, url string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    name,
+		"type":    kind,
+		"version": version,
+		"url":     url,
+	}
+}
+
+func withRoundTripConfig(es map[string]interface{}, config map[string]interface{}) map[string]interface{} {
+	es["config"] = []interface{}{config}
+	return es
+}
+
+func withRoundTripTrusts(es map[string]interface{}, accounts, external []interface{}) map[string]interface{} {
+	if len(accounts) > 0 {
+		es["trust_account"] = accounts
+	}
+	if len(external) > 0 {
+		es["trust_external"] = external
+	}
+	return es
+}
+
+func withRoundTripExtensions(es map[string]interface{}, extensions ...interface{}) map[string]interface{} {
+	es["extension"] = extensions
+	return es
+}
+
+func withRoundTripKibana(config map[string]interface{}) func(map[string]interface{}) {
+	return func(deployment map[string]interface{}) {
+		kibana := map[string]interface{}{
+			"elasticsearch_cluster_ref_id": "main-elasticsearch",
+			"ref_id":                       "main-kibana",
+			"region":                       "us-east-1",
+			"topology": []interface{}{map[string]interface{}{
+				"size":          "1g",
+				"size_resource": "memory",
+				"zone_count":    1,
+			}},
+		}
+		if len(config) > 0 {
+			kibana["config"] = []interface{}{config}
+		}
+		deployment["kibana"] = []interface{}{kibana}
+	}
+}
+
+func withRoundTripObservability(deployment map[string]interface{}, id string, logs, metrics bool) map[string]interface{} {
+	deployment["observability"] = []interface{}{map[string]interface{}{
+		"deployment_id": id,
+		"ref_id":        "main-elasticsearch",
+		"logs":          logs,
+		"metrics":       metrics,
+	}}
+	return deployment
+}
+
+// newRoundTripResponse synthesizes the deployment response which the API
+// returns once the deployment create request has been applied.
+func newRoundTripResponse(req *models.DeploymentCreateRequest) *models.DeploymentGetResponse {
+	res := models.DeploymentGetResponse{
+		ID:        ec.String(mock.ValidClusterID),
+		Name:      ec.String(req.Name),
+		Alias:     req.Alias,
+		Resources: &models.DeploymentResources{},
+		Settings:  &models.DeploymentSettings{},
+	}
+
+	if req.Metadata != nil {
+		res.Metadata = &models.DeploymentMetadata{Tags: req.Metadata.Tags}
+	}
+
+	if req.Settings != nil {
+		res.Settings.Observability = req.Settings.Observability
+		res.Settings.TrafficFilterSettings = req.Settings.TrafficFilterSettings
+	}
+
+	for _, es := range req.Resources.Elasticsearch {
+		res.Resources.Elasticsearch = append(res.Resources.Elasticsearch, &models.ElasticsearchResourceInfo{
+			ID:     ec.String(mock.ValidClusterID),
+			RefID:  es.RefID,
+			Region: es.Region,
+			Info: &models.ElasticsearchClusterInfo{
+				ClusterID: ec.String(mock.ValidClusterID),
+				Status:    ec.String("started"),
+				Settings:  es.Settings,
+				PlanInfo: &models.ElasticsearchClusterPlansInfo{
+					Current: &models.ElasticsearchClusterPlanInfo{Plan: es.Plan},
+				},
+			},
+		})
+	}
+
+	for _, kibana := range req.Resources.Kibana {
+		res.Resources.Kibana = append(res.Resources.Kibana, &models.KibanaResourceInfo{
+			ID:                        ec.String(mock.ValidClusterID),
+			RefID:                     kibana.RefID,
+			Region:                    kibana.Region,
+			ElasticsearchClusterRefID: kibana.ElasticsearchClusterRefID,
+			Info: &models.KibanaClusterInfo{
+				ClusterID: ec.String(mock.ValidClusterID),
+				Status:    ec.String("started"),
+				PlanInfo: &models.KibanaClusterPlansInfo{
+					Current: &models.KibanaClusterPlanInfo{Plan: kibana.Plan},
+				},
+			},
+		})
+	}
+
+	return &res
+}
+
+// roundTripDiff returns the differences between the configured attributes
+// and the flattened ones. Computed attributes which haven't been configured
+// are skipped, since the flatteners populate these, and so are the empty
+// lists and maps which aren't part of the configuration.
+func roundTripDiff(want, got map[string]string) []string {
+	var diffs []string
+	for k, v := range want {
+		if isZeroAttribute(v) && isComputedAttribute(k, false) {
+			continue
+		}
+		if g, ok := got[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("- %s: %q", k, v))
+		} else if g != v {
+			diffs = append(diffs, fmt.Sprintf("~ %s: %q => %q", k, v, g))
+		}
+	}
+
+	for k, v := range got {
+		if _, ok := want[k]; ok || isComputedAttribute(k, true) {
+			continue
+		}
+		if (strings.HasSuffix(k, ".#") || strings.HasSuffix(k, ".%")) && v == "0" {
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("+ %s: %q", k, v))
+	}
+
+	sort.Strings(diffs)
+	return diffs
+}
+
+func isZeroAttribute(value string) bool {
+	switch value {
+	case "", "0", "false":
+		return true
+	}
+	return false
+}
+
+// isComputedAttribute returns true when the schema of the flatmap attribute
+// key is computed. When nested is set, the attributes of computed blocks are
+// considered computed as well.
+func isComputedAttribute(key string, nested bool) bool {
+	var s *schema.Schema
+	fields := newSchema()
+	for _, part := range strings.Split(key, ".") {
+		if part == "#" || part == "%" {
+			break
+		}
+		if _, err := strconv.Atoi(part); err == nil {
+			continue
+		}
+		var ok bool
+		if s, ok = fields[part]; !ok {
+			return false
+		}
+		if nested && s.Computed {
+			return true
+		}
+		if res, ok := s.Elem.(*schema.Resource); ok {
+			fields = res.Schema
+		}
+	}
+	return s != nil && s.Computed
+}
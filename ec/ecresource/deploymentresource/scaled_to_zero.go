@@ -0,0 +1,76 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import "github.com/elastic/cloud-sdk-go/pkg/models"
+
+// isApmPlanScaledToZero returns true when all the plan's topology elements
+// have been explicitly sized to zero (e.g. size = "0g").
+func isApmPlanScaledToZero(plan *models.ApmPlan) bool {
+	if plan == nil || len(plan.ClusterTopology) == 0 {
+		return false
+	}
+	for _, t := range plan.ClusterTopology {
+		if t.Size == nil || t.Size.Value == nil || *t.Size.Value != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isIntegrationsServerPlanScaledToZero returns true when all the plan's
+// topology elements have been explicitly sized to zero (e.g. size = "0g").
+func isIntegrationsServerPlanScaledToZero(plan *models.IntegrationsServerPlan) bool {
+	if plan == nil || len(plan.ClusterTopology) == 0 {
+		return false
+	}
+	for _, t := range plan.ClusterTopology {
+		if t.Size == nil || t.Size.Value == nil || *t.Size.Value != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEssPlanScaledToZero returns true when all the plan's topology elements
+// have been explicitly sized to zero (e.g. size = "0g").
+func isEssPlanScaledToZero(plan *models.EnterpriseSearchPlan) bool {
+	if plan == nil || len(plan.ClusterTopology) == 0 {
+		return false
+	}
+	for _, t := range plan.ClusterTopology {
+		if t.Size == nil || t.Size.Value == nil || *t.Size.Value != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isKibanaPlanScaledToZero returns true when all the plan's topology elements
+// have been explicitly sized to zero (e.g. size = "0g").
+func isKibanaPlanScaledToZero(plan *models.KibanaClusterPlan) bool {
+	if plan == nil || len(plan.ClusterTopology) == 0 {
+		return false
+	}
+	for _, t := range plan.ClusterTopology {
+		if t.Size == nil || t.Size.Value == nil || *t.Size.Value != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -18,7 +18,19 @@
 package deploymentresource
 
 import (
+	"errors"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
 
 const (
@@ -30,6 +42,12 @@ const (
 	minimumZoneCount = 1
 )
 
+// NewSchema returns the schema for an "ec_deployment" resource, which data
+// sources can derive their schema from.
+func NewSchema() map[string]*schema.Schema {
+	return newSchema()
+}
+
 // newSchema returns the schema for an "ec_deployment" resource.
 func newSchema() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
@@ -46,8 +64,9 @@ func newSchema() map[string]*schema.Schema {
 		},
 		"region": {
 			Type:        schema.TypeString,
-			Description: `Required ESS region where to create the deployment, for ECE environments "ece-region" must be set`,
-			Required:    true,
+			Description: `ESS region where to create the deployment, for ECE environments "ece-region" must be set. Defaults to the provider "region"`,
+			Optional:    true,
+			Computed:    true,
 			ForceNew:    true,
 		},
 		"deployment_template_id": {
@@ -60,6 +79,54 @@ func newSchema() map[string]*schema.Schema {
 			Description: "Optional name for the deployment",
 			Optional:    true,
 		},
+		"autoscale": {
+			Type:          schema.TypeBool,
+			Description:   "Optionally enable or disable autoscaling for all the Elasticsearch resources. Defaults to the setting coming from the deployment template.",
+			Optional:      true,
+			ConflictsWith: []string{"elasticsearch.0.autoscale"},
+		},
+		"autoscale_size_as_min": {
+			Type:        schema.TypeBool,
+			Description: "Optionally use the current size of each autoscalable Elasticsearch topology element as its autoscaling minimum size when autoscaling is enabled on an existing deployment. Explicit autoscaling minimum sizes take precedence.",
+			Optional:    true,
+			Default:     false,
+		},
+		"verify_docker_images": {
+			Type:        schema.TypeBool,
+			Description: "Optionally verify that the docker images set in the resources config blocks exist in their registry, warning when they can't be found. Only images with an explicit registry are verified.",
+			Optional:    true,
+			Default:     false,
+		},
+		"migrate_to_latest_hardware": {
+			Type:        schema.TypeBool,
+			Description: "Optionally migrate all the topology elements to the current instance configurations of the deployment template when set to true on an update. It's reset to false once the deployment is read after the migration.",
+			Optional:    true,
+			Default:     false,
+		},
+		"poll_interval": {
+			Type:         schema.TypeString,
+			Description:  `Optional interval between the API calls which track the pending deployment changes, such as "10s". Overrides the provider "poll_interval"`,
+			Optional:     true,
+			ValidateFunc: ValidatePollInterval,
+		},
+		"topology_aliases": {
+			Type:        schema.TypeMap,
+			Description: `Optional map of the Elasticsearch topology IDs renamed by a deployment template, such as "hot_content" to "data_hot", to their new IDs. Used on a deployment_template_id change to keep the size of the renamed tiers, and merged with the built-in aliases`,
+			Optional:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"wait_for": {
+			Type:        schema.TypeSet,
+			Description: `Optional list of the resources, such as "elasticsearch", which the deployment creation waits for. The creation finishes once these have no pending changes and are healthy, instead of waiting for all of the resources`,
+			Optional:    true,
+			MinItems:    1,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice(waitForResourceKinds, false),
+			},
+		},
 		"request_id": {
 			Type:        schema.TypeString,
 			Description: "Optional request_id to set on the create operation, only use when previous create attempts return with an error and a request_id is returned as part of the error",
@@ -79,6 +146,44 @@ func newSchema() map[string]*schema.Schema {
 			Sensitive:   true,
 		},
 
+		// Computed timestamps
+		"created_at": {
+			Type:        schema.TypeString,
+			Description: "Computed time the deployment was created, formatted as RFC3339",
+			Computed:    true,
+		},
+		"last_modified": {
+			Type:        schema.TypeString,
+			Description: "Computed time the deployment was last modified, formatted as RFC3339",
+			Computed:    true,
+		},
+		"resource_ids": {
+			Type:        schema.TypeMap,
+			Description: "Computed map of the deployment resource IDs keyed by their ref_id",
+			Computed:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"plan_hash": {
+			Type:        schema.TypeString,
+			Description: "Computed hash of the resolved deployment resources payload of the last applied plan, which changes whenever a change triggers a new plan",
+			Computed:    true,
+		},
+		"system_tags": {
+			Type:        schema.TypeMap,
+			Description: "Computed map of the tags injected by Elastic Cloud, which are kept out of \"tags\" and kept on update",
+			Computed:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"trust_self_account_id": {
+			Type:        schema.TypeString,
+			Description: "Computed account ID of the organization which the Elasticsearch resources with \"trust_self\" set trust",
+			Computed:    true,
+		},
+
 		// APM secret_token
 		"apm_secret_token": {
 			Type:      schema.TypeString,
@@ -138,18 +243,37 @@ func newSchema() map[string]*schema.Schema {
 				Type:     schema.TypeString,
 			},
 		},
-		"observability": {
-			Type:        schema.TypeList,
-			Description: "Optional observability settings. Ship logs and metrics to a dedicated deployment.",
+		"traffic_filter_exclude": {
+			Description: "Optional list of traffic filters which are included by default in the region but must not be applied to this deployment.",
+			Type:        schema.TypeSet,
+			Set:         schema.HashString,
 			Optional:    true,
-			MaxItems:    1,
-			Elem:        newObservabilitySettings(),
+			MinItems:    1,
+			Elem: &schema.Schema{
+				MinItems: 1,
+				Type:     schema.TypeString,
+			},
+		},
+		"traffic_filter_include_default": {
+			Description: "Optionally set to false to remove the association of the traffic filters which are included by default in the region, unless these are part of \"traffic_filter\", the same way as \"traffic_filter_exclude\". Defaults to true.",
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+		},
+		"observability": {
+			Type:             schema.TypeList,
+			Description:      "Optional observability settings. Ship logs and metrics to a dedicated deployment.",
+			Optional:         true,
+			MaxItems:         1,
+			Elem:             newObservabilitySettings(),
+			DiffSuppressFunc: suppressLegacyMonitoring,
 		},
 
 		"tags": {
-			Description: "Optional map of deployment tags",
-			Type:        schema.TypeMap,
-			Optional:    true,
+			Description:  "Optional map of deployment tags",
+			Type:         schema.TypeMap,
+			Optional:     true,
+			ValidateFunc: validateTagKeys,
 			Elem: &schema.Schema{
 				Type: schema.TypeString,
 			},
@@ -162,13 +286,33 @@ func newObservabilitySettings() *schema.Resource {
 		Schema: map[string]*schema.Schema{
 			"deployment_id": {
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
+				Optional: true,
+			},
+			"self": {
+				Type:          schema.TypeBool,
+				Description:   "Ship the logs and metrics to the deployment itself. Conflicts with deployment_id.",
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"observability.0.deployment_id"},
 			},
 			"ref_id": {
 				Type:     schema.TypeString,
 				Computed: true,
 				Optional: true,
 			},
+			"logs_ref_id": {
+				Type:        schema.TypeString,
+				Description: `Optionally ship the logs to a different Elasticsearch resource of the destination deployment than "ref_id".`,
+				Computed:    true,
+				Optional:    true,
+			},
+			"metrics_ref_id": {
+				Type:        schema.TypeString,
+				Description: `Optionally ship the metrics to a different Elasticsearch resource of the destination deployment than "ref_id".`,
+				Computed:    true,
+				Optional:    true,
+			},
 			"logs": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -179,6 +323,11 @@ func newObservabilitySettings() *schema.Resource {
 				Optional: true,
 				Default:  true,
 			},
+			"legacy_monitoring_target": {
+				Type:        schema.TypeString,
+				Description: "ID of the Elasticsearch cluster which the legacy monitoring settings ship the metrics to, set when the observability settings are read from them.",
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -190,3 +339,138 @@ func newObservabilitySettings() *schema.Resource {
 func suppressMissingOptionalConfigurationBlock(k, old, new string, d *schema.ResourceData) bool {
 	return old == "1" && new == "0"
 }
+
+// suppressEquivalentYaml suppresses the diff of YAML attributes which are
+// semantically equal, such as the ones which only differ in key ordering,
+// whitespace or comments. Multi-document YAML is compared document by document.
+// When either side can't be parsed, the diff isn't suppressed.
+func suppressEquivalentYaml(k, old, new string, d *schema.ResourceData) bool {
+	if old == new {
+		return true
+	}
+
+	oldDocs, err := parseYamlDocuments(old)
+	if err != nil {
+		return false
+	}
+
+	newDocs, err := parseYamlDocuments(new)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(oldDocs, newDocs)
+}
+
+// suppressEquivalentSize suppresses the diff of size attributes which
+// represent the same amount, such as "116g", "116G" and "116gb", or "2t" and
+// "2048g". The sizes are read back from the API in their "<size>t" or
+// "<size>g" notation, so any other notation in the configuration would
+// otherwise show a perpetual diff.
+// When either side can't be parsed, the diff isn't suppressed.
+func suppressEquivalentSize(k, old, new string, d *schema.ResourceData) bool {
+	if old == new {
+		return true
+	}
+
+	oldSize, err := util.ParseSize(old)
+	if err != nil {
+		return false
+	}
+
+	newSize, err := util.ParseSize(new)
+	if err != nil {
+		return false
+	}
+
+	return oldSize == newSize
+}
+
+// parseYamlDocuments parses all the YAML documents in the input. Empty
+// documents are left out, since those don't hold any settings.
+func parseYamlDocuments(in string) ([]interface{}, error) {
+	var docs []interface{}
+	var dec = yaml.NewDecoder(strings.NewReader(in))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, normalizeYamlScalars(doc))
+		}
+	}
+}
+
+// normalizeYamlScalars converts the numeric and boolean scalars in the parsed
+// YAML into typed values, whether they're quoted or not, since the settings
+// are parsed as strings by the stack and the API may not preserve the quoting
+// of such values, such as "30" and 30, or "true" and true. Numbers are
+// compared by their value, so "1.0" and 1.0 are equal.
+func normalizeYamlScalars(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			v[key] = normalizeYamlScalars(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeYamlScalars(value)
+		}
+		return v
+	case int:
+		return parseYamlNumber(strconv.Itoa(v))
+	case int64:
+		return parseYamlNumber(strconv.FormatInt(v, 10))
+	case uint64:
+		return parseYamlNumber(strconv.FormatUint(v, 10))
+	case float64:
+		return parseYamlNumber(strconv.FormatFloat(v, 'g', -1, 64))
+	case string:
+		if b, ok := parseYamlBool(v); ok {
+			return b
+		}
+		if n := parseYamlNumber(v); n != nil {
+			return n
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// yamlNumber is the exact value of a numeric YAML scalar, as a reduced
+// fraction, so numbers are compared by value regardless of their notation.
+type yamlNumber string
+
+// decimalNumber matches the decimal numbers, with an optional exponent.
+var decimalNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// parseYamlNumber returns the yamlNumber of a decimal number, or nil when the
+// input isn't one.
+func parseYamlNumber(in string) interface{} {
+	if !decimalNumber.MatchString(in) {
+		return nil
+	}
+
+	r, ok := new(big.Rat).SetString(in)
+	if !ok {
+		return nil
+	}
+	return yamlNumber(r.RatString())
+}
+
+// parseYamlBool parses the "true" and "false" booleans in any case.
+func parseYamlBool(in string) (bool, bool) {
+	switch strings.ToLower(in) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
@@ -25,19 +25,25 @@ func newApmResource() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"elasticsearch_cluster_ref_id": {
-				Type:     schema.TypeString,
-				Default:  "main-elasticsearch",
-				Optional: true,
+				Type:             schema.TypeString,
+				Default:          defaultEsClusterRefID,
+				Optional:         true,
+				DiffSuppressFunc: suppressDerivedEsClusterRefID,
 			},
 			"ref_id": {
 				Type:     schema.TypeString,
 				Default:  "main-apm",
 				Optional: true,
 			},
+			"version": newVersionOverrideSchema(),
 			"resource_id": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"kind": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"region": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -50,6 +56,12 @@ func newApmResource() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"secret_token": {
+				Type:        schema.TypeString,
+				Description: "The APM secret token, which the APM agents use to send data to the APM Server",
+				Computed:    true,
+				Sensitive:   true,
+			},
 			"topology": apmTopologySchema(),
 
 			"config": apmConfig(),
@@ -73,9 +85,10 @@ func apmTopologySchema() *schema.Schema {
 					Computed: true,
 				},
 				"size": {
-					Type:     schema.TypeString,
-					Computed: true,
-					Optional: true,
+					Type:             schema.TypeString,
+					Computed:         true,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentSize,
 				},
 				"size_resource": {
 					Type:        schema.TypeString,
@@ -103,9 +116,10 @@ func apmConfig() *schema.Schema {
 		Elem: &schema.Resource{
 			Schema: map[string]*schema.Schema{
 				"docker_image": {
-					Type:        schema.TypeString,
-					Description: "Optionally override the docker image the APM nodes will use. Note that this field will only work for internal users only.",
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      "Optionally override the docker image the APM nodes will use. Note that this field will only work for internal users only.",
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentDockerImage,
 				},
 				// APM System Settings
 				"debug_enabled": {
@@ -116,24 +130,30 @@ func apmConfig() *schema.Schema {
 				},
 
 				"user_settings_json": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary JSON object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_yaml' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (This field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `An arbitrary JSON object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_yaml' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (This field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_override_json": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary JSON object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_yaml' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `An arbitrary JSON object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_yaml' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_yaml": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary YAML object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_json' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `An arbitrary YAML object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_json' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 				"user_settings_override_yaml": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary YAML object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_json' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (These field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `An arbitrary YAML object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_json' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (These field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 			},
 		},
@@ -33,6 +33,7 @@ func newElasticsearchResource() *schema.Resource {
 			"autoscale": {
 				Type:        schema.TypeString,
 				Description: `Enable or disable autoscaling. Defaults to the setting coming from the deployment template. Accepted values are "true" or "false".`,
+				Deprecated:  `use the deployment level "autoscale" boolean attribute instead`,
 				Computed:    true,
 				Optional:    true,
 				ValidateFunc: func(i interface{}, s string) ([]string, []error) {
@@ -45,6 +46,36 @@ func newElasticsearchResource() *schema.Resource {
 				},
 			},
 
+			"dedicated_masters_threshold": {
+				Type:         schema.TypeInt,
+				Description:  "Optional number of nodes in the Elasticsearch cluster from which a dedicated master tier is created. Defaults to the setting coming from the deployment template.",
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+
+			"dedicated_masters": {
+				Type:          schema.TypeBool,
+				Description:   `Optionally set to true to add the dedicated "master" tier of the deployment template, sized to its default size unless a "master" topology element sets it. Defaults to false.`,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"elasticsearch.0.dedicated_masters_threshold"},
+			},
+
+			"include_remote_cluster_client": {
+				Type:        schema.TypeBool,
+				Description: `Optionally set to false to remove the "remote_cluster_client" role from the node_roles of all the topology elements`,
+				Optional:    true,
+				Default:     true,
+			},
+
+			"plan_strategy": {
+				Type:         schema.TypeString,
+				Description:  `Optional plan strategy to use when updating the Elasticsearch resource, one of "autodetect", "grow_and_shrink", "rolling" or "rolling_grow_and_shrink". Defaults to the strategy chosen by the server.`,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(planStrategies, false),
+			},
+
 			"ref_id": {
 				Type:        schema.TypeString,
 				Description: "Optional ref_id to set on the Elasticsearch resource",
@@ -58,6 +89,11 @@ func newElasticsearchResource() *schema.Resource {
 				Description: "The Elasticsearch resource unique identifier",
 				Computed:    true,
 			},
+			"kind": {
+				Type:        schema.TypeString,
+				Description: "The Elasticsearch resource kind",
+				Computed:    true,
+			},
 			"region": {
 				Type:        schema.TypeString,
 				Description: "The Elasticsearch resource region",
@@ -92,6 +128,12 @@ func newElasticsearchResource() *schema.Resource {
 
 			"trust_account":  newTrustAccountSchema(),
 			"trust_external": newTrustExternalSchema(),
+			"trust_self": {
+				Type:        schema.TypeBool,
+				Description: "Optionally trust all the clusters of the current organization, with an account trust relationship for the account ID of the provider credentials. A `trust_account` block for the same account takes precedence.",
+				Optional:    true,
+				Default:     false,
+			},
 		},
 	}
 }
@@ -116,16 +158,17 @@ func elasticsearchTopologySchema() *schema.Schema {
 					Computed:    true,
 				},
 				"size": {
-					Type:        schema.TypeString,
-					Description: `Optional amount of memory per node in the "<size in GB>g" notation`,
-					Computed:    true,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `Optional amount of memory per node in the "<size in GB>g" or "<size in TB>t" notation`,
+					Computed:         true,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentSize,
 				},
 				"size_resource": {
 					Type:        schema.TypeString,
-					Description: `Optional size type, defaults to "memory".`,
-					Default:     "memory",
+					Description: `Optional size type, defaults to the deployment template size type, or "memory" when it doesn't declare any.`,
 					Optional:    true,
+					Computed:    true,
 				},
 				"zone_count": {
 					Type:        schema.TypeInt,
@@ -160,13 +203,23 @@ func elasticsearchTopologySchema() *schema.Schema {
 				"node_roles": {
 					Type:        schema.TypeSet,
 					Set:         schema.HashString,
-					Description: `The computed list of node roles for the current topology element`,
+					Description: `Optional explicit list of node roles for the current topology element, such as ["ml"] or ["transform"], which overrides the deployment template ones. Only used with versions which support node roles (7.10.0 and above)`,
+					Optional:    true,
 					Computed:    true,
 					Elem: &schema.Schema{
 						Type: schema.TypeString,
 					},
 				},
 
+				"node_attributes": {
+					Type:        schema.TypeMap,
+					Description: `Optional node attributes for shard allocation awareness, which are merged with (and override) the deployment template node attributes`,
+					Optional:    true,
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+				},
+
 				"autoscaling": {
 					Type:        schema.TypeList,
 					Description: "Optional Elasticsearch autoscaling settings, such a maximum and minimum size and resources.",
@@ -183,10 +236,11 @@ func elasticsearchTopologySchema() *schema.Schema {
 							},
 
 							"max_size": {
-								Description: "Maximum size value for the maximum autoscaling setting.",
-								Type:        schema.TypeString,
-								Optional:    true,
-								Computed:    true,
+								Description:      "Maximum size value for the maximum autoscaling setting.",
+								Type:             schema.TypeString,
+								Optional:         true,
+								Computed:         true,
+								DiffSuppressFunc: suppressEquivalentSize,
 							},
 
 							"min_size_resource": {
@@ -197,10 +251,11 @@ func elasticsearchTopologySchema() *schema.Schema {
 							},
 
 							"min_size": {
-								Description: "Minimum size value for the minimum autoscaling setting.",
-								Type:        schema.TypeString,
-								Optional:    true,
-								Computed:    true,
+								Description:      "Minimum size value for the minimum autoscaling setting.",
+								Type:             schema.TypeString,
+								Optional:         true,
+								Computed:         true,
+								DiffSuppressFunc: suppressEquivalentSize,
 							},
 
 							"policy_override_json": {
@@ -208,17 +263,26 @@ func elasticsearchTopologySchema() *schema.Schema {
 								Description: "Computed policy overrides set directly via the API or other clients.",
 								Computed:    true,
 							},
+
+							"disabled": {
+								Type:        schema.TypeBool,
+								Description: "Optionally disable autoscaling on this topology element while it remains enabled on the rest, by pinning its autoscaling sizes to its current size.",
+								Optional:    true,
+								Default:     false,
+							},
 						},
 					},
 				},
 
-				// Read only config block that is present in the provider to
+				// Per topology element config block, which is also computed to
 				// avoid unsetting already set 'topology.elasticsearch' in the
-				// deployment plan.
+				// deployment plan. Only the user settings can be set.
 				"config": {
 					Type:        schema.TypeList,
+					Optional:    true,
 					Computed:    true,
-					Description: `Computed read-only configuration to avoid unsetting plan settings from 'topology.elasticsearch'`,
+					MaxItems:    1,
+					Description: `Optional topology element user settings, computed to avoid unsetting plan settings from 'topology.elasticsearch'`,
 					Elem: &schema.Resource{
 						Schema: map[string]*schema.Schema{
 							// Settings
@@ -236,24 +300,34 @@ func elasticsearchTopologySchema() *schema.Schema {
 
 							// User settings
 							"user_settings_json": {
-								Type:        schema.TypeString,
-								Description: `JSON-formatted user level "elasticsearch.yml" setting overrides`,
-								Computed:    true,
+								Type:         schema.TypeString,
+								Description:  `JSON-formatted user level "elasticsearch.yml" setting overrides`,
+								Optional:     true,
+								Computed:     true,
+								ValidateFunc: validateUserSettingsSize,
 							},
 							"user_settings_override_json": {
-								Type:        schema.TypeString,
-								Description: `JSON-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
-								Computed:    true,
+								Type:         schema.TypeString,
+								Description:  `JSON-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
+								Optional:     true,
+								Computed:     true,
+								ValidateFunc: validateUserSettingsSize,
 							},
 							"user_settings_yaml": {
-								Type:        schema.TypeString,
-								Description: `YAML-formatted user level "elasticsearch.yml" setting overrides`,
-								Computed:    true,
+								Type:             schema.TypeString,
+								Description:      `YAML-formatted user level "elasticsearch.yml" setting overrides`,
+								Optional:         true,
+								Computed:         true,
+								DiffSuppressFunc: suppressEquivalentYaml,
+								ValidateFunc:     validateUserSettingsSize,
 							},
 							"user_settings_override_yaml": {
-								Type:        schema.TypeString,
-								Description: `YAML-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
-								Computed:    true,
+								Type:             schema.TypeString,
+								Description:      `YAML-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
+								Optional:         true,
+								Computed:         true,
+								DiffSuppressFunc: suppressEquivalentYaml,
+								ValidateFunc:     validateUserSettingsSize,
 							},
 						},
 					},
@@ -275,9 +349,30 @@ func elasticsearchConfig() *schema.Schema {
 				// Settings
 
 				"docker_image": {
-					Type:        schema.TypeString,
-					Description: "Optionally override the docker image the Elasticsearch nodes will use. Note that this field will only work for internal users only.",
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      "Optionally override the docker image the Elasticsearch nodes will use. Note that this field will only work for internal users only.",
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentDockerImage,
+				},
+
+				// Security settings, which are expanded into the user
+				// settings overrides.
+				"anonymous_access_roles": {
+					Type:          schema.TypeSet,
+					Set:           schema.HashString,
+					Description:   `Optional roles assigned to anonymous users, which enables anonymous access to the cluster. Maps to the "xpack.security.authc.anonymous.roles" setting`,
+					Optional:      true,
+					MinItems:      1,
+					ConflictsWith: []string{"elasticsearch.0.config.0.user_settings_override_yaml"},
+					Elem: &schema.Schema{
+						Type: schema.TypeString,
+					},
+				},
+				"anonymous_access_username": {
+					Type:         schema.TypeString,
+					Description:  `Optional username of the anonymous users. Maps to the "xpack.security.authc.anonymous.username" setting`,
+					Optional:     true,
+					RequiredWith: []string{"elasticsearch.0.config.0.anonymous_access_roles"},
 				},
 
 				// Ignored settings are: [ user_bundles and user_plugins ].
@@ -299,24 +394,30 @@ func elasticsearchConfig() *schema.Schema {
 
 				// User settings
 				"user_settings_json": {
-					Type:        schema.TypeString,
-					Description: `JSON-formatted user level "elasticsearch.yml" setting overrides`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `JSON-formatted user level "elasticsearch.yml" setting overrides`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_override_json": {
-					Type:        schema.TypeString,
-					Description: `JSON-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `JSON-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_yaml": {
-					Type:        schema.TypeString,
-					Description: `YAML-formatted user level "elasticsearch.yml" setting overrides`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `YAML-formatted user level "elasticsearch.yml" setting overrides`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 				"user_settings_override_yaml": {
-					Type:        schema.TypeString,
-					Description: `YAML-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `YAML-formatted admin (ECE) level "elasticsearch.yml" setting overrides`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 			},
 		},
@@ -378,6 +479,12 @@ func newSnapshotSourceSettings() *schema.Schema {
 					Default:     "__latest_success__",
 					Optional:    true,
 				},
+				"strategy": {
+					Description:  `Optional restore strategy, one of "full", "partial" or "recovery". Defaults to "full" on create and to "partial" once the cluster exists.`,
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validation.StringInSlice(snapshotRestoreStrategies, false),
+				},
 			},
 		},
 	}
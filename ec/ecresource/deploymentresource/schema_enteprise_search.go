@@ -25,19 +25,25 @@ func newEnterpriseSearchResource() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"elasticsearch_cluster_ref_id": {
-				Type:     schema.TypeString,
-				Default:  "main-elasticsearch",
-				Optional: true,
+				Type:             schema.TypeString,
+				Default:          defaultEsClusterRefID,
+				Optional:         true,
+				DiffSuppressFunc: suppressDerivedEsClusterRefID,
 			},
 			"ref_id": {
 				Type:     schema.TypeString,
 				Default:  "main-enterprise_search",
 				Optional: true,
 			},
+			"version": newVersionOverrideSchema(),
 			"resource_id": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"kind": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"region": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -73,9 +79,10 @@ func enterpriseSearchTopologySchema() *schema.Schema {
 					Computed: true,
 				},
 				"size": {
-					Type:     schema.TypeString,
-					Computed: true,
-					Optional: true,
+					Type:             schema.TypeString,
+					Computed:         true,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentSize,
 				},
 				"size_resource": {
 					Type:        schema.TypeString,
@@ -92,16 +99,22 @@ func enterpriseSearchTopologySchema() *schema.Schema {
 				// Node types
 
 				"node_type_appserver": {
-					Type:     schema.TypeBool,
-					Computed: true,
+					Type:        schema.TypeBool,
+					Description: "Optionally run the Enterprise Search nodes as Application/API server, defaults to the deployment template node types",
+					Optional:    true,
+					Computed:    true,
 				},
 				"node_type_connector": {
-					Type:     schema.TypeBool,
-					Computed: true,
+					Type:        schema.TypeBool,
+					Description: "Optionally run the Enterprise Search nodes as connector, defaults to the deployment template node types",
+					Optional:    true,
+					Computed:    true,
 				},
 				"node_type_worker": {
-					Type:     schema.TypeBool,
-					Computed: true,
+					Type:        schema.TypeBool,
+					Description: "Optionally run the Enterprise Search nodes as background worker, defaults to the deployment template node types",
+					Optional:    true,
+					Computed:    true,
 				},
 			},
 		},
@@ -118,29 +131,36 @@ func enterpriseSearchConfig() *schema.Schema {
 		Elem: &schema.Resource{
 			Schema: map[string]*schema.Schema{
 				"docker_image": {
-					Type:        schema.TypeString,
-					Description: "Optionally override the docker image the Enterprise Search nodes will use. Note that this field will only work for internal users only.",
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      "Optionally override the docker image the Enterprise Search nodes will use. Note that this field will only work for internal users only.",
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentDockerImage,
 				},
 				"user_settings_json": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary JSON object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_yaml' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (This field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `An arbitrary JSON object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_yaml' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (This field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_override_json": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary JSON object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_yaml' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `An arbitrary JSON object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_yaml' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_yaml": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary YAML object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_json' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `An arbitrary YAML object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_json' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 				"user_settings_override_yaml": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary YAML object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_json' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (These field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `An arbitrary YAML object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_json' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (These field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 			},
 		},
@@ -25,19 +25,25 @@ func newKibanaResource() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"elasticsearch_cluster_ref_id": {
-				Type:     schema.TypeString,
-				Default:  "main-elasticsearch",
-				Optional: true,
+				Type:             schema.TypeString,
+				Default:          defaultEsClusterRefID,
+				Optional:         true,
+				DiffSuppressFunc: suppressDerivedEsClusterRefID,
 			},
 			"ref_id": {
 				Type:     schema.TypeString,
 				Default:  "main-kibana",
 				Optional: true,
 			},
+			"version": newVersionOverrideSchema(),
 			"resource_id": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"kind": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"region": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -70,9 +76,10 @@ func kibanaTopologySchema() *schema.Schema {
 					Computed: true,
 				},
 				"size": {
-					Type:     schema.TypeString,
-					Computed: true,
-					Optional: true,
+					Type:             schema.TypeString,
+					Computed:         true,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentSize,
 				},
 				"size_resource": {
 					Type:        schema.TypeString,
@@ -100,29 +107,36 @@ func kibanaConfig() *schema.Schema {
 		Elem: &schema.Resource{
 			Schema: map[string]*schema.Schema{
 				"docker_image": {
-					Type:        schema.TypeString,
-					Description: "Optionally override the docker image the Kibana nodes will use. Note that this field will only work for internal users only.",
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      "Optionally override the docker image the Kibana nodes will use. Note that this field will only work for internal users only.",
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentDockerImage,
 				},
 				"user_settings_json": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary JSON object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_yaml' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (This field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `An arbitrary JSON object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_yaml' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (This field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_override_json": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary JSON object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_yaml' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:         schema.TypeString,
+					Description:  `An arbitrary JSON object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_yaml' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
+					Optional:     true,
+					ValidateFunc: validateUserSettingsSize,
 				},
 				"user_settings_yaml": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary YAML object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_json' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `An arbitrary YAML object allowing ECE admins owners to set clusters' parameters (only one of this and 'user_settings_override_json' is allowed), ie in addition to the documented 'system_settings'. (This field together with 'system_settings' and 'user_settings*' defines the total set of resource settings)`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 				"user_settings_override_yaml": {
-					Type:        schema.TypeString,
-					Description: `An arbitrary YAML object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_json' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (These field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
-					Optional:    true,
+					Type:             schema.TypeString,
+					Description:      `An arbitrary YAML object allowing (non-admin) cluster owners to set their parameters (only one of this and 'user_settings_json' is allowed), provided they are on the whitelist ('user_settings_whitelist') and not on the blacklist ('user_settings_blacklist'). (These field together with 'user_settings_override*' and 'system_settings' defines the total set of resource settings)`,
+					Optional:         true,
+					DiffSuppressFunc: suppressEquivalentYaml,
+					ValidateFunc:     validateUserSettingsSize,
 				},
 			},
 		},
@@ -0,0 +1,188 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_suppressEquivalentYaml(t *testing.T) {
+	type args struct {
+		old string
+		new string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "suppresses equal strings",
+			args: args{old: "some.setting: value", new: "some.setting: value"},
+			want: true,
+		},
+		{
+			name: "suppresses settings with different key ordering",
+			args: args{
+				old: "a.setting: value\nb.setting: value",
+				new: "b.setting: value\na.setting: value",
+			},
+			want: true,
+		},
+		{
+			name: "suppresses settings with different whitespace and comments",
+			args: args{
+				old: "some:\n  nested: setting\n",
+				new: "# the nested setting\nsome:\n    nested:   setting # comment\n\n",
+			},
+			want: true,
+		},
+		{
+			name: "suppresses equal multi-document settings",
+			args: args{
+				old: "a: 1\nb: 2\n---\nc: 3",
+				new: "b: 2\na: 1\n---\n# comment\nc: 3\n",
+			},
+			want: true,
+		},
+		{
+			name: "doesn't suppress multi-document settings in a different order",
+			args: args{
+				old: "a: 1\n---\nc: 3",
+				new: "c: 3\n---\na: 1",
+			},
+		},
+		{
+			name: "suppresses quoted and unquoted durations",
+			args: args{
+				old: "xpack.monitoring.collection.interval: \"30s\"",
+				new: "xpack.monitoring.collection.interval: 30s",
+			},
+			want: true,
+		},
+		{
+			name: "suppresses quoted and unquoted numeric settings",
+			args: args{
+				old: "indices.queries.cache.count: \"10000\"\nsome.ratio: \"0.5\"",
+				new: "indices.queries.cache.count: 10000\nsome.ratio: 0.5",
+			},
+			want: true,
+		},
+		{
+			name: "suppresses quoted and unquoted booleans",
+			args: args{
+				old: "xpack.security.enabled: \"true\"\nsome.flag: False",
+				new: "xpack.security.enabled: true\nsome.flag: \"false\"",
+			},
+			want: true,
+		},
+		{
+			name: "suppresses quoted and unquoted floats in any notation",
+			args: args{
+				old: "some.ratio: \"1.0\"\nother.ratio: \"0.10\"\nbig.number: 1e3",
+				new: "some.ratio: 1.0\nother.ratio: .1\nbig.number: \"1000\"",
+			},
+			want: true,
+		},
+		{
+			name: "doesn't suppress different booleans",
+			args: args{old: "some.flag: true", new: "some.flag: \"false\""},
+		},
+		{
+			name: "doesn't suppress booleans and strings",
+			args: args{old: "some.flag: true", new: "some.flag: \"yes please\""},
+		},
+		{
+			name: "doesn't suppress different floats",
+			args: args{old: "some.ratio: \"1.0\"", new: "some.ratio: 1.01"},
+		},
+		{
+			name: "doesn't suppress different numeric settings",
+			args: args{old: "some.setting: 1", new: "some.setting: \"2\""},
+		},
+		{
+			name: "doesn't suppress different settings",
+			args: args{old: "some.setting: value", new: "some.setting: other"},
+		},
+		{
+			name: "doesn't suppress removed settings",
+			args: args{old: "some.setting: value", new: ""},
+		},
+		{
+			name: "doesn't suppress invalid yaml",
+			args: args{old: "some.setting: value", new: "some.setting: [value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suppressEquivalentYaml("", tt.args.old, tt.args.new, nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_suppressEquivalentSize(t *testing.T) {
+	type args struct {
+		old string
+		new string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "suppresses equal sizes",
+			args: args{old: "116g", new: "116g"},
+			want: true,
+		},
+		{
+			name: "suppresses sizes in a different notation",
+			args: args{old: "116g", new: "116GB"},
+			want: true,
+		},
+		{
+			name: "suppresses fractional sizes in a different notation",
+			args: args{old: "0.5g", new: "0.50G"},
+			want: true,
+		},
+		{
+			name: "suppresses sizes in a different unit",
+			args: args{old: "2t", new: "2048g"},
+			want: true,
+		},
+		{
+			name: "doesn't suppress different sizes",
+			args: args{old: "116g", new: "120g"},
+			want: false,
+		},
+		{
+			name: "doesn't suppress unparseable sizes",
+			args: args{old: "116g", new: "116"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suppressEquivalentSize("", tt.args.old, tt.args.new, nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
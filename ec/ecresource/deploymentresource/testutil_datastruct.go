@@ -202,7 +202,6 @@ func newKibanaSample() map[string]interface{} {
 		"elasticsearch_cluster_ref_id": "main-elasticsearch",
 		"ref_id":                       "main-kibana",
 		"resource_id":                  mock.ValidClusterID,
-		"version":                      "7.7.0",
 		"region":                       "us-east-1",
 		"topology": []interface{}{
 			map[string]interface{}{
@@ -219,7 +218,6 @@ func newApmSample() map[string]interface{} {
 		"elasticsearch_cluster_ref_id": "main-elasticsearch",
 		"ref_id":                       "main-apm",
 		"resource_id":                  mock.ValidClusterID,
-		"version":                      "7.7.0",
 		"region":                       "us-east-1",
 		// Reproduces the case where the default fields are set.
 		"config": []interface{}{map[string]interface{}{
@@ -238,7 +236,6 @@ func newEnterpriseSearchSample() map[string]interface{} {
 		"elasticsearch_cluster_ref_id": "main-elasticsearch",
 		"ref_id":                       "main-enterprise_search",
 		"resource_id":                  mock.ValidClusterID,
-		"version":                      "7.7.0",
 		"region":                       "us-east-1",
 		"topology": []interface{}{
 			map[string]interface{}{
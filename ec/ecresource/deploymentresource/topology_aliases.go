@@ -0,0 +1,93 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+)
+
+// defaultTopologyAliases maps the Elasticsearch topology IDs which have been
+// renamed in the deployment templates to their new IDs.
+var defaultTopologyAliases = map[string]string{
+	"hot_content": "data_hot",
+	"warm":        "data_warm",
+	"cold":        "data_cold",
+	"frozen":      "data_frozen",
+}
+
+// topologyAliases returns the default topology aliases merged with the
+// configured "topology_aliases", which take precedence.
+func topologyAliases(overrides map[string]interface{}) map[string]string {
+	aliases := make(map[string]string, len(defaultTopologyAliases)+len(overrides))
+	for id, alias := range defaultTopologyAliases {
+		aliases[id] = alias
+	}
+	for id, rawAlias := range overrides {
+		if alias, ok := rawAlias.(string); ok && alias != "" {
+			aliases[id] = alias
+		}
+	}
+	return aliases
+}
+
+// aliasTopology renames the flattened Elasticsearch topology elements which
+// aren't part of the deployment template to their alias when the template
+// has it, so a renamed tier keeps its configured size. Elements whose alias
+// is already configured are left untouched.
+func aliasTopology(rawRes []interface{}, tpl *models.ElasticsearchPayload, aliases map[string]string) {
+	var tplIDs = make(map[string]bool)
+	if tpl != nil && tpl.Plan != nil {
+		for _, topology := range tpl.Plan.ClusterTopology {
+			if topology != nil {
+				tplIDs[topology.ID] = true
+			}
+		}
+	}
+
+	for _, r := range rawRes {
+		res, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		rawTopologies, _ := res["topology"].([]interface{})
+		var configured = make(map[string]bool, len(rawTopologies))
+		for _, rawTop := range rawTopologies {
+			if topology, ok := rawTop.(map[string]interface{}); ok {
+				id, _ := topology["id"].(string)
+				configured[id] = true
+			}
+		}
+
+		for _, rawTop := range rawTopologies {
+			topology, ok := rawTop.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			id, _ := topology["id"].(string)
+			alias := aliases[id]
+			if tplIDs[id] || !tplIDs[alias] || configured[alias] {
+				continue
+			}
+
+			topology["id"] = alias
+			configured[alias] = true
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_topologyAliases(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]interface{}
+		want      map[string]string
+	}{
+		{
+			name: "returns the default aliases",
+			want: defaultTopologyAliases,
+		},
+		{
+			name: "overrides and extends the default aliases",
+			overrides: map[string]interface{}{
+				"hot_content": "hot",
+				"ml":          "data_ml",
+			},
+			want: map[string]string{
+				"hot_content": "hot",
+				"warm":        "data_warm",
+				"cold":        "data_cold",
+				"frozen":      "data_frozen",
+				"ml":          "data_ml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, topologyAliases(tt.overrides))
+		})
+	}
+}
+
+func Test_aliasTopology(t *testing.T) {
+	// The template renames the hot_content tier to data_hot and keeps warm.
+	tpl := &models.ElasticsearchPayload{Plan: &models.ElasticsearchClusterPlan{
+		ClusterTopology: []*models.ElasticsearchClusterTopologyElement{
+			{ID: "data_hot"}, {ID: "warm"}, {ID: "master"},
+		},
+	}}
+	newRes := func(topologies ...map[string]interface{}) []interface{} {
+		var raw []interface{}
+		for _, topology := range topologies {
+			raw = append(raw, topology)
+		}
+		return []interface{}{map[string]interface{}{"topology": raw}}
+	}
+	tests := []struct {
+		name    string
+		res     []interface{}
+		aliases map[string]string
+		want    []interface{}
+	}{
+		{
+			name: "renames the tiers which the template renamed",
+			res: newRes(
+				map[string]interface{}{"id": "hot_content", "size": "8g", "instance_configuration_id": "aws.data.highio.i3"},
+				map[string]interface{}{"id": "warm", "size": "4g"},
+			),
+			aliases: defaultTopologyAliases,
+			want: newRes(
+				map[string]interface{}{"id": "data_hot", "size": "8g", "instance_configuration_id": "aws.data.highio.i3"},
+				map[string]interface{}{"id": "warm", "size": "4g"},
+			),
+		},
+		{
+			name: "keeps the tiers whose alias is already configured",
+			res: newRes(
+				map[string]interface{}{"id": "hot_content", "size": "8g"},
+				map[string]interface{}{"id": "data_hot", "size": "4g"},
+			),
+			aliases: defaultTopologyAliases,
+			want: newRes(
+				map[string]interface{}{"id": "hot_content", "size": "8g"},
+				map[string]interface{}{"id": "data_hot", "size": "4g"},
+			),
+		},
+		{
+			name: "keeps the tiers whose alias isn't part of the template",
+			res: newRes(
+				map[string]interface{}{"id": "cold", "size": "2g"},
+			),
+			aliases: defaultTopologyAliases,
+			want: newRes(
+				map[string]interface{}{"id": "cold", "size": "2g"},
+			),
+		},
+		{
+			name: "renames the tiers with the configured aliases",
+			res: newRes(
+				map[string]interface{}{"id": "dedicated_master", "size": "1g"},
+			),
+			aliases: topologyAliases(map[string]interface{}{"dedicated_master": "master"}),
+			want: newRes(
+				map[string]interface{}{"id": "master", "size": "1g"},
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aliasTopology(tt.res, tpl, tt.aliases)
+			assert.Equal(t, tt.want, tt.res)
+		})
+	}
+
+	t.Run("preserves the size of the renamed tiers on a template change", func(t *testing.T) {
+		res := newRes(
+			map[string]interface{}{"id": "hot_content", "size": "8g", "instance_configuration_id": "aws.data.highio.i3"},
+		)
+		aliasTopology(res, tpl, defaultTopologyAliases)
+		unsetTopology(res, tpl)
+		assert.Equal(t, newRes(map[string]interface{}{"id": "data_hot", "size": "8g"}), res)
+	})
+}
@@ -18,6 +18,11 @@
 package deploymentresource
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
@@ -43,12 +48,17 @@ func flattenTrafficFiltering(settings *models.DeploymentSettings) *schema.Set {
 }
 
 // expandTrafficFilterCreate expands the flattened "traffic_filter" settings to
-// a DeploymentCreateRequest.
-func expandTrafficFilterCreate(set *schema.Set, req *models.DeploymentCreateRequest) {
+// a DeploymentCreateRequest. Any rulesets which are part of the "exclude" set
+// are left out of the request.
+func expandTrafficFilterCreate(set, exclude *schema.Set, req *models.DeploymentCreateRequest) {
 	if set == nil || req == nil {
 		return
 	}
 
+	if exclude != nil && exclude.Len() > 0 {
+		set = set.Difference(exclude)
+	}
+
 	if set.Len() == 0 {
 		return
 	}
@@ -66,3 +76,25 @@ func expandTrafficFilterCreate(set *schema.Set, req *models.DeploymentCreateRequ
 		util.ItemsToString(set.List())...,
 	)
 }
+
+// checkTrafficFilterExclusions ensures no ruleset is part of both
+// "traffic_filter" and "traffic_filter_exclude", since it would be associated
+// and have its association removed right after.
+func checkTrafficFilterExclusions(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	filters, _ := d.Get("traffic_filter").(*schema.Set)
+	exclude, _ := d.Get("traffic_filter_exclude").(*schema.Set)
+	if filters == nil || exclude == nil {
+		return nil
+	}
+
+	overlap := util.ItemsToString(filters.Intersection(exclude).List())
+	if len(overlap) == 0 {
+		return nil
+	}
+
+	sort.Strings(overlap)
+	return fmt.Errorf(
+		`"traffic_filter_exclude" rulesets can't be part of "traffic_filter": %s`,
+		strings.Join(overlap, ", "),
+	)
+}
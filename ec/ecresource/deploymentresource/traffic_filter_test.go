@@ -18,11 +18,17 @@
 package deploymentresource
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
 
 func TestParseTrafficFiltering(t *testing.T) {
@@ -85,8 +91,9 @@ func TestParseTrafficFiltering(t *testing.T) {
 
 func Test_expandTrafficFilterCreate(t *testing.T) {
 	type args struct {
-		v   *schema.Set
-		req *models.DeploymentCreateRequest
+		v       *schema.Set
+		exclude *schema.Set
+		req     *models.DeploymentCreateRequest
 	}
 	tests := []struct {
 		name string
@@ -137,6 +144,30 @@ func Test_expandTrafficFilterCreate(t *testing.T) {
 				}},
 			}},
 		},
+		{
+			name: "leaves out the excluded traffic filtering rules",
+			args: args{
+				v: schema.NewSet(schema.HashString, []interface{}{
+					"0.0.0.0/0", "192.168.1.0/24", "some-default-ruleset",
+				}),
+				exclude: schema.NewSet(schema.HashString, []interface{}{"some-default-ruleset"}),
+				req:     &models.DeploymentCreateRequest{},
+			},
+			want: &models.DeploymentCreateRequest{Settings: &models.DeploymentCreateSettings{
+				TrafficFilterSettings: &models.TrafficFilterSettings{Rulesets: []string{
+					"0.0.0.0/0", "192.168.1.0/24",
+				}},
+			}},
+		},
+		{
+			name: "parses no traffic filtering rules when all are excluded",
+			args: args{
+				v:       schema.NewSet(schema.HashString, []interface{}{"some-default-ruleset"}),
+				exclude: schema.NewSet(schema.HashString, []interface{}{"some-default-ruleset"}),
+				req:     &models.DeploymentCreateRequest{},
+			},
+			want: &models.DeploymentCreateRequest{},
+		},
 		{
 			name: "parses no traffic filtering rules",
 			args: args{
@@ -148,8 +179,92 @@ func Test_expandTrafficFilterCreate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			expandTrafficFilterCreate(tt.args.v, tt.args.req)
+			expandTrafficFilterCreate(tt.args.v, tt.args.exclude, tt.args.req)
 			assert.Equal(t, tt.want, tt.args.req)
 		})
 	}
 }
+
+func Test_flattenTrafficFilteringOrder(t *testing.T) {
+	newSettings := func(rulesets ...string) *models.DeploymentSettings {
+		return &models.DeploymentSettings{
+			TrafficFilterSettings: &models.TrafficFilterSettings{Rulesets: rulesets},
+		}
+	}
+	configured := []interface{}{"rule-a", "rule-b", "rule-c"}
+	tests := []struct {
+		name     string
+		settings *models.DeploymentSettings
+		change   bool
+	}{
+		{
+			name:     "doesn't change when the rulesets are read in the configured order",
+			settings: newSettings("rule-a", "rule-b", "rule-c"),
+		},
+		{
+			name:     "doesn't change when the rulesets are read in a different order",
+			settings: newSettings("rule-c", "rule-a", "rule-b"),
+		},
+		{
+			name:     "changes when a ruleset is missing",
+			settings: newSettings("rule-c", "rule-a"),
+			change:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State: map[string]interface{}{
+					"traffic_filter": configured,
+				},
+			})
+
+			prior := d.Get("traffic_filter").(*schema.Set)
+			assert.NoError(t, d.Set("traffic_filter", flattenTrafficFiltering(tt.settings)))
+			assert.Equal(t, tt.change, !prior.Equal(d.Get("traffic_filter")))
+		})
+	}
+}
+
+func Test_checkTrafficFilterExclusions(t *testing.T) {
+	newConfig := func(filters, exclude []interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.12.0",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+			"traffic_filter":         filters,
+			"traffic_filter_exclude": exclude,
+		}
+	}
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    error
+	}{
+		{
+			name:   "accepts distinct rulesets",
+			config: newConfig([]interface{}{"rule-a"}, []interface{}{"rule-b"}),
+		},
+		{
+			name:   "rejects the rulesets in both traffic_filter and traffic_filter_exclude",
+			config: newConfig([]interface{}{"rule-a", "rule-b", "rule-c"}, []interface{}{"rule-c", "rule-b"}),
+			err:    errors.New(`"traffic_filter_exclude" rulesets can't be part of "traffic_filter": rule-b, rule-c`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := schema.Resource{Schema: newSchema(), CustomizeDiff: checkTrafficFilterExclusions}
+			_, err := res.Diff(
+				context.Background(), nil, terraform.NewResourceConfigRaw(tt.config), nil,
+			)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
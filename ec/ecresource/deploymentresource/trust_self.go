@@ -0,0 +1,228 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/apierror"
+	"github.com/elastic/cloud-sdk-go/pkg/client/accounts"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// currentAccountID returns the account ID of the organization which the
+// provider credentials belong to. The account API requires a region, which
+// is the region of the deployment.
+func currentAccountID(ctx context.Context, client *api.API, region string) (string, error) {
+	res, err := client.V1API.Accounts.GetCurrentAccount(
+		accounts.NewGetCurrentAccountParams().
+			WithContext(api.WithRegion(ctx, region)),
+		client.AuthWriter,
+	)
+	if err != nil {
+		return "", multierror.NewPrefixed("failed obtaining the current account", apierror.Wrap(err))
+	}
+
+	if res.Payload == nil || res.Payload.ID == nil {
+		return "", nil
+	}
+	return *res.Payload.ID, nil
+}
+
+// trustSelfAccountID returns the account ID which "trust_self" trusts. The
+// account ID is kept in the state, so the current account is only obtained
+// when it isn't known yet or when "trust_self" changes.
+func trustSelfAccountID(ctx context.Context, d *schema.ResourceData, client *api.API) (string, error) {
+	accountID, _ := d.Get("trust_self_account_id").(string)
+	if accountID != "" && !trustSelfChanged(d) {
+		return accountID, nil
+	}
+
+	return currentAccountID(ctx, client, d.Get("region").(string))
+}
+
+// trustSelfChanged returns true when "trust_self" changes in any of the
+// Elasticsearch resources.
+func trustSelfChanged(d *schema.ResourceData) bool {
+	es, _ := d.Get("elasticsearch").([]interface{})
+	for i := range es {
+		if d.HasChange(fmt.Sprintf("elasticsearch.%d.trust_self", i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTrustSelf adds the trust relationship with all the clusters of the
+// current account to the expanded Elasticsearch resources which have
+// "trust_self" set, keeping the account ID in "trust_self_account_id".
+func addTrustSelf(ctx context.Context, d *schema.ResourceData, client *api.API, ess []*models.ElasticsearchPayload) error {
+	es, _ := d.Get("elasticsearch").([]interface{})
+	if !trustsSelf(es) {
+		return nil
+	}
+
+	accountID, err := trustSelfAccountID(ctx, d, client)
+	if err != nil {
+		return err
+	}
+
+	expandTrustSelf(es, ess, accountID)
+	return d.Set("trust_self_account_id", accountID)
+}
+
+// trustsSelf returns true when any of the flattened Elasticsearch resources
+// has "trust_self" set.
+func trustsSelf(raw []interface{}) bool {
+	for _, rawEs := range raw {
+		if es, ok := rawEs.(map[string]interface{}); ok {
+			if self, ok := es["trust_self"].(bool); ok && self {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandTrustSelf adds a trust relationship with all the clusters of the
+// accountID to the Elasticsearch resources which have "trust_self" set,
+// unless their "trust_account" blocks already set the trust of the account.
+// Both the flattened and expanded resources are matched by their position.
+func expandTrustSelf(raw []interface{}, ess []*models.ElasticsearchPayload, accountID string) {
+	if accountID == "" {
+		return
+	}
+
+	for i, rawEs := range raw {
+		es, ok := rawEs.(map[string]interface{})
+		if !ok || i >= len(ess) || ess[i] == nil {
+			continue
+		}
+		if self, ok := es["trust_self"].(bool); !ok || !self {
+			continue
+		}
+
+		if ess[i].Settings == nil {
+			ess[i].Settings = &models.ElasticsearchClusterSettings{}
+		}
+		settings := ess[i].Settings
+		if settings.Trust == nil {
+			settings.Trust = &models.ElasticsearchClusterTrustSettings{}
+		}
+
+		if hasAccountTrust(settings.Trust.Accounts, accountID) {
+			continue
+		}
+
+		settings.Trust.Accounts = append(settings.Trust.Accounts, &models.AccountTrustRelationship{
+			AccountID: ec.String(accountID),
+			TrustAll:  ec.Bool(true),
+		})
+	}
+}
+
+// hasAccountTrust returns true when there's a trust relationship with the
+// accountID.
+func hasAccountTrust(accounts []*models.AccountTrustRelationship, accountID string) bool {
+	for _, acc := range accounts {
+		if acc != nil && acc.AccountID != nil && *acc.AccountID == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenTrustSelf moves the trust relationship with all the clusters of the
+// current account to "trust_self" for the Elasticsearch resources which have
+// it set in the prior state, so the relationship added by "trust_self"
+// doesn't show as a "trust_account" block. The current account is only
+// obtained when "trust_self" is set and its account ID isn't known yet.
+func flattenTrustSelf(ctx context.Context, d *schema.ResourceData, prior []interface{}, client *api.API) error {
+	if !trustsSelf(prior) {
+		return nil
+	}
+
+	accountID, _ := d.Get("trust_self_account_id").(string)
+	if accountID == "" {
+		var err error
+		if accountID, err = currentAccountID(ctx, client, d.Get("region").(string)); err != nil {
+			return err
+		}
+		if err := d.Set("trust_self_account_id", accountID); err != nil {
+			return err
+		}
+	}
+
+	es, _ := d.Get("elasticsearch").([]interface{})
+	setTrustSelf(es, prior, accountID)
+	return d.Set("elasticsearch", es)
+}
+
+// setTrustSelf removes the trust relationship with all the clusters of the
+// accountID from the flattened Elasticsearch resources which have
+// "trust_self" set in the prior state, and sets "trust_self" when it's found.
+// The relationship is only removed when it's been added by "trust_self", so
+// a "trust_account" block which explicitly sets the trust of the accountID is
+// kept.
+func setTrustSelf(es, prior []interface{}, accountID string) {
+	forEachPrior(es, prior, "ref_id", func(m, priorM map[string]interface{}) {
+		if self, _ := priorM["trust_self"].(bool); !self {
+			return
+		}
+
+		accounts, ok := m["trust_account"].(*schema.Set)
+		if !ok {
+			return
+		}
+
+		priorAccounts, _ := priorM["trust_account"].(*schema.Set)
+		explicit := hasTrustAccount(priorAccounts, accountID)
+		for _, rawAcc := range accounts.List() {
+			acc, _ := rawAcc.(map[string]interface{})
+			if acc["account_id"] == accountID && acc["trust_all"] == true {
+				if !explicit {
+					accounts.Remove(rawAcc)
+				}
+				m["trust_self"] = true
+			}
+		}
+
+		if accounts.Len() == 0 {
+			delete(m, "trust_account")
+		}
+	})
+}
+
+// hasTrustAccount returns true when any of the flattened "trust_account"
+// blocks sets the trust of the accountID.
+func hasTrustAccount(accounts *schema.Set, accountID string) bool {
+	if accounts == nil {
+		return false
+	}
+	for _, rawAcc := range accounts.List() {
+		if acc, _ := rawAcc.(map[string]interface{}); acc["account_id"] == accountID {
+			return true
+		}
+	}
+	return false
+}
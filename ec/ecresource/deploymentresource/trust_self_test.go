@@ -0,0 +1,283 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_expandTrustSelf(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       []interface{}
+		ess       []*models.ElasticsearchPayload
+		accountID string
+		want      []*models.ElasticsearchPayload
+	}{
+		{
+			name:      "adds the trust relationship with the current account",
+			raw:       []interface{}{map[string]interface{}{"trust_self": true}},
+			ess:       []*models.ElasticsearchPayload{{}},
+			accountID: "1234",
+			want: []*models.ElasticsearchPayload{{
+				Settings: &models.ElasticsearchClusterSettings{
+					Trust: &models.ElasticsearchClusterTrustSettings{
+						Accounts: []*models.AccountTrustRelationship{{
+							AccountID: ec.String("1234"),
+							TrustAll:  ec.Bool(true),
+						}},
+					},
+				},
+			}},
+		},
+		{
+			name: "keeps an explicit trust relationship with the current account",
+			raw:  []interface{}{map[string]interface{}{"trust_self": true}},
+			ess: []*models.ElasticsearchPayload{{
+				Settings: &models.ElasticsearchClusterSettings{
+					Trust: &models.ElasticsearchClusterTrustSettings{
+						Accounts: []*models.AccountTrustRelationship{{
+							AccountID:      ec.String("1234"),
+							TrustAll:       ec.Bool(false),
+							TrustAllowlist: []string{"abc"},
+						}},
+					},
+				},
+			}},
+			accountID: "1234",
+			want: []*models.ElasticsearchPayload{{
+				Settings: &models.ElasticsearchClusterSettings{
+					Trust: &models.ElasticsearchClusterTrustSettings{
+						Accounts: []*models.AccountTrustRelationship{{
+							AccountID:      ec.String("1234"),
+							TrustAll:       ec.Bool(false),
+							TrustAllowlist: []string{"abc"},
+						}},
+					},
+				},
+			}},
+		},
+		{
+			name:      "does nothing when trust_self isn't set",
+			raw:       []interface{}{map[string]interface{}{"trust_self": false}},
+			ess:       []*models.ElasticsearchPayload{{}},
+			accountID: "1234",
+			want:      []*models.ElasticsearchPayload{{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expandTrustSelf(tt.raw, tt.ess, tt.accountID)
+			assert.Equal(t, tt.want, tt.ess)
+		})
+	}
+}
+
+func Test_addTrustSelf(t *testing.T) {
+	newEs := func(self bool) map[string]interface{} {
+		return map[string]interface{}{
+			"region":        "us-east-1",
+			"elasticsearch": []interface{}{map[string]interface{}{"trust_self": self}},
+		}
+	}
+	accountResponse := func() mock.Response {
+		return mock.New200StructResponse(models.AccountResponse{ID: ec.String("1234")})
+	}
+	tests := []struct {
+		name      string
+		state     map[string]interface{}
+		change    map[string]interface{}
+		accountID string
+		client    *api.API
+		want      []*models.ElasticsearchPayload
+		wantID    string
+	}{
+		{
+			name:   "obtains the current account when its ID isn't known",
+			state:  newEs(true),
+			change: newEs(true),
+			client: api.NewMock(accountResponse()),
+			want: []*models.ElasticsearchPayload{{Settings: &models.ElasticsearchClusterSettings{
+				Trust: &models.ElasticsearchClusterTrustSettings{
+					Accounts: []*models.AccountTrustRelationship{{AccountID: ec.String("1234"), TrustAll: ec.Bool(true)}},
+				},
+			}}},
+			wantID: "1234",
+		},
+		{
+			name:      "uses the account ID kept in the state when trust_self doesn't change",
+			state:     newEs(true),
+			change:    newEs(true),
+			accountID: "5678",
+			client:    api.NewMock(),
+			want: []*models.ElasticsearchPayload{{Settings: &models.ElasticsearchClusterSettings{
+				Trust: &models.ElasticsearchClusterTrustSettings{
+					Accounts: []*models.AccountTrustRelationship{{AccountID: ec.String("5678"), TrustAll: ec.Bool(true)}},
+				},
+			}}},
+			wantID: "5678",
+		},
+		{
+			name:      "obtains the current account when trust_self changes",
+			state:     newEs(false),
+			change:    newEs(true),
+			accountID: "5678",
+			client:    api.NewMock(accountResponse()),
+			want: []*models.ElasticsearchPayload{{Settings: &models.ElasticsearchClusterSettings{
+				Trust: &models.ElasticsearchClusterTrustSettings{
+					Accounts: []*models.AccountTrustRelationship{{AccountID: ec.String("1234"), TrustAll: ec.Bool(true)}},
+				},
+			}}},
+			wantID: "1234",
+		},
+		{
+			name:   "doesn't obtain the current account without trust_self",
+			state:  newEs(false),
+			change: newEs(false),
+			client: api.NewMock(),
+			want:   []*models.ElasticsearchPayload{{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State:  tt.state,
+				Change: tt.change,
+			})
+			if tt.accountID != "" {
+				assert.NoError(t, d.Set("trust_self_account_id", tt.accountID))
+			}
+
+			ess := []*models.ElasticsearchPayload{{}}
+			assert.NoError(t, addTrustSelf(context.Background(), d, tt.client, ess))
+			assert.Equal(t, tt.want, ess)
+			assert.Equal(t, tt.wantID, d.Get("trust_self_account_id"))
+		})
+	}
+}
+
+func Test_flattenTrustSelf(t *testing.T) {
+	newTrustAccount := func(id string, all bool) *models.AccountTrustRelationship {
+		return &models.AccountTrustRelationship{AccountID: ec.String(id), TrustAll: ec.Bool(all)}
+	}
+	tests := []struct {
+		name          string
+		prior         []interface{}
+		accounts      []*models.AccountTrustRelationship
+		accountID     string
+		client        *api.API
+		wantSelf      string
+		wantAccountID []string
+	}{
+		{
+			name:     "moves the current account trust relationship to trust_self",
+			prior:    []interface{}{map[string]interface{}{"ref_id": "main-elasticsearch", "trust_self": true}},
+			accounts: []*models.AccountTrustRelationship{newTrustAccount("1234", true), newTrustAccount("5678", true)},
+			client: api.NewMock(mock.New200StructResponse(models.AccountResponse{
+				ID: ec.String("1234"),
+			})),
+			wantSelf:      "true",
+			wantAccountID: []string{"5678"},
+		},
+		{
+			name:     "unsets trust_self when the trust relationship has been removed",
+			prior:    []interface{}{map[string]interface{}{"ref_id": "main-elasticsearch", "trust_self": true}},
+			accounts: []*models.AccountTrustRelationship{newTrustAccount("5678", true)},
+			client: api.NewMock(mock.New200StructResponse(models.AccountResponse{
+				ID: ec.String("1234"),
+			})),
+			wantSelf:      "false",
+			wantAccountID: []string{"5678"},
+		},
+		{
+			name:          "uses the account ID kept in the state",
+			prior:         []interface{}{map[string]interface{}{"ref_id": "main-elasticsearch", "trust_self": true}},
+			accounts:      []*models.AccountTrustRelationship{newTrustAccount("1234", true), newTrustAccount("5678", true)},
+			accountID:     "1234",
+			client:        api.NewMock(),
+			wantSelf:      "true",
+			wantAccountID: []string{"5678"},
+		},
+		{
+			name: "keeps an explicit trust relationship with the current account",
+			prior: []interface{}{map[string]interface{}{
+				"ref_id":     "main-elasticsearch",
+				"trust_self": true,
+				"trust_account": flattenAccountTrust(&models.ElasticsearchClusterTrustSettings{
+					Accounts: []*models.AccountTrustRelationship{newTrustAccount("1234", true)},
+				}),
+			}},
+			accounts:      []*models.AccountTrustRelationship{newTrustAccount("1234", true), newTrustAccount("5678", true)},
+			accountID:     "1234",
+			client:        api.NewMock(),
+			wantSelf:      "true",
+			wantAccountID: []string{"1234", "5678"},
+		},
+		{
+			name:          "doesn't obtain the current account without trust_self",
+			prior:         []interface{}{map[string]interface{}{"ref_id": "main-elasticsearch", "trust_self": false}},
+			accounts:      []*models.AccountTrustRelationship{newTrustAccount("1234", true)},
+			client:        api.NewMock(),
+			wantSelf:      "false",
+			wantAccountID: []string{"1234"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State: map[string]interface{}{
+					"region":        "us-east-1",
+					"elasticsearch": []interface{}{map[string]interface{}{}},
+				},
+			})
+			es := d.Get("elasticsearch").([]interface{})
+			es[0].(map[string]interface{})["trust_account"] = flattenAccountTrust(
+				&models.ElasticsearchClusterTrustSettings{Accounts: tt.accounts},
+			)
+			assert.NoError(t, d.Set("elasticsearch", es))
+			if tt.accountID != "" {
+				assert.NoError(t, d.Set("trust_self_account_id", tt.accountID))
+			}
+
+			assert.NoError(t, flattenTrustSelf(context.Background(), d, tt.prior, tt.client))
+
+			attrs := d.State().Attributes
+			assert.Equal(t, tt.wantSelf, attrs["elasticsearch.0.trust_self"])
+
+			var accountIDs []string
+			for _, rawAcc := range d.Get("elasticsearch.0.trust_account").(*schema.Set).List() {
+				accountIDs = append(accountIDs, rawAcc.(map[string]interface{})["account_id"].(string))
+			}
+			assert.ElementsMatch(t, tt.wantAccountID, accountIDs)
+		})
+	}
+}
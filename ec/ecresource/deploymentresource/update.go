@@ -23,7 +23,9 @@ import (
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -32,54 +34,107 @@ import (
 func updateResource(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	client := meta.(*api.API)
 
+	var diags diag.Diagnostics
 	if hasDeploymentChange(d) {
-		if err := updateDeployment(ctx, d, client); err != nil {
-			return diag.FromErr(err)
+		diags = checkDockerImages(ctx, d)
+		diags = append(diags, checkDockerImagePlugins(d)...)
+		if d.HasChange("observability") {
+			diags = append(diags, checkObservabilityDestination(
+				d.Get("observability").([]interface{}), client,
+			)...)
+		}
+		diags = append(diags, checkVersionSkew(d)...)
+		diags = append(diags, checkHardwareMigration(d, client)...)
+		autoscalingDiags, err := updateDeployment(ctx, d, client)
+		diags = append(diags, autoscalingDiags...)
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
 		}
 	}
 
+	diags = append(diags, checkTrafficFilterDefaults(d, client)...)
 	if err := handleTrafficFilterChange(d, client); err != nil {
 		return diag.FromErr(err)
 	}
 
+	if d.HasChanges("traffic_filter", "traffic_filter_exclude", "traffic_filter_include_default") {
+		if err := handleTrafficFilterExclusions(d, client); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	if err := handleRemoteClusters(d, client); err != nil {
 		return diag.FromErr(err)
 	}
 
-	return readResource(ctx, d, meta)
+	return append(diags, readResource(ctx, d, meta)...)
 }
 
-func updateDeployment(_ context.Context, d *schema.ResourceData, client *api.API) error {
+// updateDeployment updates the deployment with the local configuration and
+// returns the informational diagnostics about its autoscaled tiers and
+// unsupported topology sizes, which are returned even when the update fails.
+func updateDeployment(ctx context.Context, d *schema.ResourceData, client *api.API) (diag.Diagnostics, error) {
+	if hasOnlyTagsChange(d) {
+		return nil, updateDeploymentTags(d, client)
+	}
+
 	req, err := updateResourceToModel(d, client)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := addTrustSelf(ctx, d, client, req.Resources.Elasticsearch); err != nil {
+		return nil, err
+	}
+
+	// The autoscaled tiers and the unsupported topology sizes are checked
+	// before the update is sent, so that the warnings are returned along any
+	// error which the update results in, such as a rejected size.
+	var diags diag.Diagnostics
+	if req.Resources != nil {
+		diags = checkAutoscaling(req.Resources.Elasticsearch)
+		diags = append(diags, checkDiscreteSizes(d, client, req.Resources.Elasticsearch)...)
+	}
+
+	overrides, err := OverrideVersions(d, req, deploymentapi.PayloadOverrides{
+		Version: d.Get("version").(string),
+		Region:  d.Get("region").(string),
+	})
+	if err != nil {
+		return diags, err
 	}
 
 	res, err := deploymentapi.Update(deploymentapi.UpdateParams{
 		API:          client,
 		DeploymentID: d.Id(),
 		Request:      req,
-		Overrides: deploymentapi.PayloadOverrides{
-			Version: d.Get("version").(string),
-			Region:  d.Get("region").(string),
-		},
+		Overrides:    *overrides,
 	})
+	dumpPayload("update", req)
 	if err != nil {
-		return multierror.NewPrefixed("failed updating deployment", err)
+		return diags, multierror.NewPrefixed("failed updating deployment", err)
+	}
+
+	if err := WaitForPlanCompletion(ctx, client, d.Id()); err != nil {
+		return diags, multierror.NewPrefixed("failed tracking update progress", err)
 	}
 
-	if err := WaitForPlanCompletion(client, d.Id()); err != nil {
-		return multierror.NewPrefixed("failed tracking update progress", err)
+	if err := setPlanHash(d, req.Resources); err != nil {
+		return diags, err
 	}
 
-	return parseCredentials(d, res.Resources)
+	if err := parseCredentials(d, res.Resources); err != nil {
+		return diags, err
+	}
+
+	return diags, nil
 }
 
 // hasDeploymentChange checks if there's any change in the resource attributes
-// except in the "traffic_filter" prefixed keys. If so, it returns true.
+// except in the ones which don't affect the deployment. If so, it returns true.
 func hasDeploymentChange(d *schema.ResourceData) bool {
 	for attr := range d.State().Attributes {
-		if strings.HasPrefix(attr, "traffic_filter") {
+		if !isDeploymentAttribute(attr) {
 			continue
 		}
 		// Check if any of the resource attributes has a change.
@@ -89,3 +144,47 @@ func hasDeploymentChange(d *schema.ResourceData) bool {
 	}
 	return false
 }
+
+// isDeploymentAttribute returns false for the "traffic_filter", "wait_for" and
+// "topology_aliases" prefixed keys, "verify_docker_images" and "poll_interval",
+// which don't affect the deployment.
+func isDeploymentAttribute(attr string) bool {
+	if strings.HasPrefix(attr, "traffic_filter") || strings.HasPrefix(attr, "wait_for") ||
+		strings.HasPrefix(attr, "topology_aliases") {
+		return false
+	}
+	return attr != "verify_docker_images" && attr != "poll_interval"
+}
+
+// hasOnlyTagsChange returns true when "tags" is the only deployment attribute
+// with a change, ignoring the attributes which don't affect the deployment.
+func hasOnlyTagsChange(d *schema.ResourceData) bool {
+	for attr := range d.State().Attributes {
+		if !isDeploymentAttribute(attr) || strings.HasPrefix(attr, "tags.") {
+			continue
+		}
+		if d.HasChange(attr) {
+			return false
+		}
+	}
+	return d.HasChange("tags")
+}
+
+// updateDeploymentTags sends an update request which only contains the
+// deployment metadata tags, including the system tags. Since orphans aren't pruned, the resources and
+// their topology are left untouched and no plan is applied.
+func updateDeploymentTags(d *schema.ResourceData, client *api.API) error {
+	if _, err := deploymentapi.Update(deploymentapi.UpdateParams{
+		API:          client,
+		DeploymentID: d.Id(),
+		Request: &models.DeploymentUpdateRequest{
+			PruneOrphans: ec.Bool(false),
+			Metadata: &models.DeploymentUpdateMetadata{
+				Tags: expandUpdateTags(d),
+			},
+		},
+	}); err != nil {
+		return multierror.NewPrefixed("failed updating deployment tags", err)
+	}
+	return nil
+}
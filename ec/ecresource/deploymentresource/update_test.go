@@ -18,9 +18,13 @@
 package deploymentresource
 
 import (
+	"context"
+	"net/url"
 	"testing"
 
+	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 
@@ -28,7 +32,7 @@ import (
 )
 
 func Test_hasDeploymentChange(t *testing.T) {
-	unchanged := Resource().Data(util.NewResourceData(t, util.ResDataParams{
+	unchanged := Resource(nil, nil, nil).Data(util.NewResourceData(t, util.ResDataParams{
 		ID:     mock.ValidClusterID,
 		Schema: newSchema(),
 		State:  newSampleLegacyDeployment(),
@@ -42,6 +46,22 @@ func Test_hasDeploymentChange(t *testing.T) {
 		},
 	})
 
+	changesToVerifyDockerImages := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State: map[string]interface{}{
+			"verify_docker_images": true,
+		},
+	})
+
+	changesToWaitFor := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State: map[string]interface{}{
+			"wait_for": []interface{}{"elasticsearch"},
+		},
+	})
+
 	changesToName := util.NewResourceData(t, util.ResDataParams{
 		ID:     mock.ValidClusterID,
 		Schema: newSchema(),
@@ -75,6 +95,16 @@ func Test_hasDeploymentChange(t *testing.T) {
 			args: args{d: changesToTrafficFilter},
 			want: false,
 		},
+		{
+			name: "when a new resource has some changes in verify_docker_images",
+			args: args{d: changesToVerifyDockerImages},
+			want: false,
+		},
+		{
+			name: "when a new resource has some changes in wait_for",
+			args: args{d: changesToWaitFor},
+			want: false,
+		},
 		{
 			name: "when a new resource is has some changes in name",
 			args: args{d: changesToName},
@@ -93,3 +123,158 @@ func Test_hasDeploymentChange(t *testing.T) {
 		})
 	}
 }
+
+func Test_hasOnlyTagsChange(t *testing.T) {
+	state := map[string]interface{}{
+		"name":   "some name",
+		"region": "some-region",
+		"tags":   map[string]interface{}{"owner": "elastic"},
+	}
+	changesToTags := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+		Change: map[string]interface{}{
+			"name":   "some name",
+			"region": "some-region",
+			"tags":   map[string]interface{}{"owner": "someone", "cost-center": "rnd"},
+		},
+	})
+
+	changesToTagsAndTrafficFilter := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+		Change: map[string]interface{}{
+			"name":           "some name",
+			"region":         "some-region",
+			"tags":           map[string]interface{}{"owner": "someone"},
+			"traffic_filter": []interface{}{"1.1.1.1"},
+		},
+	})
+
+	changesToTagsAndName := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+		Change: map[string]interface{}{
+			"name":   "some other name",
+			"region": "some-region",
+			"tags":   map[string]interface{}{"owner": "someone"},
+		},
+	})
+
+	unchanged := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+		Change: state,
+	})
+
+	type args struct {
+		d *schema.ResourceData
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "when only the tags change",
+			args: args{d: changesToTags},
+			want: true,
+		},
+		{
+			name: "when the tags and the traffic_filter change",
+			args: args{d: changesToTagsAndTrafficFilter},
+			want: true,
+		},
+		{
+			name: "when the tags and the name change",
+			args: args{d: changesToTagsAndName},
+			want: false,
+		},
+		{
+			name: "when nothing changes",
+			args: args{d: unchanged},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hasOnlyTagsChange(tt.args.d)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_updateDeploymentOnlyTags(t *testing.T) {
+	d := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State: map[string]interface{}{
+			"name":                   "some name",
+			"region":                 "us-east-1",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"version":                "7.10.1",
+			"tags":                   map[string]interface{}{"owner": "elastic"},
+		},
+		Change: map[string]interface{}{
+			"name":                   "some name",
+			"region":                 "us-east-1",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"version":                "7.10.1",
+			"tags":                   map[string]interface{}{"owner": "someone", "cost-center": "rnd"},
+		},
+	})
+
+	// A single request is expected, without fetching the deployment template
+	// nor tracking any plan.
+	client := api.NewMock(mock.New200ResponseAssertion(
+		&mock.RequestAssertion{
+			Host:   api.DefaultMockHost,
+			Header: api.DefaultWriteMockHeaders,
+			Method: "PUT",
+			Path:   "/api/v1/deployments/" + mock.ValidClusterID,
+			Query: url.Values{
+				"hide_pruned_orphans": []string{"false"},
+				"skip_snapshot":       []string{"false"},
+			},
+			Body: mock.NewStringBody(`{"metadata":{"tags":[{"key":"cost-center","value":"rnd"},{"key":"owner","value":"someone"}]},"prune_orphans":false}` + "\n"),
+		},
+		mock.NewStringBody("{}"),
+	))
+
+	diags, err := updateDeployment(context.Background(), d, client)
+	assert.NoError(t, err)
+	assert.Empty(t, diags)
+}
+
+func Test_updateDeploymentSizeWarnings(t *testing.T) {
+	state := newSampleLegacyDeployment()
+	change := newSampleLegacyDeployment()
+	change["elasticsearch"].([]interface{})[0].(map[string]interface{})["topology"].([]interface{})[0].(map[string]interface{})["size"] = "16g"
+	d := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+		Change: change,
+	})
+	template := func() mock.Response {
+		return mock.New200Response(fileAsResponseBody(t, "testdata/template-aws-io-optimized-v2.json"))
+	}
+
+	// The deployment template is obtained to expand the update and to check
+	// the topology sizes, before the update is sent.
+	client := api.NewMock(template(), template(), mock.NewErrorResponse(400, mock.APIError{
+		Code: "deployments.invalid_size", Message: "invalid size",
+	}))
+
+	diags, err := updateDeployment(context.Background(), d, client)
+	assert.EqualError(t, err, "failed updating deployment: 1 error occurred:\n\t* api error: deployments.invalid_size: invalid size\n\n")
+	assert.Equal(t, diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  `elasticsearch topology hot_content size "16g" isn't supported by the "aws.data.highio.i3" instance configuration`,
+		Detail:   `The size may be rounded or rejected by the API. The nearest valid sizes are: "15g", "29g".`,
+	}}, diags)
+}
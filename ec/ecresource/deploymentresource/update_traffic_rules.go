@@ -18,13 +18,22 @@
 package deploymentresource
 
 import (
+	"fmt"
+
 	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/trafficfilterapi"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
 
+// handleTrafficFilterChange associates the rulesets which have been added to
+// "traffic_filter" and removes the association of the ones which have been
+// removed from it. The rulesets present in both the state and the
+// configuration are left untouched.
 func handleTrafficFilterChange(d *schema.ResourceData, client *api.API) error {
 	if !d.HasChange("traffic_filter") {
 		return nil
@@ -46,6 +55,187 @@ func handleTrafficFilterChange(d *schema.ResourceData, client *api.API) error {
 	return nil
 }
 
+// handleTrafficFilterExclusions removes the association between the deployment
+// and any of the rulesets set in "traffic_filter_exclude" which are associated
+// with it, as well as the rulesets which are included by default in the region
+// when "traffic_filter_include_default" is false. This is necessary for
+// rulesets which have "include_by_default" set, since those are associated to
+// the deployment by the API regardless of the request. All the associated
+// rulesets are checked, not only the ones added to "traffic_filter_exclude",
+// and the ones which are part of "traffic_filter" are left untouched.
+//
+// The default rulesets which were excluded by the previous values and aren't
+// anymore, either because "traffic_filter_include_default" has been set back
+// to true or because they've been removed from "traffic_filter_exclude", are
+// associated with the deployment again.
+func handleTrafficFilterExclusions(d *schema.ResourceData, client *api.API) error {
+	oldExclude, newExclude := d.GetChange("traffic_filter_exclude")
+	oldInclude, newInclude := d.GetChange("traffic_filter_include_default")
+	wasExcluded := newDefaultExclusion(oldExclude, oldInclude)
+	excluded := newDefaultExclusion(newExclude, newInclude)
+	if !wasExcluded.any() && !excluded.any() {
+		return nil
+	}
+
+	res, err := trafficfilterapi.List(trafficfilterapi.ListParams{
+		API: client, Region: d.Get("region").(string), IncludeAssociations: true,
+	})
+	if err != nil {
+		return multierror.NewPrefixed("failed listing traffic filter rulesets", err)
+	}
+
+	filters, _ := d.Get("traffic_filter").(*schema.Set)
+	for _, ruleset := range res.Rulesets {
+		if ruleset == nil || ruleset.ID == nil {
+			continue
+		}
+
+		if filters != nil && filters.Contains(*ruleset.ID) {
+			continue
+		}
+
+		associated := isAssociated(ruleset, d.Id())
+		switch {
+		case associated && excluded.matches(ruleset):
+			if err := trafficfilterapi.DeleteAssociation(trafficfilterapi.DeleteAssociationParams{
+				API:        client,
+				ID:         *ruleset.ID,
+				EntityID:   d.Id(),
+				EntityType: "deployment",
+			}); err != nil {
+				return err
+			}
+		case !associated && includedByDefault(ruleset) &&
+			wasExcluded.matches(ruleset) && !excluded.matches(ruleset):
+			if err := trafficfilterapi.CreateAssociation(trafficfilterapi.CreateAssociationParams{
+				API:        client,
+				ID:         *ruleset.ID,
+				EntityID:   d.Id(),
+				EntityType: "deployment",
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// defaultExclusion holds the values of "traffic_filter_exclude" and
+// "traffic_filter_include_default".
+type defaultExclusion struct {
+	exclude        *schema.Set
+	includeDefault bool
+}
+
+func newDefaultExclusion(exclude, includeDefault interface{}) defaultExclusion {
+	set, _ := exclude.(*schema.Set)
+	include, _ := includeDefault.(bool)
+	return defaultExclusion{exclude: set, includeDefault: include}
+}
+
+// any returns true when the values can exclude any ruleset.
+func (e defaultExclusion) any() bool {
+	return !e.includeDefault || (e.exclude != nil && e.exclude.Len() > 0)
+}
+
+// matches returns true when the ruleset is excluded from the deployment.
+func (e defaultExclusion) matches(ruleset *models.TrafficFilterRulesetInfo) bool {
+	if !e.includeDefault && includedByDefault(ruleset) {
+		return true
+	}
+	return e.exclude != nil && e.exclude.Contains(*ruleset.ID)
+}
+
+// includedByDefault returns true when the ruleset is included by default in
+// its region.
+func includedByDefault(ruleset *models.TrafficFilterRulesetInfo) bool {
+	return ruleset.IncludeByDefault != nil && *ruleset.IncludeByDefault
+}
+
+// isAssociated returns true when the ruleset is associated with the
+// deployment.
+func isAssociated(ruleset *models.TrafficFilterRulesetInfo, deploymentID string) bool {
+	for _, assoc := range ruleset.Associations {
+		if assoc.ID != nil && *assoc.ID == deploymentID {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenTrafficFilterDefaults sets "traffic_filter_include_default" from the
+// association of the rulesets which are included by default in the region and
+// aren't part of "traffic_filter" nor "traffic_filter_exclude", so that these
+// being associated or having their association removed outside of Terraform
+// shows up as a change. The value is only changed when the associations
+// contradict it, since it can't be told apart when there are no such rulesets.
+func flattenTrafficFilterDefaults(d *schema.ResourceData, filters *schema.Set, client *api.API) error {
+	res, err := trafficfilterapi.List(trafficfilterapi.ListParams{
+		API: client, Region: d.Get("region").(string), IncludeAssociations: true,
+	})
+	if err != nil {
+		return multierror.NewPrefixed("failed listing traffic filter rulesets", err)
+	}
+
+	includeDefault := d.Get("traffic_filter_include_default").(bool)
+	exclude, _ := d.Get("traffic_filter_exclude").(*schema.Set)
+	for _, ruleset := range res.Rulesets {
+		if ruleset == nil || ruleset.ID == nil || !includedByDefault(ruleset) {
+			continue
+		}
+
+		if filters != nil && filters.Contains(*ruleset.ID) {
+			continue
+		}
+
+		if exclude != nil && exclude.Contains(*ruleset.ID) {
+			continue
+		}
+
+		if isAssociated(ruleset, d.Id()) != includeDefault {
+			return d.Set("traffic_filter_include_default", !includeDefault)
+		}
+	}
+
+	return nil
+}
+
+// checkTrafficFilterDefaults returns a warning for each ruleset removed from
+// "traffic_filter" which is included by default in the region, unless it's
+// also part of "traffic_filter_exclude". Removing such a ruleset drops its
+// association with the deployment, which is rarely intended. Any errors
+// obtaining the rulesets are ignored, since these are handled when the
+// traffic filter changes are applied.
+func checkTrafficFilterDefaults(d *schema.ResourceData, client *api.API) diag.Diagnostics {
+	if !d.HasChange("traffic_filter") || !d.Get("traffic_filter_include_default").(bool) {
+		return nil
+	}
+
+	var _, deletions = getChange(d.GetChange("traffic_filter"))
+	if exclude, ok := d.Get("traffic_filter_exclude").(*schema.Set); ok {
+		deletions = deletions.Difference(exclude)
+	}
+
+	var diags diag.Diagnostics
+	for _, ruleID := range deletions.List() {
+		res, err := trafficfilterapi.Get(trafficfilterapi.GetParams{
+			API: client, ID: ruleID.(string),
+		})
+		if err != nil || res.IncludeByDefault == nil || !*res.IncludeByDefault {
+			continue
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf(`traffic filter ruleset "%s" is included by default in the region`, ruleID),
+			Detail:   `Removing it from "traffic_filter" removes its association with the deployment. Add it to "traffic_filter_exclude" to exclude it explicitly.`,
+		})
+	}
+
+	return diags
+}
+
 func getChange(oldInterface, newInterface interface{}) (add, delete *schema.Set) {
 	var old, new *schema.Set
 	if s, ok := oldInterface.(*schema.Set); ok {
@@ -18,10 +18,18 @@
 package deploymentresource
 
 import (
+	"net/url"
 	"testing"
 
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
 
 func Test_getChange(t *testing.T) {
@@ -94,3 +102,501 @@ func Test_getChange(t *testing.T) {
 		})
 	}
 }
+
+func Test_handleTrafficFilterChange(t *testing.T) {
+	newRD := func(current, desired []interface{}) *schema.ResourceData {
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State: map[string]interface{}{
+				"deployment_template_id": "aws-io-optimized-v2",
+				"region":                 "us-east-1",
+				"version":                "7.10.1",
+				"traffic_filter":         current,
+			},
+			Change: map[string]interface{}{
+				"deployment_template_id": "aws-io-optimized-v2",
+				"region":                 "us-east-1",
+				"version":                "7.10.1",
+				"traffic_filter":         desired,
+			},
+		})
+	}
+	getRuleset := func(id, body string) mock.Response {
+		return mock.New200ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultReadMockHeaders,
+				Method: "GET",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets/" + id,
+				Query: url.Values{
+					"include_associations": []string{"true"},
+				},
+			},
+			mock.NewStringBody(body),
+		)
+	}
+	deleteAssociation := func(id string) mock.Response {
+		return mock.New200ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultReadMockHeaders,
+				Method: "DELETE",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets/" + id + "/associations/deployment/" + mock.ValidClusterID,
+			},
+			mock.NewStringBody("{}"),
+		)
+	}
+	associated := `{"id": "rule-c", "associations": [{"id": "` + mock.ValidClusterID + `", "entity_type": "deployment"}]}`
+
+	type args struct {
+		d      *schema.ResourceData
+		client *api.API
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "doesn't call the API when the rulesets haven't changed",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-b"},
+					[]interface{}{"rule-b", "rule-a"},
+				),
+				client: api.NewMock(),
+			},
+		},
+		{
+			name: "only removes the association of the ruleset removed from the set",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-b", "rule-c"},
+					[]interface{}{"rule-a", "rule-b"},
+				),
+				client: api.NewMock(
+					getRuleset("rule-c", associated),
+					deleteAssociation("rule-c"),
+				),
+			},
+		},
+		{
+			name: "only associates the added ruleset and removes the removed ruleset",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-c"},
+					[]interface{}{"rule-a", "rule-d"},
+				),
+				client: api.NewMock(
+					getRuleset("rule-d", `{"id": "rule-d"}`),
+					mock.New201Response(mock.NewStringBody("{}")),
+					getRuleset("rule-c", associated),
+					deleteAssociation("rule-c"),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, handleTrafficFilterChange(tt.args.d, tt.args.client))
+		})
+	}
+}
+
+func Test_checkTrafficFilterDefaults(t *testing.T) {
+	newRD := func(current, desired, exclude []interface{}) *schema.ResourceData {
+		change := map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "7.10.1",
+			"traffic_filter":         desired,
+		}
+		if len(exclude) > 0 {
+			change["traffic_filter_exclude"] = exclude
+		}
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State: map[string]interface{}{
+				"deployment_template_id": "aws-io-optimized-v2",
+				"region":                 "us-east-1",
+				"version":                "7.10.1",
+				"traffic_filter":         current,
+			},
+			Change: change,
+		})
+	}
+	ruleset := func(id string, includeByDefault bool) mock.Response {
+		return mock.New200StructResponse(models.TrafficFilterRulesetInfo{
+			ID: ec.String(id), IncludeByDefault: ec.Bool(includeByDefault),
+		})
+	}
+
+	type args struct {
+		d      *schema.ResourceData
+		client *api.API
+	}
+	tests := []struct {
+		name string
+		args args
+		want diag.Diagnostics
+	}{
+		{
+			name: "doesn't call the API when the rulesets haven't changed",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a"},
+					[]interface{}{"rule-a"},
+					nil,
+				),
+				client: api.NewMock(),
+			},
+		},
+		{
+			name: "doesn't warn when the removed ruleset isn't included by default",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-b"},
+					[]interface{}{"rule-a"},
+					nil,
+				),
+				client: api.NewMock(ruleset("rule-b", false)),
+			},
+		},
+		{
+			name: "warns when a ruleset included by default would be dropped",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-b"},
+					[]interface{}{"rule-a"},
+					nil,
+				),
+				client: api.NewMock(ruleset("rule-b", true)),
+			},
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `traffic filter ruleset "rule-b" is included by default in the region`,
+				Detail:   `Removing it from "traffic_filter" removes its association with the deployment. Add it to "traffic_filter_exclude" to exclude it explicitly.`,
+			}},
+		},
+		{
+			name: "doesn't warn when the ruleset included by default is explicitly excluded",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-b"},
+					[]interface{}{"rule-a"},
+					[]interface{}{"rule-b"},
+				),
+				client: api.NewMock(),
+			},
+		},
+		{
+			name: "ignores the errors obtaining the removed ruleset",
+			args: args{
+				d: newRD(
+					[]interface{}{"rule-a", "rule-b"},
+					[]interface{}{"rule-a"},
+					nil,
+				),
+				client: api.NewMock(mock.NewErrorResponse(404, mock.APIError{
+					Code: "traffic_filter.not_found", Message: "not found",
+				})),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkTrafficFilterDefaults(tt.args.d, tt.args.client)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_handleTrafficFilterExclusions(t *testing.T) {
+	type exclusions struct {
+		exclude        []interface{}
+		includeDefault bool
+	}
+	newRD := func(filters []interface{}, old, new exclusions) *schema.ResourceData {
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State: map[string]interface{}{
+				"deployment_template_id":         "aws-io-optimized-v2",
+				"region":                         "us-east-1",
+				"version":                        "7.10.1",
+				"traffic_filter":                 filters,
+				"traffic_filter_exclude":         old.exclude,
+				"traffic_filter_include_default": old.includeDefault,
+			},
+			Change: map[string]interface{}{
+				"deployment_template_id":         "aws-io-optimized-v2",
+				"region":                         "us-east-1",
+				"version":                        "7.10.1",
+				"traffic_filter":                 filters,
+				"traffic_filter_exclude":         new.exclude,
+				"traffic_filter_include_default": new.includeDefault,
+			},
+		})
+	}
+	listRulesets := func() mock.Response {
+		return mock.New200ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultReadMockHeaders,
+				Method: "GET",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets",
+				Query: url.Values{
+					"include_associations": []string{"true"},
+					"region":               []string{"us-east-1"},
+				},
+			},
+			mock.NewStringBody(`{"rulesets": [
+				{"id": "rule-a", "include_by_default": true, "associations": [{"id": "`+mock.ValidClusterID+`", "entity_type": "deployment"}]},
+				{"id": "rule-b", "include_by_default": false, "associations": [{"id": "`+mock.ValidClusterID+`", "entity_type": "deployment"}]},
+				{"id": "rule-c", "include_by_default": true, "associations": [{"id": "`+mock.ValidClusterID+`", "entity_type": "deployment"}]},
+				{"id": "rule-d", "include_by_default": true, "associations": []}
+			]}`),
+		)
+	}
+	deleteAssociation := func(id string) mock.Response {
+		return mock.New200ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultReadMockHeaders,
+				Method: "DELETE",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets/" + id + "/associations/deployment/" + mock.ValidClusterID,
+			},
+			mock.NewStringBody("{}"),
+		)
+	}
+	createAssociation := func(id string) mock.Response {
+		return mock.New200ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultWriteMockHeaders,
+				Method: "POST",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets/" + id + "/associations",
+				Body:   mock.NewStringBody(`{"entity_type":"deployment","id":"` + mock.ValidClusterID + `"}` + "\n"),
+			},
+			mock.NewStringBody("{}"),
+		)
+	}
+
+	type args struct {
+		d      *schema.ResourceData
+		client *api.API
+	}
+	tests := []struct {
+		name string
+		args args
+		err  string
+	}{
+		{
+			name: "doesn't call the API without exclusions",
+			args: args{
+				d: newRD([]interface{}{"rule-b"},
+					exclusions{includeDefault: true},
+					exclusions{includeDefault: true},
+				),
+				client: api.NewMock(),
+			},
+		},
+		{
+			name: "removes the association of all the associated excluded rulesets",
+			args: args{
+				d: newRD([]interface{}{"rule-c"},
+					exclusions{includeDefault: true},
+					exclusions{exclude: []interface{}{"rule-a", "rule-b", "rule-d"}, includeDefault: true},
+				),
+				client: api.NewMock(listRulesets(), deleteAssociation("rule-a"), deleteAssociation("rule-b")),
+			},
+		},
+		{
+			name: "removes the association of the default rulesets which aren't in the traffic filters",
+			args: args{
+				d: newRD([]interface{}{"rule-b", "rule-c"},
+					exclusions{includeDefault: true},
+					exclusions{includeDefault: false},
+				),
+				client: api.NewMock(listRulesets(), deleteAssociation("rule-a")),
+			},
+		},
+		{
+			name: "removes the association of both the default and the excluded rulesets",
+			args: args{
+				d: newRD([]interface{}{"rule-c"},
+					exclusions{includeDefault: true},
+					exclusions{exclude: []interface{}{"rule-b"}, includeDefault: false},
+				),
+				client: api.NewMock(listRulesets(), deleteAssociation("rule-a"), deleteAssociation("rule-b")),
+			},
+		},
+		{
+			name: "associates the default rulesets again when these are included by default again",
+			args: args{
+				d: newRD([]interface{}{"rule-c"},
+					exclusions{includeDefault: false},
+					exclusions{includeDefault: true},
+				),
+				client: api.NewMock(listRulesets(), createAssociation("rule-d")),
+			},
+		},
+		{
+			name: "associates the default rulesets which have been removed from the exclusions",
+			args: args{
+				d: newRD(nil,
+					exclusions{exclude: []interface{}{"rule-d"}, includeDefault: true},
+					exclusions{includeDefault: true},
+				),
+				client: api.NewMock(listRulesets(), createAssociation("rule-d")),
+			},
+		},
+		{
+			name: "doesn't associate the rulesets removed from the exclusions which are still excluded by default",
+			args: args{
+				d: newRD([]interface{}{"rule-c"},
+					exclusions{exclude: []interface{}{"rule-d"}, includeDefault: false},
+					exclusions{includeDefault: false},
+				),
+				client: api.NewMock(listRulesets(), deleteAssociation("rule-a")),
+			},
+		},
+		{
+			name: "doesn't associate the default rulesets which weren't excluded",
+			args: args{
+				d: newRD(nil,
+					exclusions{exclude: []interface{}{"rule-b"}, includeDefault: true},
+					exclusions{includeDefault: true},
+				),
+				client: api.NewMock(listRulesets()),
+			},
+		},
+		{
+			name: "returns the error listing the rulesets",
+			args: args{
+				d: newRD(nil,
+					exclusions{includeDefault: true},
+					exclusions{exclude: []interface{}{"rule-a"}, includeDefault: true},
+				),
+				client: api.NewMock(mock.NewErrorResponse(500, mock.APIError{
+					Code: "some", Message: "message",
+				})),
+			},
+			err: "failed listing traffic filter rulesets: 1 error occurred:\n\t* api error: some: message\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := handleTrafficFilterExclusions(tt.args.d, tt.args.client)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_flattenTrafficFilterDefaults(t *testing.T) {
+	newRD := func(exclude []interface{}, includeDefault bool) *schema.ResourceData {
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State: map[string]interface{}{
+				"deployment_template_id":         "aws-io-optimized-v2",
+				"region":                         "us-east-1",
+				"version":                        "7.10.1",
+				"traffic_filter_exclude":         exclude,
+				"traffic_filter_include_default": includeDefault,
+			},
+		})
+	}
+	listRulesets := func() mock.Response {
+		return mock.New200ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultReadMockHeaders,
+				Method: "GET",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets",
+				Query: url.Values{
+					"include_associations": []string{"true"},
+					"region":               []string{"us-east-1"},
+				},
+			},
+			mock.NewStringBody(`{"rulesets": [
+				{"id": "rule-a", "include_by_default": true, "associations": [{"id": "`+mock.ValidClusterID+`", "entity_type": "deployment"}]},
+				{"id": "rule-b", "include_by_default": false, "associations": []},
+				{"id": "rule-c", "include_by_default": true, "associations": []}
+			]}`),
+		)
+	}
+
+	type args struct {
+		d       *schema.ResourceData
+		filters *schema.Set
+		client  *api.API
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+		err  string
+	}{
+		{
+			name: "keeps the default rulesets included when all of them are associated",
+			args: args{
+				d:      newRD([]interface{}{"rule-c"}, true),
+				client: api.NewMock(listRulesets()),
+			},
+			want: true,
+		},
+		{
+			name: "unsets the inclusion when the association of a default ruleset has been removed",
+			args: args{
+				d:      newRD(nil, true),
+				client: api.NewMock(listRulesets()),
+			},
+			want: false,
+		},
+		{
+			name: "keeps the default rulesets excluded when none of them are associated",
+			args: args{
+				d:       newRD(nil, false),
+				filters: schema.NewSet(schema.HashString, []interface{}{"rule-a"}),
+				client:  api.NewMock(listRulesets()),
+			},
+			want: false,
+		},
+		{
+			name: "sets the inclusion when a default ruleset has been associated",
+			args: args{
+				d:      newRD(nil, false),
+				client: api.NewMock(listRulesets()),
+			},
+			want: true,
+		},
+		{
+			name: "returns the error listing the rulesets",
+			args: args{
+				d: newRD(nil, true),
+				client: api.NewMock(mock.NewErrorResponse(500, mock.APIError{
+					Code: "some", Message: "message",
+				})),
+			},
+			want: true,
+			err:  "failed listing traffic filter rulesets: 1 error occurred:\n\t* api error: some: message\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := flattenTrafficFilterDefaults(tt.args.d, tt.args.filters, tt.args.client)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, tt.args.d.Get("traffic_filter_include_default"))
+		})
+	}
+}
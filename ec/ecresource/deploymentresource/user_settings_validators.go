@@ -0,0 +1,182 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// largeUserSettingsSize is the size in bytes of each of the user settings
+// above which a warning is returned. It isn't a documented API limit, but the
+// API has been seen rejecting larger request payloads with a 413.
+const largeUserSettingsSize = 64 * 1024
+
+// validateUserSettingsSize is a schema.SchemaValidateFunc which warns about
+// the user settings larger than largeUserSettingsSize, regardless of whether
+// they are set inline or read from a file, reporting the exact overrun. The
+// settings are left to the API to reject.
+func validateUserSettingsSize(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+
+	if size := len(v); size > largeUserSettingsSize {
+		return []string{fmt.Sprintf(
+			"%s is %d bytes, which exceeds %d bytes by %d bytes and might be rejected by the API",
+			k, size, largeUserSettingsSize, size-largeUserSettingsSize,
+		)}, nil
+	}
+
+	return nil, nil
+}
+
+// userSettingsValidator validates the Elasticsearch user settings, which are
+// flattened into their dotted setting names regardless of whether these were
+// set as YAML or JSON.
+type userSettingsValidator interface {
+	Validate(settings map[string]interface{}) error
+}
+
+// forbiddenSettings is a userSettingsValidator which rejects the settings
+// which can't be set by users, keyed by setting name to the reason why.
+type forbiddenSettings map[string]string
+
+// Validate returns an error for each of the forbidden settings which is set.
+func (f forbiddenSettings) Validate(settings map[string]interface{}) error {
+	merr := multierror.NewPrefixed("forbidden user settings")
+	for _, name := range sortedSettingNames(settings) {
+		if reason, ok := f[name]; ok {
+			merr = merr.Append(fmt.Errorf(`"%s" %s`, name, reason))
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+// defaultUserSettingsValidators are the validators applied to the
+// Elasticsearch user settings, covering a handful of common mistakes.
+var defaultUserSettingsValidators = []userSettingsValidator{
+	forbiddenSettings{
+		"node.roles":  "conflicts with the node roles derived from the topology",
+		"node.master": "conflicts with the node roles derived from the topology",
+		"node.data":   "conflicts with the node roles derived from the topology",
+		"node.ingest": "conflicts with the node roles derived from the topology",
+		"node.ml":     "conflicts with the node roles derived from the topology",
+		"path.data":   "is managed by the platform",
+		"path.logs":   "is managed by the platform",
+	},
+}
+
+// checkUserSettings returns a CustomizeDiff function which validates the
+// Elasticsearch user settings with the validators.
+func checkUserSettings(validators ...userSettingsValidator) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		if !d.HasChange("elasticsearch") {
+			return nil
+		}
+
+		var keys = []string{
+			"elasticsearch.0.config.0.user_settings_yaml",
+			"elasticsearch.0.config.0.user_settings_json",
+		}
+		if topology, ok := d.Get("elasticsearch.0.topology").([]interface{}); ok {
+			for i := range topology {
+				keys = append(keys,
+					fmt.Sprintf("elasticsearch.0.topology.%d.config.0.user_settings_yaml", i),
+					fmt.Sprintf("elasticsearch.0.topology.%d.config.0.user_settings_json", i),
+				)
+			}
+		}
+
+		merr := multierror.NewPrefixed("invalid elasticsearch user settings")
+		for _, key := range keys {
+			raw, _ := d.Get(key).(string)
+			merr = merr.Append(validateUserSettings(key, raw, validators))
+		}
+
+		return merr.ErrorOrNil()
+	}
+}
+
+// validateUserSettings parses the YAML or JSON user settings set in the key
+// and validates them with the validators. Settings which can't be parsed are
+// left to the API to reject.
+func validateUserSettings(key, raw string, validators []userSettingsValidator) error {
+	if raw == "" {
+		return nil
+	}
+
+	// JSON is a subset of YAML, so both are parsed as YAML documents.
+	docs, err := parseYamlDocuments(raw)
+	if err != nil {
+		return nil
+	}
+
+	settings := make(map[string]interface{})
+	for _, doc := range docs {
+		flattenSettingNames("", doc, settings)
+	}
+
+	merr := multierror.NewPrefixed(key)
+	for _, v := range validators {
+		merr = merr.Append(v.Validate(settings))
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// flattenSettingNames flattens the nested settings into their dotted setting
+// names, so that "node: {roles: []}" and "node.roles: []" are equivalent.
+func flattenSettingNames(prefix string, in interface{}, out map[string]interface{}) {
+	var m = make(map[string]interface{})
+	switch v := in.(type) {
+	case map[interface{}]interface{}:
+		for k, value := range v {
+			m[fmt.Sprint(k)] = value
+		}
+	case map[string]interface{}:
+		m = v
+	default:
+		if prefix != "" {
+			out[prefix] = in
+		}
+		return
+	}
+
+	for k, value := range m {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		flattenSettingNames(name, value, out)
+	}
+}
+
+func sortedSettingNames(settings map[string]interface{}) []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
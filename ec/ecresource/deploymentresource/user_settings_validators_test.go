@@ -0,0 +1,112 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateUserSettings(t *testing.T) {
+	const key = "elasticsearch.0.config.0.user_settings_yaml"
+	tests := []struct {
+		name string
+		raw  string
+		err  error
+	}{
+		{
+			name: "accepts empty settings",
+		},
+		{
+			name: "accepts allowed settings",
+			raw:  "action.auto_create_index: true\nxpack.monitoring.collection.interval: 30s",
+		},
+		{
+			name: "flags a node.roles user setting",
+			raw:  "node.roles: [master, data]",
+			err:  errors.New("elasticsearch.0.config.0.user_settings_yaml: 1 error occurred:\n\t* forbidden user settings: \"node.roles\" conflicts with the node roles derived from the topology\n\n"),
+		},
+		{
+			name: "flags a nested node.roles user setting",
+			raw:  "node:\n  roles:\n    - master\n",
+			err:  errors.New("elasticsearch.0.config.0.user_settings_yaml: 1 error occurred:\n\t* forbidden user settings: \"node.roles\" conflicts with the node roles derived from the topology\n\n"),
+		},
+		{
+			name: "flags a node.roles user setting set as json",
+			raw:  `{"node": {"roles": ["master"]}, "path.data": "/data"}`,
+			err:  errors.New("elasticsearch.0.config.0.user_settings_yaml: 2 errors occurred:\n\t* forbidden user settings: \"node.roles\" conflicts with the node roles derived from the topology\n\t* forbidden user settings: \"path.data\" is managed by the platform\n\n"),
+		},
+		{
+			name: "leaves invalid settings to the API",
+			raw:  "node.roles: [master",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUserSettings(key, tt.raw, defaultUserSettingsValidators)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_validateUserSettingsSize(t *testing.T) {
+	const key = "kibana.0.config.0.user_settings_yaml"
+	tests := []struct {
+		name  string
+		val   interface{}
+		warns []string
+		errs  []error
+	}{
+		{
+			name: "accepts empty settings",
+			val:  "",
+		},
+		{
+			name: "accepts settings of the warning size",
+			val:  strings.Repeat("a", largeUserSettingsSize),
+		},
+		{
+			name: "warns about settings larger than the warning size with the overrun",
+			val:  strings.Repeat("a", largeUserSettingsSize+10),
+			warns: []string{
+				"kibana.0.config.0.user_settings_yaml is 65546 bytes, which exceeds 65536 bytes by 10 bytes and might be rejected by the API",
+			},
+		},
+		{
+			name: "rejects a non string value",
+			val:  1,
+			errs: []error{errors.New(
+				"expected type of kibana.0.config.0.user_settings_yaml to be string",
+			)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warns, errs := validateUserSettingsSize(tt.val, key)
+			assert.Equal(t, tt.warns, warns)
+			assert.Equal(t, tt.errs, errs)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceKinds holds the deployment resource kinds in the order in which
+// they're validated.
+var resourceKinds = []string{
+	"elasticsearch", "kibana", "apm", "integrations_server", "enterprise_search",
+}
+
+// checkRefIDs is a CustomizeDiff function which ensures that the ref_id of
+// every resource is unique across the deployment and that every
+// elasticsearch_cluster_ref_id references a declared Elasticsearch resource,
+// once the omitted ones are derived from the single Elasticsearch resource.
+func checkRefIDs(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	resources := make(map[string][]interface{}, len(resourceKinds))
+	for _, kind := range resourceKinds {
+		if raw, ok := d.Get(kind).([]interface{}); ok {
+			resources[kind] = raw
+		}
+	}
+	deriveEsClusterRefIDs(resources["elasticsearch"],
+		resources["kibana"], resources["apm"], resources["integrations_server"], resources["enterprise_search"],
+	)
+	return validateRefIDs(resources)
+}
+
+// validateRefIDs receives the flattened deployment resources keyed by their
+// kind and returns an error naming all the offending blocks.
+func validateRefIDs(resources map[string][]interface{}) error {
+	merr := multierror.NewPrefixed("invalid ref_id configuration")
+
+	seen := make(map[string]string)
+	esRefIDs := make(map[string]bool)
+	for _, kind := range resourceKinds {
+		for i, raw := range resources[kind] {
+			res, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			refID, _ := res["ref_id"].(string)
+			if refID == "" {
+				continue
+			}
+
+			block := fmt.Sprintf("%s.%d", kind, i)
+			if other, ok := seen[refID]; ok {
+				merr = merr.Append(fmt.Errorf(
+					`%s.ref_id "%s" is already used by %s`, block, refID, other,
+				))
+				continue
+			}
+			seen[refID] = block
+
+			if kind == "elasticsearch" {
+				esRefIDs[refID] = true
+			}
+		}
+	}
+
+	for _, kind := range resourceKinds[1:] {
+		for i, raw := range resources[kind] {
+			res, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			esRefID, _ := res["elasticsearch_cluster_ref_id"].(string)
+			if esRefID == "" || esRefIDs[esRefID] {
+				continue
+			}
+
+			merr = merr.Append(fmt.Errorf(
+				`%s.%d.elasticsearch_cluster_ref_id "%s" doesn't match any elasticsearch ref_id`,
+				kind, i, esRefID,
+			))
+		}
+	}
+
+	return merr.ErrorOrNil()
+}
+
+// validateTagKeys is a ValidateFunc for the "tags" map which rejects keys that
+// only differ in their case, since the API may treat them as the same key.
+func validateTagKeys(i interface{}, k string) ([]string, []error) {
+	tags, ok := i.(map[string]interface{})
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be a map", k)}
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		lower := strings.ToLower(key)
+		if other, ok := seen[lower]; ok {
+			errs = append(errs, fmt.Errorf(
+				`%s: key "%s" conflicts with key "%s", tag keys are case-insensitive`,
+				k, key, other,
+			))
+			continue
+		}
+		seen[lower] = key
+	}
+
+	return nil, errs
+}
+
+// VersionSettings holds the provider settings which affect the validation of
+// the deployment version.
+type VersionSettings struct {
+	// AllowPrerelease accepts pre-release and snapshot stack versions, such
+	// as "8.3.0-SNAPSHOT", which are rejected otherwise.
+	AllowPrerelease bool
+}
+
+// checkVersion returns a CustomizeDiff function which validates the deployment
+// version when it changes, with the provider settings.
+func checkVersion(settings *VersionSettings) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		if !d.HasChange("version") || !d.NewValueKnown("version") {
+			return nil
+		}
+
+		allowPrerelease := settings != nil && settings.AllowPrerelease
+		return validateVersion(d.Get("version").(string), allowPrerelease)
+	}
+}
+
+// validateVersion ensures the version is a valid semantic version, which is
+// only allowed to be a pre-release version when allowPrerelease is set.
+func validateVersion(version string, allowPrerelease bool) error {
+	v, err := semver.Parse(version)
+	if err != nil {
+		return fmt.Errorf(`invalid version "%s": %w`, version, err)
+	}
+
+	if len(v.Pre) > 0 && !allowPrerelease {
+		return fmt.Errorf(
+			`pre-release version "%s" is not allowed: set "allow_prerelease_versions" in the provider configuration to use it`,
+			version,
+		)
+	}
+
+	return nil
+}
+
+// minAnonymousAccessVersion is the minimum Elasticsearch version which
+// supports the typed security settings, since security is only available
+// on the basic license since 6.8.0.
+var minAnonymousAccessVersion = semver.MustParse("6.8.0")
+
+// checkSecuritySettings ensures the typed Elasticsearch security settings are
+// only set on the versions which support them.
+func checkSecuritySettings(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("version") {
+		return nil
+	}
+
+	roles, _ := d.Get("elasticsearch.0.config.0.anonymous_access_roles").(*schema.Set)
+	username, _ := d.Get("elasticsearch.0.config.0.anonymous_access_username").(string)
+	if (roles == nil || roles.Len() == 0) && username == "" {
+		return nil
+	}
+
+	return validateSecuritySettingsVersion(d.Get("version").(string))
+}
+
+// validateSecuritySettingsVersion ensures the version supports the typed
+// Elasticsearch security settings. Invalid versions are reported by
+// checkVersion.
+func validateSecuritySettingsVersion(version string) error {
+	v, err := semver.Parse(version)
+	if err != nil {
+		return nil
+	}
+
+	if v.LT(minAnonymousAccessVersion) {
+		return fmt.Errorf(
+			`anonymous access settings require an Elasticsearch version of %s or higher, got "%s"`,
+			minAnonymousAccessVersion, version,
+		)
+	}
+
+	return nil
+}
+
+// integrationsServerVersion is the first version in which the Integrations
+// Server resource supersedes the APM resource.
+var integrationsServerVersion = semver.MustParse("8.0.0")
+
+// checkApmIntegrationsServer ensures the "apm" and "integrations_server"
+// blocks aren't declared in the same deployment.
+func checkApmIntegrationsServer(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	apm, _ := d.Get("apm").([]interface{})
+	integrationsServer, _ := d.Get("integrations_server").([]interface{})
+	if len(apm) == 0 || len(integrationsServer) == 0 {
+		return nil
+	}
+
+	var version string
+	if d.NewValueKnown("version") {
+		version, _ = d.Get("version").(string)
+	}
+	return validateApmIntegrationsServer(version)
+}
+
+// validateApmIntegrationsServer returns the error for a deployment which
+// declares both the "apm" and "integrations_server" blocks, naming the block
+// to keep for the version when it's known.
+func validateApmIntegrationsServer(version string) error {
+	const conflict = `"apm" and "integrations_server" can't be declared in the same deployment`
+	v, err := semver.Parse(version)
+	if err != nil {
+		return fmt.Errorf(
+			`%s: use "integrations_server" for versions %s or higher and "apm" for lower versions`,
+			conflict, integrationsServerVersion,
+		)
+	}
+
+	if v.LT(integrationsServerVersion) {
+		return fmt.Errorf(
+			`%s: remove "integrations_server", "apm" must be used for versions lower than %s`,
+			conflict, integrationsServerVersion,
+		)
+	}
+
+	return fmt.Errorf(
+		`%s: remove "apm", "integrations_server" supersedes it on versions %s or higher`,
+		conflict, integrationsServerVersion,
+	)
+}
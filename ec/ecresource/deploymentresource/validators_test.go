@@ -0,0 +1,304 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateRefIDs(t *testing.T) {
+	type args struct {
+		resources map[string][]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		err  error
+	}{
+		{
+			name: "no resources returns no error",
+		},
+		{
+			name: "unique ref_ids return no error",
+			args: args{resources: map[string][]interface{}{
+				"elasticsearch": {map[string]interface{}{"ref_id": "main-elasticsearch"}},
+				"kibana": {map[string]interface{}{
+					"ref_id":                       "main-kibana",
+					"elasticsearch_cluster_ref_id": "main-elasticsearch",
+				}},
+				"apm": {map[string]interface{}{
+					"ref_id":                       "main-apm",
+					"elasticsearch_cluster_ref_id": "main-elasticsearch",
+				}},
+				"enterprise_search": {map[string]interface{}{
+					"ref_id":                       "main-enterprise_search",
+					"elasticsearch_cluster_ref_id": "main-elasticsearch",
+				}},
+			}},
+		},
+		{
+			name: "duplicated ref_ids return an error naming the blocks",
+			args: args{resources: map[string][]interface{}{
+				"elasticsearch": {map[string]interface{}{"ref_id": "main"}},
+				"kibana": {map[string]interface{}{
+					"ref_id":                       "main",
+					"elasticsearch_cluster_ref_id": "main",
+				}},
+				"integrations_server": {map[string]interface{}{
+					"ref_id":                       "main",
+					"elasticsearch_cluster_ref_id": "main",
+				}},
+			}},
+			err: multierror.NewPrefixed("invalid ref_id configuration",
+				errors.New(`kibana.0.ref_id "main" is already used by elasticsearch.0`),
+				errors.New(`integrations_server.0.ref_id "main" is already used by elasticsearch.0`),
+			),
+		},
+		{
+			name: "elasticsearch_cluster_ref_id not matching the elasticsearch ref_id returns an error",
+			args: args{resources: map[string][]interface{}{
+				"elasticsearch": {map[string]interface{}{"ref_id": "main-elasticsearch"}},
+				"kibana": {map[string]interface{}{
+					"ref_id":                       "main-kibana",
+					"elasticsearch_cluster_ref_id": "other-elasticsearch",
+				}},
+			}},
+			err: multierror.NewPrefixed("invalid ref_id configuration",
+				errors.New(`kibana.0.elasticsearch_cluster_ref_id "other-elasticsearch" doesn't match any elasticsearch ref_id`),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRefIDs(tt.args.resources)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_validateTagKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		tags interface{}
+		errs []error
+	}{
+		{
+			name: "no tags return no errors",
+			tags: map[string]interface{}{},
+		},
+		{
+			name: "distinct keys return no errors",
+			tags: map[string]interface{}{
+				"owner": "elastic",
+				"team":  "cloud",
+			},
+		},
+		{
+			name: "keys only differing in case return an error",
+			tags: map[string]interface{}{
+				"Owner": "elastic",
+				"owner": "someone-else",
+				"team":  "cloud",
+			},
+			errs: []error{
+				errors.New(`tags: key "owner" conflicts with key "Owner", tag keys are case-insensitive`),
+			},
+		},
+		{
+			name: "non map values return an error",
+			tags: "owner",
+			errs: []error{
+				errors.New(`expected type of tags to be a map`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warns, errs := validateTagKeys(tt.tags, "tags")
+			assert.Empty(t, warns)
+			assert.Equal(t, tt.errs, errs)
+		})
+	}
+}
+
+func Test_validateVersion(t *testing.T) {
+	type args struct {
+		version         string
+		allowPrerelease bool
+	}
+	tests := []struct {
+		name string
+		args args
+		err  error
+	}{
+		{
+			name: "accepts a release version",
+			args: args{version: "8.2.0"},
+		},
+		{
+			name: "rejects a snapshot version when pre-release versions aren't allowed",
+			args: args{version: "8.3.0-SNAPSHOT"},
+			err:  errors.New(`pre-release version "8.3.0-SNAPSHOT" is not allowed: set "allow_prerelease_versions" in the provider configuration to use it`),
+		},
+		{
+			name: "accepts a snapshot version when pre-release versions are allowed",
+			args: args{version: "8.3.0-SNAPSHOT", allowPrerelease: true},
+		},
+		{
+			name: "accepts a pre-release version when pre-release versions are allowed",
+			args: args{version: "8.3.0-rc1", allowPrerelease: true},
+		},
+		{
+			name: "rejects an invalid version",
+			args: args{version: "8.3", allowPrerelease: true},
+			err:  errors.New(`invalid version "8.3": No Major.Minor.Patch elements found`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVersion(tt.args.version, tt.args.allowPrerelease)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_validateSecuritySettingsVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		err     error
+	}{
+		{
+			name:    "accepts a supported version",
+			version: "7.10.1",
+		},
+		{
+			name:    "accepts the minimum supported version",
+			version: "6.8.0",
+		},
+		{
+			name:    "rejects an unsupported version",
+			version: "6.7.2",
+			err:     errors.New(`anonymous access settings require an Elasticsearch version of 6.8.0 or higher, got "6.7.2"`),
+		},
+		{
+			name:    "ignores an invalid version",
+			version: "7.10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSecuritySettingsVersion(tt.version)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_validateApmIntegrationsServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		err     error
+	}{
+		{
+			name:    "directs 8.x deployments to integrations_server",
+			version: "8.1.0",
+			err:     errors.New(`"apm" and "integrations_server" can't be declared in the same deployment: remove "apm", "integrations_server" supersedes it on versions 8.0.0 or higher`),
+		},
+		{
+			name:    "directs 7.x deployments to apm",
+			version: "7.17.3",
+			err:     errors.New(`"apm" and "integrations_server" can't be declared in the same deployment: remove "integrations_server", "apm" must be used for versions lower than 8.0.0`),
+		},
+		{
+			name: "names both blocks when the version is unknown",
+			err:  errors.New(`"apm" and "integrations_server" can't be declared in the same deployment: use "integrations_server" for versions 8.0.0 or higher and "apm" for lower versions`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, validateApmIntegrationsServer(tt.version), tt.err.Error())
+		})
+	}
+}
+
+func Test_checkApmIntegrationsServer(t *testing.T) {
+	newConfig := func(kinds ...string) map[string]interface{} {
+		config := map[string]interface{}{
+			"deployment_template_id": "aws-io-optimized-v2",
+			"region":                 "us-east-1",
+			"version":                "8.1.0",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+		}
+		for _, kind := range kinds {
+			config[kind] = []interface{}{map[string]interface{}{}}
+		}
+		return config
+	}
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    error
+	}{
+		{
+			name:   "accepts apm",
+			config: newConfig("apm"),
+		},
+		{
+			name:   "accepts integrations_server",
+			config: newConfig("integrations_server"),
+		},
+		{
+			name:   "rejects apm and integrations_server",
+			config: newConfig("apm", "integrations_server"),
+			err:    errors.New(`"apm" and "integrations_server" can't be declared in the same deployment: remove "apm", "integrations_server" supersedes it on versions 8.0.0 or higher`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := schema.Resource{Schema: newSchema(), CustomizeDiff: checkApmIntegrationsServer}
+			_, err := res.Diff(
+				context.Background(), nil, terraform.NewResourceConfigRaw(tt.config), nil,
+			)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blang/semver/v4"
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+// versionOverrideResources are the deployment resources which accept a
+// "version" override of the deployment version.
+var versionOverrideResources = []string{
+	"apm", "enterprise_search", "integrations_server", "kibana",
+}
+
+func newVersionOverrideSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Description: `Optional version override for the resource, which must have the same major version as the deployment "version". Defaults to the deployment version.`,
+		Optional:    true,
+	}
+}
+
+// versionOverride returns the "version" override of the resource, which is
+// empty when the resource follows the deployment version.
+func versionOverride(d interface{ Get(string) interface{} }, resource string) string {
+	v, _ := d.Get(resource + ".0.version").(string)
+	return v
+}
+
+// checkVersionOverrides returns a CustomizeDiff function which validates the
+// resource "version" overrides against the deployment version.
+func checkVersionOverrides(settings *VersionSettings) schema.CustomizeDiffFunc {
+	return func(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+		if !d.NewValueKnown("version") {
+			return nil
+		}
+
+		allowPrerelease := settings != nil && settings.AllowPrerelease
+		merr := multierror.NewPrefixed("invalid version override")
+		for _, resource := range versionOverrideResources {
+			override := versionOverride(d, resource)
+			if override == "" {
+				continue
+			}
+
+			err := validateVersionOverride(d.Get("version").(string), override, allowPrerelease)
+			if err != nil {
+				merr = merr.Append(fmt.Errorf("%s: %w", resource, err))
+			}
+		}
+
+		return merr.ErrorOrNil()
+	}
+}
+
+// validateVersionOverride ensures the resource version override is a valid
+// version with the same major version as the deployment version.
+func validateVersionOverride(version, override string, allowPrerelease bool) error {
+	if err := validateVersion(override, allowPrerelease); err != nil {
+		return err
+	}
+
+	// An invalid deployment version is reported by checkVersion.
+	deploymentVersion, err := semver.Parse(version)
+	if err != nil {
+		return nil
+	}
+
+	if overrideVersion := semver.MustParse(override); overrideVersion.Major != deploymentVersion.Major {
+		return fmt.Errorf(
+			`version "%s" isn't compatible with the deployment version "%s": the major versions must match`,
+			override, version,
+		)
+	}
+
+	return nil
+}
+
+// checkVersionSkew returns a warning for each resource "version" override with
+// a different minor version than the deployment version, since mixed version
+// deployments are only meant to be run temporarily, such as during upgrades.
+func checkVersionSkew(d *schema.ResourceData) diag.Diagnostics {
+	version, err := semver.Parse(d.Get("version").(string))
+	if err != nil {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+	for _, resource := range versionOverrideResources {
+		override, err := semver.Parse(versionOverride(d, resource))
+		if err != nil || override.Minor == version.Minor {
+			continue
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary: fmt.Sprintf(`%s version "%s" differs from the deployment version "%s"`,
+				resource, override, version,
+			),
+			Detail: "Running resources with different minor versions isn't recommended other than temporarily, such as during upgrades.",
+		})
+	}
+
+	return diags
+}
+
+// OverrideVersions applies the payload overrides to the deployment create or
+// update request, followed by the resource "version" overrides. The returned
+// overrides don't include the deployment version, since it has already been
+// applied and would otherwise replace the resource versions.
+func OverrideVersions(d *schema.ResourceData, req interface{}, overrides deploymentapi.PayloadOverrides) (*deploymentapi.PayloadOverrides, error) {
+	if err := deploymentapi.OverrideCreateOrUpdateRequest(req, &overrides); err != nil {
+		return nil, err
+	}
+	overrides.Version = ""
+
+	var apm []*models.ApmPayload
+	var enterpriseSearch []*models.EnterpriseSearchPayload
+	var integrationsServer []*models.IntegrationsServerPayload
+	var kibana []*models.KibanaPayload
+	switch r := req.(type) {
+	case *models.DeploymentCreateRequest:
+		if r.Resources != nil {
+			apm, enterpriseSearch = r.Resources.Apm, r.Resources.EnterpriseSearch
+			integrationsServer, kibana = r.Resources.IntegrationsServer, r.Resources.Kibana
+		}
+	case *models.DeploymentUpdateRequest:
+		if r.Resources != nil {
+			apm, enterpriseSearch = r.Resources.Apm, r.Resources.EnterpriseSearch
+			integrationsServer, kibana = r.Resources.IntegrationsServer, r.Resources.Kibana
+		}
+	}
+
+	if v := versionOverride(d, "apm"); v != "" {
+		for _, res := range apm {
+			if res.Plan != nil && res.Plan.Apm != nil {
+				res.Plan.Apm.Version = v
+			}
+		}
+	}
+
+	if v := versionOverride(d, "enterprise_search"); v != "" {
+		for _, res := range enterpriseSearch {
+			if res.Plan != nil && res.Plan.EnterpriseSearch != nil {
+				res.Plan.EnterpriseSearch.Version = v
+			}
+		}
+	}
+
+	if v := versionOverride(d, "integrations_server"); v != "" {
+		for _, res := range integrationsServer {
+			if res.Plan != nil && res.Plan.IntegrationsServer != nil {
+				res.Plan.IntegrationsServer.Version = v
+			}
+		}
+	}
+
+	if v := versionOverride(d, "kibana"); v != "" {
+		for _, res := range kibana {
+			if res.Plan != nil && res.Plan.Kibana != nil {
+				res.Plan.Kibana.Version = v
+			}
+		}
+	}
+
+	return &overrides, nil
+}
+
+// withoutVersionOverrides returns a copy of the deployment resources without
+// the resources which have a "version" override, so these aren't taken into
+// account when the deployment version is read.
+func withoutVersionOverrides(d *schema.ResourceData, res *models.DeploymentResources) *models.DeploymentResources {
+	filtered := *res
+	if versionOverride(d, "apm") != "" {
+		filtered.Apm = nil
+	}
+	if versionOverride(d, "enterprise_search") != "" {
+		filtered.EnterpriseSearch = nil
+	}
+	if versionOverride(d, "integrations_server") != "" {
+		filtered.IntegrationsServer = nil
+	}
+	if versionOverride(d, "kibana") != "" {
+		filtered.Kibana = nil
+	}
+	return &filtered
+}
+
+// resourceVersions returns the version read from the current plan of the
+// running resources which accept a "version" override, keyed by resource.
+func resourceVersions(res *models.DeploymentResources) map[string]string {
+	versions := make(map[string]string)
+	for _, r := range res.Apm {
+		if !util.IsCurrentApmPlanEmpty(r) && !isApmResourceStopped(r) && r.Info.PlanInfo.Current.Plan.Apm != nil {
+			versions["apm"] = r.Info.PlanInfo.Current.Plan.Apm.Version
+			break
+		}
+	}
+
+	for _, r := range res.EnterpriseSearch {
+		if !util.IsCurrentEssPlanEmpty(r) && !isEssResourceStopped(r) && r.Info.PlanInfo.Current.Plan.EnterpriseSearch != nil {
+			versions["enterprise_search"] = r.Info.PlanInfo.Current.Plan.EnterpriseSearch.Version
+			break
+		}
+	}
+
+	for _, r := range res.IntegrationsServer {
+		if !util.IsCurrentIntegrationsServerPlanEmpty(r) && !isIntegrationsServerResourceStopped(r) && r.Info.PlanInfo.Current.Plan.IntegrationsServer != nil {
+			versions["integrations_server"] = r.Info.PlanInfo.Current.Plan.IntegrationsServer.Version
+			break
+		}
+	}
+
+	for _, r := range res.Kibana {
+		if !util.IsCurrentKibanaPlanEmpty(r) && !isKibanaResourceStopped(r) && r.Info.PlanInfo.Current.Plan.Kibana != nil {
+			versions["kibana"] = r.Info.PlanInfo.Current.Plan.Kibana.Version
+			break
+		}
+	}
+
+	return versions
+}
+
+// setVersionOverride sets the "version" of the flattened resource to the
+// version read from its plan when it has a "version" override, so any drift
+// from the override is detected. Resources following the deployment version
+// are left untouched.
+func setVersionOverride(d *schema.ResourceData, resource string, flattened []interface{}, version string) {
+	if len(flattened) == 0 || version == "" || versionOverride(d, resource) == "" {
+		return
+	}
+
+	if m, ok := flattened[0].(map[string]interface{}); ok {
+		m["version"] = version
+	}
+}
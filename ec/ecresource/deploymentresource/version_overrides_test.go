@@ -0,0 +1,204 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func newVersionOverrideRD(t *testing.T, version string, overrides map[string]string) *schema.ResourceData {
+	state := map[string]interface{}{
+		"name":                   "my_deployment_name",
+		"deployment_template_id": "aws-io-optimized-v2",
+		"region":                 "us-east-1",
+		"version":                version,
+	}
+	for resource, override := range overrides {
+		state[resource] = []interface{}{map[string]interface{}{
+			"version": override,
+		}}
+	}
+
+	return util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		Schema: newSchema(),
+		State:  state,
+	})
+}
+
+func Test_validateVersionOverride(t *testing.T) {
+	type args struct {
+		version         string
+		override        string
+		allowPrerelease bool
+	}
+	tests := []struct {
+		name string
+		args args
+		err  error
+	}{
+		{
+			name: "accepts a different patch version",
+			args: args{version: "8.2.1", override: "8.2.0"},
+		},
+		{
+			name: "accepts a different minor version",
+			args: args{version: "8.2.0", override: "8.1.3"},
+		},
+		{
+			name: "rejects a different major version",
+			args: args{version: "8.2.0", override: "7.17.3"},
+			err:  errors.New(`version "7.17.3" isn't compatible with the deployment version "8.2.0": the major versions must match`),
+		},
+		{
+			name: "rejects an invalid version",
+			args: args{version: "8.2.0", override: "8.2"},
+			err:  errors.New(`invalid version "8.2": No Major.Minor.Patch elements found`),
+		},
+		{
+			name: "rejects a pre-release version when pre-release versions aren't allowed",
+			args: args{version: "8.2.0", override: "8.3.0-SNAPSHOT"},
+			err:  errors.New(`pre-release version "8.3.0-SNAPSHOT" is not allowed: set "allow_prerelease_versions" in the provider configuration to use it`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVersionOverride(tt.args.version, tt.args.override, tt.args.allowPrerelease)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_checkVersionSkew(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		want      diag.Diagnostics
+	}{
+		{
+			name: "doesn't warn without version overrides",
+		},
+		{
+			name:      "doesn't warn on a patch version skew",
+			overrides: map[string]string{"kibana": "8.2.0"},
+		},
+		{
+			name:      "warns on a minor version skew",
+			overrides: map[string]string{"kibana": "8.1.3"},
+			want: diag.Diagnostics{{
+				Severity: diag.Warning,
+				Summary:  `kibana version "8.1.3" differs from the deployment version "8.2.1"`,
+				Detail:   "Running resources with different minor versions isn't recommended other than temporarily, such as during upgrades.",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkVersionSkew(newVersionOverrideRD(t, "8.2.1", tt.overrides))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_overrideVersions(t *testing.T) {
+	newRequest := func() *models.DeploymentCreateRequest {
+		return &models.DeploymentCreateRequest{
+			Resources: &models.DeploymentCreateResources{
+				Elasticsearch: []*models.ElasticsearchPayload{{
+					Plan: &models.ElasticsearchClusterPlan{
+						Elasticsearch: &models.ElasticsearchConfiguration{},
+					},
+				}},
+				Kibana: []*models.KibanaPayload{{
+					Plan: &models.KibanaClusterPlan{Kibana: &models.KibanaConfiguration{}},
+				}},
+				Apm: []*models.ApmPayload{{
+					Plan: &models.ApmPlan{Apm: &models.ApmConfiguration{}},
+				}},
+			},
+		}
+	}
+	tests := []struct {
+		name         string
+		overrides    map[string]string
+		wantEs       string
+		wantKibana   string
+		wantApm      string
+		wantOverride *deploymentapi.PayloadOverrides
+	}{
+		{
+			name:         "applies the deployment version to all the resources",
+			wantEs:       "8.2.1",
+			wantKibana:   "8.2.1",
+			wantApm:      "8.2.1",
+			wantOverride: &deploymentapi.PayloadOverrides{Region: "us-east-1"},
+		},
+		{
+			name:         "applies the kibana version override",
+			overrides:    map[string]string{"kibana": "8.2.0"},
+			wantEs:       "8.2.1",
+			wantKibana:   "8.2.0",
+			wantApm:      "8.2.1",
+			wantOverride: &deploymentapi.PayloadOverrides{Region: "us-east-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			got, err := OverrideVersions(
+				newVersionOverrideRD(t, "8.2.1", tt.overrides), req,
+				deploymentapi.PayloadOverrides{Version: "8.2.1", Region: "us-east-1"},
+			)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.wantOverride, got)
+			assert.Equal(t, tt.wantEs, req.Resources.Elasticsearch[0].Plan.Elasticsearch.Version)
+			assert.Equal(t, tt.wantKibana, req.Resources.Kibana[0].Plan.Kibana.Version)
+			assert.Equal(t, tt.wantApm, req.Resources.Apm[0].Plan.Apm.Version)
+		})
+	}
+}
+
+func Test_withoutVersionOverrides(t *testing.T) {
+	kibanaRes := []*models.KibanaResourceInfo{{RefID: ec.String("main-kibana")}}
+	apmRes := []*models.ApmResourceInfo{{RefID: ec.String("main-apm")}}
+	res := &models.DeploymentResources{Kibana: kibanaRes, Apm: apmRes}
+
+	got := withoutVersionOverrides(
+		newVersionOverrideRD(t, "8.2.1", map[string]string{"kibana": "8.2.0"}), res,
+	)
+	assert.Equal(t, &models.DeploymentResources{Apm: apmRes}, got)
+	assert.Equal(t, kibanaRes, res.Kibana)
+}
@@ -18,25 +18,204 @@
 package deploymentresource
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi"
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/deputil"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
 	"github.com/elastic/cloud-sdk-go/pkg/plan"
 	"github.com/elastic/cloud-sdk-go/pkg/plan/planutil"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 const (
 	defaultPollPlanFrequency = 2 * time.Second
 	defaultMaxPlanRetry      = 4
+
+	minPollPlanFrequency = time.Second
 )
 
-// WaitForPlanCompletion waits for a pending plan to finish.
-func WaitForPlanCompletion(client *api.API, id string) error {
-	return planutil.Wait(plan.TrackChangeParams{
+// TrackingSettings holds the provider settings which control how the pending
+// deployment plans are tracked.
+type TrackingSettings struct {
+	// PollInterval is the time between the API calls which poll a pending
+	// plan. Defaults to 2 seconds when unset.
+	PollInterval time.Duration
+}
+
+type pollIntervalKey struct{}
+
+// withPollInterval wraps a CRUD function so that the pending plans are polled
+// with the resource "poll_interval" or, when it's unset, with the provider
+// settings.
+func withPollInterval(settings *TrackingSettings, f contextFunc) contextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		interval := defaultPollPlanFrequency
+		if settings != nil && settings.PollInterval > 0 {
+			interval = settings.PollInterval
+		}
+
+		// The value has already been validated by ValidatePollInterval.
+		if raw := d.Get("poll_interval").(string); raw != "" {
+			if v, err := time.ParseDuration(raw); err == nil {
+				interval = v
+			}
+		}
+
+		return f(context.WithValue(ctx, pollIntervalKey{}, interval), d, meta)
+	}
+}
+
+// ValidatePollInterval is a ValidateFunc for the plan poll interval settings,
+// which must be a duration of at least 1 second.
+func ValidatePollInterval(i interface{}, k string) ([]string, []error) {
+	raw, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+
+	interval, err := time.ParseDuration(raw)
+	if err != nil {
+		return nil, []error{fmt.Errorf(`%s: invalid duration "%s": %w`, k, raw, err)}
+	}
+
+	if interval < minPollPlanFrequency {
+		return nil, []error{fmt.Errorf(
+			`%s: duration "%s" is invalid: it must be at least %s`, k, raw, minPollPlanFrequency,
+		)}
+	}
+
+	return nil, nil
+}
+
+// pollInterval returns the plan poll interval set by withPollInterval, or the
+// default one when it's unset.
+func pollInterval(ctx context.Context) time.Duration {
+	if interval, ok := ctx.Value(pollIntervalKey{}).(time.Duration); ok {
+		return interval
+	}
+	return defaultPollPlanFrequency
+}
+
+// WaitForPlanCompletion waits for a pending plan to finish, polling it with
+// the interval set by withPollInterval. It stops waiting when the context is
+// done, which happens once the resource operation timeout is reached.
+func WaitForPlanCompletion(ctx context.Context, client *api.API, id string) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- planutil.Wait(plan.TrackChangeParams{
+			API: client, DeploymentID: id,
+			Config: plan.TrackFrequencyConfig{
+				PollFrequency: pollInterval(ctx),
+				MaxRetries:    defaultMaxPlanRetry,
+			},
+		})
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for the pending plan to finish: %w", ctx.Err())
+	}
+}
+
+// resourcePlans holds the plans of a deployment resource, which have the same
+// shape for all the resource kinds.
+type resourcePlans struct {
+	RefID string `json:"ref_id"`
+	Info  struct {
+		Healthy  *bool `json:"healthy"`
+		PlanInfo struct {
+			Pending *planAttempt   `json:"pending"`
+			Current *planAttempt   `json:"current"`
+			History []*planAttempt `json:"history"`
+		} `json:"plan_info"`
+	} `json:"info"`
+}
+
+type planAttempt struct {
+	PlanAttemptLog []*models.ClusterPlanStepInfo `json:"plan_attempt_log"`
+}
+
+// currentLog returns the attempt log of the current plan or, when it has
+// none, such as for resources which failed to be created, the one of the last
+// plan in the history.
+func (r resourcePlans) currentLog() []*models.ClusterPlanStepInfo {
+	if current := r.Info.PlanInfo.Current; current != nil && len(current.PlanAttemptLog) > 0 {
+		return current.PlanAttemptLog
+	}
+
+	if history := r.Info.PlanInfo.History; len(history) > 0 && history[len(history)-1] != nil {
+		return history[len(history)-1].PlanAttemptLog
+	}
+
+	return nil
+}
+
+// getResourcePlans returns the plans of the deployment resources keyed by
+// their kind, such as "elasticsearch" or "kibana". The plan logs and history,
+// which are needed to obtain the plan failures, are only read when withLogs
+// is set.
+func getResourcePlans(client *api.API, id string, withLogs bool) (map[string][]resourcePlans, error) {
+	res, err := deploymentapi.Get(deploymentapi.GetParams{
 		API: client, DeploymentID: id,
-		Config: plan.TrackFrequencyConfig{
-			PollFrequency: defaultPollPlanFrequency,
-			MaxRetries:    defaultMaxPlanRetry,
+		QueryParams: deputil.QueryParams{
+			ShowPlans:       true,
+			ShowPlanLogs:    withLogs,
+			ShowPlanHistory: withLogs,
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newResourcePlans(res.Resources)
+}
+
+// newResourcePlans returns the plans of the deployment resources keyed by
+// their kind. Since the resources of each kind are different types, these are
+// decoded from their JSON representation, which is keyed by the kind.
+func newResourcePlans(res *models.DeploymentResources) (map[string][]resourcePlans, error) {
+	plans := make(map[string][]resourcePlans)
+	if res == nil {
+		return plans, nil
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &plans); err != nil {
+		return nil, err
+	}
+
+	return plans, nil
+}
+
+// planFailures returns the errors of the finished plans of the deployment
+// resources. The plans which are still pending aren't checked.
+func planFailures(plans map[string][]resourcePlans) error {
+	merr := multierror.NewPrefixed("found deployment plan errors")
+	for _, kind := range waitForResourceKinds {
+		for _, r := range plans[kind] {
+			log := r.currentLog()
+			if len(log) == 0 {
+				continue
+			}
+
+			if _, err := plan.GetStepName(log); err != nil && err != plan.ErrPlanFinished {
+				merr = merr.Append(fmt.Errorf("%s resource %s: %w", kind, r.RefID, err))
+			}
+		}
+	}
+
+	return merr.ErrorOrNil()
 }
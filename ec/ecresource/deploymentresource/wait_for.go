@@ -0,0 +1,141 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// waitForResourceKinds are the resources which can be set in "wait_for".
+var waitForResourceKinds = []string{
+	"elasticsearch", "kibana", "apm", "integrations_server", "enterprise_search",
+}
+
+// checkWaitFor validates that the resources set in "wait_for" are declared in
+// the deployment, since the creation would never finish otherwise.
+func checkWaitFor(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	waitFor, _ := d.Get("wait_for").(*schema.Set)
+	if waitFor == nil {
+		return nil
+	}
+
+	for _, kind := range waitFor.List() {
+		if res, _ := d.Get(kind.(string)).([]interface{}); len(res) == 0 {
+			return fmt.Errorf(
+				`"wait_for": the %s resource isn't declared in the deployment`, kind,
+			)
+		}
+	}
+
+	return nil
+}
+
+// waitForResources waits for the pending plan of a new deployment to finish.
+// When "wait_for" is set, it returns as soon as the named resources have no
+// pending plan and are healthy, leaving the other resources being created.
+// As in the plan tracker, it also returns once the deployment has been polled
+// defaultMaxPlanRetry times in a row without pending plans, and fails once
+// the deployment can't be read defaultMaxPlanRetry times in a row. In both
+// cases, the failures of the finished plans of any of the resources are
+// returned.
+func waitForResources(ctx context.Context, d *schema.ResourceData, client *api.API, id string) error {
+	waitFor, _ := d.Get("wait_for").(*schema.Set)
+	if waitFor == nil || waitFor.Len() == 0 {
+		return WaitForPlanCompletion(ctx, client, id)
+	}
+
+	kinds := make([]string, 0, waitFor.Len())
+	for _, kind := range waitFor.List() {
+		kinds = append(kinds, kind.(string))
+	}
+
+	ticker := time.NewTicker(pollInterval(ctx))
+	defer ticker.Stop()
+
+	var retries, failures int
+	for retries < defaultMaxPlanRetry {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the pending plan to finish: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		plans, err := getResourcePlans(client, id, false)
+		if err != nil {
+			if failures++; failures >= defaultMaxPlanRetry {
+				return multierror.NewPrefixed("failed reading the deployment plans", err)
+			}
+			continue
+		}
+		failures = 0
+
+		if resourcesReady(plans, kinds) {
+			break
+		}
+
+		if hasPendingPlans(plans) {
+			retries = 0
+			continue
+		}
+		retries++
+	}
+
+	plans, err := getResourcePlans(client, id, true)
+	if err != nil {
+		return multierror.NewPrefixed("failed reading the deployment plans", err)
+	}
+
+	return planFailures(plans)
+}
+
+// hasPendingPlans returns true when any of the resources has a pending plan.
+func hasPendingPlans(plans map[string][]resourcePlans) bool {
+	for _, resources := range plans {
+		for _, r := range resources {
+			if r.Info.PlanInfo.Pending != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resourcesReady returns true when all the resources of the kinds have no
+// pending plan and are healthy. Kinds without any resources aren't ready.
+func resourcesReady(plans map[string][]resourcePlans, kinds []string) bool {
+	for _, kind := range kinds {
+		if len(plans[kind]) == 0 {
+			return false
+		}
+
+		for _, r := range plans[kind] {
+			healthy := r.Info.Healthy != nil && *r.Info.Healthy
+			if r.Info.PlanInfo.Pending != nil || !healthy {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,316 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	planmock "github.com/elastic/cloud-sdk-go/pkg/plan/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_checkWaitFor(t *testing.T) {
+	newConfig := func(waitFor ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"region":                 "us-east-1",
+			"deployment_template_id": "aws-io-optimized-v2",
+			"version":                "7.12.0",
+			"elasticsearch":          []interface{}{map[string]interface{}{}},
+			"kibana":                 []interface{}{map[string]interface{}{}},
+			"wait_for":               waitFor,
+		}
+	}
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		err    error
+	}{
+		{
+			name:   "accepts the declared resources",
+			config: newConfig("elasticsearch", "kibana"),
+		},
+		{
+			name:   "accepts an unset wait_for",
+			config: newConfig(),
+		},
+		{
+			name:   "fails on resources which aren't declared",
+			config: newConfig("elasticsearch", "apm"),
+			err:    errors.New(`"wait_for": the apm resource isn't declared in the deployment`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := schema.Resource{Schema: newSchema(), CustomizeDiff: checkWaitFor}
+			_, err := res.Diff(
+				context.Background(), nil, terraform.NewResourceConfigRaw(tt.config), nil,
+			)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_resourcesReady(t *testing.T) {
+	newEs := func(healthy bool, pending bool) *models.ElasticsearchResourceInfo {
+		info := &models.ElasticsearchResourceInfo{Info: &models.ElasticsearchClusterInfo{
+			Healthy:  ec.Bool(healthy),
+			PlanInfo: &models.ElasticsearchClusterPlansInfo{},
+		}}
+		if pending {
+			info.Info.PlanInfo.Pending = &models.ElasticsearchClusterPlanInfo{}
+		}
+		return info
+	}
+	newKibana := func(healthy bool, pending bool) *models.KibanaResourceInfo {
+		info := &models.KibanaResourceInfo{Info: &models.KibanaClusterInfo{
+			Healthy:  ec.Bool(healthy),
+			PlanInfo: &models.KibanaClusterPlansInfo{},
+		}}
+		if pending {
+			info.Info.PlanInfo.Pending = &models.KibanaClusterPlanInfo{}
+		}
+		return info
+	}
+	tests := []struct {
+		name  string
+		res   *models.DeploymentResources
+		kinds []string
+		want  bool
+	}{
+		{
+			name:  "is ready when the resources are healthy without pending plans",
+			res:   &models.DeploymentResources{Elasticsearch: []*models.ElasticsearchResourceInfo{newEs(true, false)}},
+			kinds: []string{"elasticsearch"},
+			want:  true,
+		},
+		{
+			name: "ignores the resources which aren't waited for",
+			res: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{newEs(true, false)},
+				Kibana:        []*models.KibanaResourceInfo{newKibana(false, true)},
+			},
+			kinds: []string{"elasticsearch"},
+			want:  true,
+		},
+		{
+			name: "isn't ready while a resource has a pending plan",
+			res: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{newEs(true, false)},
+				Kibana:        []*models.KibanaResourceInfo{newKibana(true, true)},
+			},
+			kinds: []string{"elasticsearch", "kibana"},
+		},
+		{
+			name:  "isn't ready while a resource is unhealthy",
+			res:   &models.DeploymentResources{Elasticsearch: []*models.ElasticsearchResourceInfo{newEs(false, false)}},
+			kinds: []string{"elasticsearch"},
+		},
+		{
+			name:  "isn't ready when there are no resources of the kind",
+			res:   &models.DeploymentResources{Elasticsearch: []*models.ElasticsearchResourceInfo{newEs(true, false)}},
+			kinds: []string{"apm"},
+		},
+		{
+			name:  "isn't ready without resources",
+			kinds: []string{"elasticsearch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plans, err := newResourcePlans(tt.res)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, resourcesReady(plans, tt.kinds))
+		})
+	}
+}
+
+func Test_waitForResources(t *testing.T) {
+	type deployment struct {
+		esHealthy     bool
+		kibanaPending bool
+		kibanaFailed  bool
+	}
+	newDeployment := func(dep deployment) mock.Response {
+		kibana := &models.KibanaResourceInfo{
+			RefID: ec.String("main-kibana"),
+			Info: &models.KibanaClusterInfo{
+				Healthy:  ec.Bool(!dep.kibanaPending && !dep.kibanaFailed),
+				PlanInfo: &models.KibanaClusterPlansInfo{},
+			},
+		}
+		if dep.kibanaPending {
+			kibana.Info.PlanInfo.Pending = &models.KibanaClusterPlanInfo{}
+		}
+		if dep.kibanaFailed {
+			kibana.Info.PlanInfo.History = []*models.KibanaClusterPlanInfo{{
+				PlanAttemptLog: planmock.NewPlanStepLog(planmock.NewPlanStepWithDetailsAndError(
+					"plan-completed", []*models.ClusterPlanStepLogMessageInfo{{Message: ec.String("some failure")}},
+				)),
+			}}
+		}
+		return mock.New200StructResponse(models.DeploymentGetResponse{
+			ID: ec.String(mock.ValidClusterID),
+			Resources: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{{
+					RefID: ec.String("main-elasticsearch"),
+					Info: &models.ElasticsearchClusterInfo{
+						Healthy:  ec.Bool(dep.esHealthy),
+						PlanInfo: &models.ElasticsearchClusterPlansInfo{},
+					},
+				}},
+				Kibana: []*models.KibanaResourceInfo{kibana},
+			},
+		})
+	}
+	newResponses := func(n int, dep deployment) []mock.Response {
+		var responses []mock.Response
+		for i := 0; i < n; i++ {
+			responses = append(responses, newDeployment(dep))
+		}
+		return responses
+	}
+	apiError := func() mock.Response {
+		return mock.NewErrorResponse(500, mock.APIError{Code: "some", Message: "message"})
+	}
+	tests := []struct {
+		name      string
+		waitFor   []interface{}
+		responses []mock.Response
+		cancel    bool
+		err       string
+	}{
+		{
+			name:    "returns once the waited for resources are ready",
+			waitFor: []interface{}{"elasticsearch"},
+			responses: []mock.Response{
+				newDeployment(deployment{esHealthy: true, kibanaPending: true}),
+				newDeployment(deployment{esHealthy: true, kibanaPending: true}),
+			},
+		},
+		{
+			name:    "waits for the pending resources",
+			waitFor: []interface{}{"elasticsearch", "kibana"},
+			responses: []mock.Response{
+				newDeployment(deployment{esHealthy: true, kibanaPending: true}),
+				newDeployment(deployment{esHealthy: true}),
+				newDeployment(deployment{esHealthy: true}),
+			},
+		},
+		{
+			name:    "returns the plan failures of the resources which aren't waited for",
+			waitFor: []interface{}{"elasticsearch"},
+			responses: []mock.Response{
+				newDeployment(deployment{esHealthy: true, kibanaFailed: true}),
+				newDeployment(deployment{esHealthy: true, kibanaFailed: true}),
+			},
+			err: "found deployment plan errors: 1 error occurred:\n\t* kibana resource main-kibana: some failure\n\n",
+		},
+		{
+			name:    "returns the plan failures once there are no pending plans",
+			waitFor: []interface{}{"kibana"},
+			responses: append(
+				newResponses(defaultMaxPlanRetry, deployment{esHealthy: true, kibanaFailed: true}),
+				newDeployment(deployment{esHealthy: true, kibanaFailed: true}),
+			),
+			err: "found deployment plan errors: 1 error occurred:\n\t* kibana resource main-kibana: some failure\n\n",
+		},
+		{
+			name:    "polls again once a plan is pending",
+			waitFor: []interface{}{"elasticsearch"},
+			responses: append(append(append(
+				newResponses(defaultMaxPlanRetry-1, deployment{}),
+				newDeployment(deployment{kibanaPending: true}),
+				newDeployment(deployment{}),
+			), newResponses(defaultMaxPlanRetry-1, deployment{})...),
+				newDeployment(deployment{kibanaFailed: true}),
+			),
+			err: "found deployment plan errors: 1 error occurred:\n\t* kibana resource main-kibana: some failure\n\n",
+		},
+		{
+			name:      "fails when the deployment can't be read",
+			waitFor:   []interface{}{"elasticsearch"},
+			responses: []mock.Response{apiError(), apiError(), apiError(), apiError()},
+			err:       "failed reading the deployment plans: 1 error occurred:\n\t* api error: some: message\n\n",
+		},
+		{
+			name:    "retries the deployment reads which fail",
+			waitFor: []interface{}{"elasticsearch"},
+			responses: []mock.Response{
+				apiError(), apiError(), apiError(),
+				newDeployment(deployment{esHealthy: true}),
+				newDeployment(deployment{esHealthy: true}),
+			},
+		},
+		{
+			name:    "stops polling when the context is done",
+			waitFor: []interface{}{"elasticsearch"},
+			cancel:  true,
+			err:     "timed out waiting for the pending plan to finish: context canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     mock.ValidClusterID,
+				Schema: newSchema(),
+				State: map[string]interface{}{
+					"region":                 "us-east-1",
+					"deployment_template_id": "aws-io-optimized-v2",
+					"version":                "7.12.0",
+					"elasticsearch":          []interface{}{map[string]interface{}{}},
+					"kibana":                 []interface{}{map[string]interface{}{}},
+					"wait_for":               tt.waitFor,
+				},
+			})
+
+			interval := time.Millisecond
+			if tt.cancel {
+				interval = time.Hour
+			}
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), pollIntervalKey{}, interval))
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			err := waitForResources(ctx, d, api.NewMock(tt.responses...), mock.ValidClusterID)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package deploymentresource
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	planmock "github.com/elastic/cloud-sdk-go/pkg/plan/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_withPollInterval(t *testing.T) {
+	newDeployment := func(interval string) *schema.ResourceData {
+		state := newSampleLegacyDeployment()
+		if interval != "" {
+			state["poll_interval"] = interval
+		}
+		return util.NewResourceData(t, util.ResDataParams{
+			ID:     mock.ValidClusterID,
+			Schema: newSchema(),
+			State:  state,
+		})
+	}
+	tests := []struct {
+		name     string
+		d        *schema.ResourceData
+		settings *TrackingSettings
+		want     time.Duration
+	}{
+		{
+			name: "defaults to the default poll interval",
+			d:    newDeployment(""),
+			want: defaultPollPlanFrequency,
+		},
+		{
+			name:     "uses the provider poll interval",
+			d:        newDeployment(""),
+			settings: &TrackingSettings{PollInterval: 30 * time.Second},
+			want:     30 * time.Second,
+		},
+		{
+			name:     "the resource poll interval overrides the provider one",
+			d:        newDeployment("5s"),
+			settings: &TrackingSettings{PollInterval: 30 * time.Second},
+			want:     5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Duration
+			f := withPollInterval(tt.settings, func(ctx context.Context, _ *schema.ResourceData, _ interface{}) diag.Diagnostics {
+				got, _ = ctx.Value(pollIntervalKey{}).(time.Duration)
+				return nil
+			})
+			assert.Nil(t, f(context.Background(), tt.d, nil))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidatePollInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		errs []error
+	}{
+		{
+			name: "accepts a valid duration",
+			in:   "10s",
+		},
+		{
+			name: "rejects an invalid duration",
+			in:   "10",
+			errs: []error{errors.New(`poll_interval: invalid duration "10": time: missing unit in duration "10"`)},
+		},
+		{
+			name: "rejects a too short duration",
+			in:   "500ms",
+			errs: []error{errors.New(`poll_interval: duration "500ms" is invalid: it must be at least 1s`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := ValidatePollInterval(tt.in, "poll_interval")
+			if len(tt.errs) == 0 {
+				assert.Empty(t, errs)
+				return
+			}
+			if assert.Len(t, errs, len(tt.errs)) {
+				for i := range tt.errs {
+					assert.EqualError(t, errs[i], tt.errs[i].Error())
+				}
+			}
+		})
+	}
+}
+
+func TestWaitForPlanCompletion(t *testing.T) {
+	newDeployment := func(pending bool, current ...*models.ClusterPlanStepInfo) mock.Response {
+		info := &models.ElasticsearchClusterPlansInfo{
+			Current: &models.ElasticsearchClusterPlanInfo{PlanAttemptLog: current},
+		}
+		if pending {
+			info.Pending = &models.ElasticsearchClusterPlanInfo{
+				PlanAttemptLog: planmock.NewPlanStepLog(planmock.NewPlanStep("plan-started", "success")),
+			}
+		}
+		return mock.New200StructResponse(models.DeploymentGetResponse{
+			ID: ec.String(mock.ValidClusterID),
+			Resources: &models.DeploymentResources{
+				Elasticsearch: []*models.ElasticsearchResourceInfo{{
+					ID:    ec.String("resource-id"),
+					RefID: ec.String("main-elasticsearch"),
+					Info:  &models.ElasticsearchClusterInfo{PlanInfo: info},
+				}},
+			},
+		})
+	}
+	newFailedStep := func(msg string) *models.ClusterPlanStepInfo {
+		return planmock.NewPlanStepWithDetailsAndError("plan-completed",
+			[]*models.ClusterPlanStepLogMessageInfo{{Message: ec.String(msg)}},
+		)
+	}
+	// The plan tracker polls the deployment until it's been polled
+	// defaultMaxPlanRetry times without pending plans, and then reads the
+	// finished plans.
+	newResponses := func(current ...*models.ClusterPlanStepInfo) []mock.Response {
+		responses := []mock.Response{newDeployment(true, current...)}
+		for i := 0; i <= defaultMaxPlanRetry; i++ {
+			responses = append(responses, newDeployment(false, current...))
+		}
+		return responses
+	}
+
+	tests := []struct {
+		name          string
+		responses     []mock.Response
+		cancel        bool
+		err           string
+		retryShutdown bool
+	}{
+		{
+			name:      "returns once the deployment has no pending plans",
+			responses: newResponses(planmock.NewPlanStep("plan-completed", "success")),
+		},
+		{
+			name:      "returns the failure of the finished plan",
+			responses: newResponses(newFailedStep("some failure")),
+			err:       "found deployment plan errors",
+		},
+		{
+			name:          "returns the shutdown timeouts as errors which are retried",
+			responses:     newResponses(newFailedStep("Timeout exceeded")),
+			err:           "Timeout exceeded",
+			retryShutdown: true,
+		},
+		{
+			name:          "returns the shutdown deallocation failures as errors which are retried",
+			responses:     newResponses(newFailedStep("Some instances were not stopped")),
+			err:           "Some instances were not stopped",
+			retryShutdown: true,
+		},
+		{
+			name:   "stops polling when the context is done",
+			cancel: true,
+			err:    "timed out waiting for the pending plan to finish: context canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval := time.Millisecond
+			if tt.cancel {
+				interval = time.Hour
+			}
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), pollIntervalKey{}, interval))
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			err := WaitForPlanCompletion(ctx, api.NewMock(tt.responses...), mock.ValidClusterID)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+			assert.Equal(t, tt.retryShutdown, shouldRetryShutdown(err, 0, 3))
+		})
+	}
+}
@@ -19,8 +19,13 @@ package elasticsearchkeystoreresource
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net/http"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/apierror"
+	"github.com/elastic/cloud-sdk-go/pkg/client/deployments"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -38,6 +43,11 @@ func read(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diag
 		DeploymentID: deploymentID,
 	})
 	if err != nil {
+		if keystoreNotFound(err) {
+			log.Printf("[WARN] deployment %s not found, removing the keystore setting from the state", deploymentID)
+			d.SetId("")
+			return nil
+		}
 		return diag.FromErr(err)
 	}
 
@@ -48,6 +58,24 @@ func read(_ context.Context, d *schema.ResourceData, meta interface{}) diag.Diag
 	return nil
 }
 
+// keystoreNotFound returns true when the deployment or its Elasticsearch
+// keystore don't exist, such as when the deployment has been deleted outside
+// of Terraform.
+func keystoreNotFound(err error) bool {
+	var deploymentNotFound *deployments.GetDeploymentNotFound
+	if errors.As(err, &deploymentNotFound) {
+		return true
+	}
+
+	var keystoreNotFound *deployments.GetDeploymentEsResourceKeystoreNotFound
+	if errors.As(err, &keystoreNotFound) {
+		return true
+	}
+
+	// We also check for the case where a 403 is thrown for ESS.
+	return apierror.IsRuntimeStatusCode(err, http.StatusForbidden)
+}
+
 // This modelToState function is a little different than others in that it does
 // not set any other fields than "as_file". This is because the "value" is not
 // returned by the API for obvious reasons and thus we cannot reconcile that the
@@ -18,11 +18,14 @@
 package elasticsearchkeystoreresource
 
 import (
+	"context"
 	"testing"
 
+	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -118,3 +121,49 @@ func Test_modelToState(t *testing.T) {
 		})
 	}
 }
+
+func Test_read(t *testing.T) {
+	newRD := func() *schema.ResourceData {
+		return newResourceData(t, resDataParams{
+			ID: mock.ValidClusterID,
+			Resources: map[string]interface{}{
+				"deployment_id": mock.ValidClusterID,
+				"setting_name":  "my_secret",
+				"value":         "supersecret",
+			},
+		})
+	}
+	tests := []struct {
+		name   string
+		d      *schema.ResourceData
+		client *api.API
+		want   diag.Diagnostics
+		wantID string
+	}{
+		{
+			name: "removes the keystore setting from the state when the deployment doesn't exist",
+			d:    newRD(),
+			client: api.NewMock(mock.NewErrorResponse(404, mock.APIError{
+				Code: "deployments.deployment_not_found", Message: "not found",
+			})),
+		},
+		{
+			name: "returns any other error",
+			d:    newRD(),
+			client: api.NewMock(mock.NewErrorResponse(500, mock.APIError{
+				Code: "some", Message: "message",
+			})),
+			want: diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  "api error: 1 error occurred:\n\t* some: message\n\n",
+			}},
+			wantID: mock.ValidClusterID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, read(context.Background(), tt.d, tt.client))
+			assert.Equal(t, tt.wantID, tt.d.Id())
+		})
+	}
+}
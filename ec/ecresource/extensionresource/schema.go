@@ -51,15 +51,15 @@ func newSchema() map[string]*schema.Schema {
 
 		// Uploading file via API
 		"file_path": {
-			Type:         schema.TypeString,
-			Description:  "file path",
-			Optional:     true,
-			RequiredWith: []string{"file_hash"},
+			Type:        schema.TypeString,
+			Description: "file path",
+			Optional:    true,
 		},
 		"file_hash": {
 			Type:        schema.TypeString,
-			Description: "file hash",
+			Description: "file hash, computed from the file_path contents when not set",
 			Optional:    true,
+			Computed:    true,
 		},
 
 		"url": {
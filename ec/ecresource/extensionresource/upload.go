@@ -18,9 +18,16 @@
 package extensionresource
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/apierror"
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/extensionapi"
 	"github.com/elastic/cloud-sdk-go/pkg/multierror"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -32,15 +39,100 @@ func uploadExtension(client *api.API, d *schema.ResourceData) error {
 	if err != nil {
 		return multierror.NewPrefixed("failed to open file", err)
 	}
+	defer reader.Close()
+
+	info, err := reader.Stat()
+	if err != nil {
+		return multierror.NewPrefixed("failed to stat file", err)
+	}
+
+	if err := validateFileSize(filePath, info.Size()); err != nil {
+		return err
+	}
 
 	_, err = extensionapi.Upload(extensionapi.UploadParams{
 		API:         client,
 		ExtensionID: d.Id(),
 		File:        reader,
 	})
+	if err != nil {
+		return uploadError(filePath, info.Size(), err)
+	}
+
+	if d.Get("file_hash").(string) != "" {
+		return nil
+	}
+
+	hash, err := fileHash(filePath)
 	if err != nil {
 		return err
 	}
 
+	return d.Set("file_hash", hash)
+}
+
+// validateFileSize returns an error when the extension file is empty, since
+// there's nothing to upload.
+func validateFileSize(filePath string, size int64) error {
+	if size == 0 {
+		return fmt.Errorf("extension file %s is empty", filePath)
+	}
+
 	return nil
 }
+
+// uploadError returns a clear error when the API rejects the extension file
+// due to its size. The maximum size is enforced by the API, so it's not
+// checked before the upload.
+func uploadError(filePath string, size int64, err error) error {
+	if apierror.IsRuntimeStatusCode(err, http.StatusRequestEntityTooLarge) {
+		return fmt.Errorf(
+			"extension file %s is %d bytes, which exceeds the maximum upload size accepted by the API",
+			filePath, size,
+		)
+	}
+
+	return err
+}
+
+// fileHash returns the base64 encoded SHA-256 checksum of the file contents,
+// which is the same as Terraform's filebase64sha256 function.
+func fileHash(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", multierror.NewPrefixed("failed to open file", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", multierror.NewPrefixed("failed to hash file", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// setFileHash is a CustomizeDiff function which computes the "file_hash" from
+// the "file_path" contents when it isn't explicitly set, so the file is only
+// uploaded again when its contents change.
+func setFileHash(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	filePath := d.Get("file_path").(string)
+	if filePath == "" {
+		return nil
+	}
+
+	if cfg := d.GetRawConfig(); !cfg.IsNull() && !cfg.GetAttr("file_hash").IsNull() {
+		return nil
+	}
+
+	hash, err := fileHash(filePath)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("file_hash").(string) == hash {
+		return nil
+	}
+
+	return d.SetNew("file_hash", hash)
+}
@@ -0,0 +1,111 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package extensionresource
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_fileHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+		err      bool
+	}{
+		{
+			name:     "returns the base64 encoded SHA-256 of the file",
+			filePath: "testdata/test_extension_bundle.json",
+			want:     "Qm/ATwS/j9tYMdw3u7bc9w9jo34FpoxupfY+ha5Xk3Y=",
+		},
+		{
+			name:     "returns an error when the file doesn't exist",
+			filePath: "testdata/missing.zip",
+			err:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fileHash(tt.filePath)
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_validateFileSize(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		err  error
+	}{
+		{
+			name: "accepts a non-empty file",
+			size: 1000,
+		},
+		{
+			name: "rejects an empty file",
+			size: 0,
+			err:  errors.New("extension file plugin.zip is empty"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFileSize("plugin.zip", tt.size)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_uploadError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "returns a clear error when the file is too large",
+			err:  runtime.NewAPIError("unknown error", nil, http.StatusRequestEntityTooLarge),
+			want: errors.New("extension file plugin.zip is 1000 bytes, which exceeds the maximum upload size accepted by the API"),
+		},
+		{
+			name: "returns any other error as is",
+			err:  errors.New("some error"),
+			want: errors.New("some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uploadError("plugin.zip", 1000, tt.err)
+			assert.EqualError(t, err, tt.want.Error())
+		})
+	}
+}
@@ -19,11 +19,14 @@ package trafficfilterassocresource
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api"
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/trafficfilterapi"
+	"github.com/elastic/cloud-sdk-go/pkg/client/deployments_traffic_filter"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -35,13 +38,48 @@ func create(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.
 	params.API = client
 
 	if err := trafficfilterapi.CreateAssociation(params); err != nil {
+		if rulesetNotFound(err) {
+			return diag.FromErr(fmt.Errorf(
+				`traffic filter ruleset "%s" doesn't exist: reference the ec_deployment_traffic_filter resource id in "traffic_filter_id" so that the ruleset is created before the association: %w`,
+				params.ID, err,
+			))
+		}
 		return diag.FromErr(err)
 	}
 
 	d.SetId(hashID(params.EntityID, params.ID))
+	if err := d.Set("association_id", associationID(params.ID, params.EntityID)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The removed default associations are persisted even when the removal
+	// fails, so that these are restored once the association is destroyed.
+	if !d.Get("include_default").(bool) {
+		removed, err := removeDefaultAssociations(client, params.ID, params.EntityID)
+		if err := d.Set("removed_default_associations", removed); err != nil {
+			return diag.FromErr(err)
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	return read(ctx, d, meta)
 }
 
+// rulesetNotFound returns true when the association couldn't be created since
+// the traffic filter ruleset doesn't exist.
+func rulesetNotFound(err error) bool {
+	var notFound *deployments_traffic_filter.CreateTrafficFilterRulesetAssociationNotFound
+	return errors.As(err, &notFound)
+}
+
+// associationID returns the association identifier in the
+// "<traffic_filter_id>/<deployment_id>" format.
+func associationID(rulesetID, deploymentID string) string {
+	return rulesetID + "/" + deploymentID
+}
+
 func hashID(elem ...string) string {
 	return strconv.Itoa(schema.HashString(strings.Join(elem, "-")))
 }
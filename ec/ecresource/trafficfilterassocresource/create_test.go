@@ -0,0 +1,167 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trafficfilterassocresource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_create(t *testing.T) {
+	tc201 := util.NewResourceData(t, util.ResDataParams{
+		ID:     "123451",
+		State:  newSampleTrafficFilterAssociation(),
+		Schema: newSchema(),
+	})
+
+	tc404Err := util.NewResourceData(t, util.ResDataParams{
+		ID:     "123451",
+		State:  newSampleTrafficFilterAssociation(),
+		Schema: newSchema(),
+	})
+
+	tc500Err := util.NewResourceData(t, util.ResDataParams{
+		ID:     "123451",
+		State:  newSampleTrafficFilterAssociation(),
+		Schema: newSchema(),
+	})
+	tcExclusive := util.NewResourceData(t, util.ResDataParams{
+		ID: "123451",
+		State: map[string]interface{}{
+			"deployment_id":     mock.ValidClusterID,
+			"traffic_filter_id": mockTrafficFilterID,
+			"include_default":   false,
+		},
+		Schema: newSchema(),
+	})
+	type args struct {
+		ctx  context.Context
+		d    *schema.ResourceData
+		meta interface{}
+	}
+	tests := []struct {
+		name              string
+		args              args
+		want              diag.Diagnostics
+		wantAssociationID string
+		wantRemoved       []interface{}
+	}{
+		{
+			name: "captures the association id",
+			args: args{
+				d: tc201,
+				meta: api.NewMock(
+					mock.New201Response(mock.NewStringBody("{}")),
+					mock.New200StructResponse(models.TrafficFilterRulesetInfo{
+						ID: ec.String(mockTrafficFilterID),
+						Associations: []*models.FilterAssociation{{
+							EntityType: ec.String(entityType),
+							ID:         ec.String(mock.ValidClusterID),
+						}},
+					}),
+				),
+			},
+			wantAssociationID: mockTrafficFilterID + "/" + mock.ValidClusterID,
+		},
+		{
+			name: "removes the associations with the rulesets included by default when include_default is false",
+			args: args{
+				d: tcExclusive,
+				meta: api.NewMock(
+					mock.New201Response(mock.NewStringBody("{}")),
+					mock.New200StructResponse(models.TrafficFilterRulesetInfo{
+						ID:     ec.String(mockTrafficFilterID),
+						Region: ec.String("us-east-1"),
+					}),
+					mock.New200StructResponse(models.TrafficFilterRulesets{
+						Rulesets: []*models.TrafficFilterRulesetInfo{{
+							ID:               ec.String("default-ruleset"),
+							IncludeByDefault: ec.Bool(true),
+							Associations: []*models.FilterAssociation{{
+								EntityType: ec.String(entityType),
+								ID:         ec.String(mock.ValidClusterID),
+							}},
+						}},
+					}),
+					mock.New200Response(mock.NewStringBody("{}")),
+					mock.New200StructResponse(models.TrafficFilterRulesetInfo{
+						ID: ec.String(mockTrafficFilterID),
+						Associations: []*models.FilterAssociation{{
+							EntityType: ec.String(entityType),
+							ID:         ec.String(mock.ValidClusterID),
+						}},
+					}),
+				),
+			},
+			wantAssociationID: mockTrafficFilterID + "/" + mock.ValidClusterID,
+			wantRemoved:       []interface{}{"default-ruleset"},
+		},
+		{
+			name: "returns an error when it receives a 500",
+			args: args{
+				d: tc500Err,
+				meta: api.NewMock(mock.NewErrorResponse(500, mock.APIError{
+					Code: "some", Message: "message",
+				})),
+			},
+			want: diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "api error: 1 error occurred:\n\t* some: message\n\n",
+				},
+			},
+		},
+		{
+			name: "returns a clear error when the ruleset doesn't exist",
+			args: args{
+				d: tc404Err,
+				meta: api.NewMock(mock.NewErrorResponse(404, mock.APIError{
+					Code: "traffic_filter.not_found", Message: "not found",
+				})),
+			},
+			want: diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary: `traffic filter ruleset "` + mockTrafficFilterID + `" doesn't exist: ` +
+						`reference the ec_deployment_traffic_filter resource id in "traffic_filter_id" so that the ruleset is created before the association: ` +
+						"api error: 1 error occurred:\n\t* traffic_filter.not_found: not found\n\n",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := create(tt.args.ctx, tt.args.d, tt.args.meta)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantAssociationID, tt.args.d.Get("association_id"))
+			assert.ElementsMatch(t, tt.wantRemoved,
+				tt.args.d.Get("removed_default_associations").(*schema.Set).List(),
+			)
+		})
+	}
+}
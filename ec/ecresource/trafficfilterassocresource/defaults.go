@@ -0,0 +1,99 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trafficfilterassocresource
+
+import (
+	"fmt"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/trafficfilterapi"
+	"github.com/elastic/cloud-sdk-go/pkg/multierror"
+)
+
+// removeDefaultAssociations removes the association between the deployment
+// and the rulesets which are included by default in the region of the
+// associated ruleset, so that the deployment is only associated with the
+// rulesets which are explicitly associated with it. Only the associations of
+// the deployment are removed, the ruleset itself is left untouched. The IDs of
+// the rulesets whose association has been removed are returned, including
+// when an error occurs after some of them have been removed.
+func removeDefaultAssociations(client *api.API, rulesetID, deploymentID string) ([]string, error) {
+	ruleset, err := trafficfilterapi.Get(trafficfilterapi.GetParams{
+		API: client, ID: rulesetID,
+	})
+	if err != nil {
+		return nil, multierror.NewPrefixed("failed obtaining the traffic filter ruleset", err)
+	}
+
+	var region string
+	if ruleset.Region != nil {
+		region = *ruleset.Region
+	}
+
+	res, err := trafficfilterapi.List(trafficfilterapi.ListParams{
+		API: client, Region: region, IncludeAssociations: true,
+	})
+	if err != nil {
+		return nil, multierror.NewPrefixed("failed listing traffic filter rulesets", err)
+	}
+
+	var removed []string
+	for _, r := range res.Rulesets {
+		if r.ID == nil || *r.ID == rulesetID || r.IncludeByDefault == nil || !*r.IncludeByDefault {
+			continue
+		}
+
+		for _, assoc := range r.Associations {
+			if assoc.ID == nil || *assoc.ID != deploymentID {
+				continue
+			}
+
+			if err := trafficfilterapi.DeleteAssociation(trafficfilterapi.DeleteAssociationParams{
+				API:        client,
+				ID:         *r.ID,
+				EntityID:   deploymentID,
+				EntityType: entityType,
+			}); err != nil {
+				return removed, err
+			}
+			removed = append(removed, *r.ID)
+			break
+		}
+	}
+
+	return removed, nil
+}
+
+// restoreDefaultAssociations associates the deployment with the rulesets
+// whose association was removed by removeDefaultAssociations. The rulesets
+// which don't exist anymore are skipped.
+func restoreDefaultAssociations(client *api.API, rulesets []string, deploymentID string) error {
+	for _, id := range rulesets {
+		if err := trafficfilterapi.CreateAssociation(trafficfilterapi.CreateAssociationParams{
+			API:        client,
+			ID:         id,
+			EntityID:   deploymentID,
+			EntityType: entityType,
+		}); err != nil && !rulesetNotFound(err) {
+			return multierror.NewPrefixed(
+				fmt.Sprintf("failed restoring the association with the default traffic filter ruleset %s", id), err,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trafficfilterassocresource
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api"
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/elastic/cloud-sdk-go/pkg/models"
+	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_removeDefaultAssociations(t *testing.T) {
+	newAssociations := func(ids ...string) []*models.FilterAssociation {
+		var res []*models.FilterAssociation
+		for _, id := range ids {
+			res = append(res, &models.FilterAssociation{
+				EntityType: ec.String(entityType),
+				ID:         ec.String(id),
+			})
+		}
+		return res
+	}
+	rulesetResponse := mock.New200StructResponse(models.TrafficFilterRulesetInfo{
+		ID:     ec.String(mockTrafficFilterID),
+		Region: ec.String("us-east-1"),
+	})
+	tests := []struct {
+		name   string
+		client *api.API
+		want   []string
+		err    error
+	}{
+		{
+			name: "only removes the associations of the deployment with other rulesets included by default",
+			client: api.NewMock(
+				rulesetResponse,
+				mock.New200StructResponse(models.TrafficFilterRulesets{
+					Rulesets: []*models.TrafficFilterRulesetInfo{
+						{
+							ID:               ec.String(mockTrafficFilterID),
+							IncludeByDefault: ec.Bool(true),
+							Associations:     newAssociations(mock.ValidClusterID),
+						},
+						{
+							ID:               ec.String("not-default"),
+							IncludeByDefault: ec.Bool(false),
+							Associations:     newAssociations(mock.ValidClusterID),
+						},
+						{
+							ID:               ec.String("default-other-deployment"),
+							IncludeByDefault: ec.Bool(true),
+							Associations:     newAssociations("other-deployment"),
+						},
+						{
+							ID:               ec.String("default"),
+							IncludeByDefault: ec.Bool(true),
+							Associations:     newAssociations("other-deployment", mock.ValidClusterID),
+						},
+					},
+				}),
+				mock.New200Response(mock.NewStringBody("{}")),
+			),
+			want: []string{"default"},
+		},
+		{
+			name: "returns an error when the rulesets can't be listed",
+			client: api.NewMock(
+				rulesetResponse,
+				mock.NewErrorResponse(500, mock.APIError{
+					Code: "some", Message: "message",
+				}),
+			),
+			err: errors.New("failed listing traffic filter rulesets: 1 error occurred:\n\t* api error: some: message\n\n"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := removeDefaultAssociations(tt.client, mockTrafficFilterID, mock.ValidClusterID)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_restoreDefaultAssociations(t *testing.T) {
+	createAssociation := func(id string) mock.Response {
+		return mock.New201ResponseAssertion(
+			&mock.RequestAssertion{
+				Host:   api.DefaultMockHost,
+				Header: api.DefaultWriteMockHeaders,
+				Method: "POST",
+				Path:   "/api/v1/deployments/traffic-filter/rulesets/" + id + "/associations",
+				Body:   mock.NewStringBody(`{"entity_type":"deployment","id":"` + mock.ValidClusterID + `"}` + "\n"),
+			},
+			mock.NewStringBody("{}"),
+		)
+	}
+	tests := []struct {
+		name     string
+		client   *api.API
+		rulesets []string
+		err      error
+	}{
+		{
+			name:     "associates the deployment with each of the rulesets",
+			client:   api.NewMock(createAssociation("default-a"), createAssociation("default-b")),
+			rulesets: []string{"default-a", "default-b"},
+		},
+		{
+			name: "skips the rulesets which don't exist anymore",
+			client: api.NewMock(
+				mock.NewErrorResponse(404, mock.APIError{
+					Code: "traffic_filter.not_found", Message: "not found",
+				}),
+				createAssociation("default-b"),
+			),
+			rulesets: []string{"default-a", "default-b"},
+		},
+		{
+			name: "returns an error when the association can't be created",
+			client: api.NewMock(mock.NewErrorResponse(500, mock.APIError{
+				Code: "some", Message: "message",
+			})),
+			rulesets: []string{"default-a"},
+			err:      errors.New("failed restoring the association with the default traffic filter ruleset default-a: 1 error occurred:\n\t* api error: some: message\n\n"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := restoreDefaultAssociations(tt.client, tt.rulesets, mock.ValidClusterID)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
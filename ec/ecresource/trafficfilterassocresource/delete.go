@@ -26,6 +26,8 @@ import (
 	"github.com/elastic/cloud-sdk-go/pkg/client/deployments_traffic_filter"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
 )
 
 // delete will delete an existing deployment traffic filter ruleset association.
@@ -36,11 +38,16 @@ func delete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.
 	params.API = client
 
 	if err := trafficfilterapi.DeleteAssociation(trafficfilterapi.DeleteAssociationParams(params)); err != nil {
-		if associationDeleted(err) {
-			d.SetId("")
-			return nil
+		if !associationDeleted(err) {
+			return diag.FromErr(err)
+		}
+	}
+
+	removed, _ := d.Get("removed_default_associations").(*schema.Set)
+	if removed != nil && removed.Len() > 0 {
+		if err := restoreDefaultAssociations(client, util.ItemsToString(removed.List()), params.EntityID); err != nil {
+			return diag.FromErr(err)
 		}
-		return diag.FromErr(err)
 	}
 
 	d.SetId("")
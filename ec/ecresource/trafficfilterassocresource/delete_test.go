@@ -52,6 +52,24 @@ func Test_delete(t *testing.T) {
 		Schema: newSchema(),
 	})
 	wantTC404.SetId("")
+
+	newExclusive := func() map[string]interface{} {
+		state := newSampleTrafficFilterAssociation()
+		state["include_default"] = false
+		state["removed_default_associations"] = []interface{}{"default-ruleset"}
+		return state
+	}
+	tcRestore := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		State:  newExclusive(),
+		Schema: newSchema(),
+	})
+	wantTCRestore := util.NewResourceData(t, util.ResDataParams{
+		ID:     mock.ValidClusterID,
+		State:  newExclusive(),
+		Schema: newSchema(),
+	})
+	wantTCRestore.SetId("")
 	type args struct {
 		ctx  context.Context
 		d    *schema.ResourceData
@@ -90,6 +108,27 @@ func Test_delete(t *testing.T) {
 			want:   nil,
 			wantRD: wantTC404,
 		},
+		{
+			name: "associates the removed default rulesets again",
+			args: args{
+				d: tcRestore,
+				meta: api.NewMock(
+					mock.New200Response(mock.NewStringBody("{}")),
+					mock.New201ResponseAssertion(
+						&mock.RequestAssertion{
+							Host:   api.DefaultMockHost,
+							Header: api.DefaultWriteMockHeaders,
+							Method: "POST",
+							Path:   "/api/v1/deployments/traffic-filter/rulesets/default-ruleset/associations",
+							Body:   mock.NewStringBody(`{"entity_type":"deployment","id":"` + mock.ValidClusterID + `"}` + "\n"),
+						},
+						mock.NewStringBody("{}"),
+					),
+				),
+			},
+			want:   nil,
+			wantRD: wantTCRestore,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -35,6 +35,21 @@ func flatten(res *models.TrafficFilterRulesetInfo, d *schema.ResourceData) error
 		}
 	}
 
+	// Populates the association ID on resources created before it existed.
+	if found && d.Get("association_id").(string) == "" {
+		if err := d.Set("association_id", associationID(d.Get("traffic_filter_id").(string), deploymentID)); err != nil {
+			return err
+		}
+	}
+
+	// Resources created before "include_default" existed are additive to the
+	// region defaults.
+	if _, ok := d.GetOkExists("include_default"); found && !ok { //nolint:staticcheck
+		if err := d.Set("include_default", true); err != nil {
+			return err
+		}
+	}
+
 	if !found {
 		if err := d.Set("deployment_id", ""); err != nil {
 			return err
@@ -24,6 +24,7 @@ import (
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/elastic/terraform-provider-ec/ec/internal/util"
@@ -118,3 +119,39 @@ func Test_flatten(t *testing.T) {
 		})
 	}
 }
+
+func Test_flattenIncludeDefault(t *testing.T) {
+	res := &models.TrafficFilterRulesetInfo{
+		Associations: []*models.FilterAssociation{{
+			EntityType: ec.String(entityType),
+			ID:         ec.String(mock.ValidClusterID),
+		}},
+	}
+	tests := []struct {
+		name  string
+		state map[string]string
+		want  string
+	}{
+		{
+			name:  "sets include_default on associations created before it existed",
+			state: map[string]string{},
+			want:  "true",
+		},
+		{
+			name:  "keeps an explicit include_default",
+			state: map[string]string{"include_default": "false"},
+			want:  "false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.state["deployment_id"] = mock.ValidClusterID
+			tt.state["traffic_filter_id"] = mockTrafficFilterID
+			d := Resource().Data(&terraform.InstanceState{
+				ID: "123451", Attributes: tt.state,
+			})
+			assert.NoError(t, flatten(res, d))
+			assert.Equal(t, tt.want, d.State().Attributes["include_default"])
+		})
+	}
+}
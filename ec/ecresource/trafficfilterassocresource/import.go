@@ -0,0 +1,57 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trafficfilterassocresource
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// importFunc imports an association from its "<traffic_filter_id>/<deployment_id>"
+// association ID.
+func importFunc(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.Split(d.Id(), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf(
+			`invalid association id "%s": expected format "<traffic_filter_id>/<deployment_id>"`,
+			d.Id(),
+		)
+	}
+
+	rulesetID, deploymentID := parts[0], parts[1]
+	if err := d.Set("traffic_filter_id", rulesetID); err != nil {
+		return nil, err
+	}
+	if err := d.Set("deployment_id", deploymentID); err != nil {
+		return nil, err
+	}
+	if err := d.Set("association_id", associationID(rulesetID, deploymentID)); err != nil {
+		return nil, err
+	}
+	// The association is imported as additive to the region defaults, since
+	// removing them is only done when the association is created.
+	if err := d.Set("include_default", true); err != nil {
+		return nil, err
+	}
+
+	d.SetId(hashID(deploymentID, rulesetID))
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,75 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package trafficfilterassocresource
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/elastic/cloud-sdk-go/pkg/api/mock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/terraform-provider-ec/ec/internal/util"
+)
+
+func Test_importFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want map[string]interface{}
+		err  error
+	}{
+		{
+			name: "imports the association from its association id",
+			id:   mockTrafficFilterID + "/" + mock.ValidClusterID,
+			want: map[string]interface{}{
+				"traffic_filter_id": mockTrafficFilterID,
+				"deployment_id":     mock.ValidClusterID,
+				"association_id":    mockTrafficFilterID + "/" + mock.ValidClusterID,
+			},
+		},
+		{
+			name: "fails when the association id has an invalid format",
+			id:   mockTrafficFilterID,
+			err: errors.New(
+				`invalid association id "420b7b540dfc967a7a649c18e2fce4e4": expected format "<traffic_filter_id>/<deployment_id>"`,
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := util.NewResourceData(t, util.ResDataParams{
+				ID:     tt.id,
+				State:  map[string]interface{}{},
+				Schema: newSchema(),
+			})
+			got, err := importFunc(context.Background(), d, nil)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			for k, v := range tt.want {
+				assert.Equal(t, v, got[0].Get(k), k)
+			}
+			assert.Equal(t, hashID(mock.ValidClusterID, mockTrafficFilterID), got[0].Id())
+		})
+	}
+}
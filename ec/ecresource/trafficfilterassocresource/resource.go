@@ -33,6 +33,10 @@ func Resource() *schema.Resource {
 		ReadContext:   read,
 		DeleteContext: delete,
 
+		Importer: &schema.ResourceImporter{
+			StateContext: importFunc,
+		},
+
 		Timeouts: &schema.ResourceTimeout{
 			Default: schema.DefaultTimeout(10 * time.Minute),
 		},
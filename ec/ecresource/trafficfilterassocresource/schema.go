@@ -36,5 +36,25 @@ func newSchema() map[string]*schema.Schema {
 			Required:    true,
 			ForceNew:    true,
 		},
+		"include_default": {
+			Type:        schema.TypeBool,
+			Description: "Optionally set to false to remove the association of the deployment with the traffic filters which are included by default in its region, so that the association isn't additive to the region defaults. Defaults to true.",
+			Optional:    true,
+			Default:     true,
+			ForceNew:    true,
+		},
+		"removed_default_associations": {
+			Type:        schema.TypeSet,
+			Description: "Computed list of the traffic filter rulesets included by default in the region whose association with the deployment was removed when \"include_default\" is false. These are associated with the deployment again when the association is destroyed.",
+			Computed:    true,
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"association_id": {
+			Type:        schema.TypeString,
+			Description: "Computed association ID, composed of the traffic filter and deployment IDs",
+			Computed:    true,
+		},
 	}
 }
@@ -19,13 +19,21 @@ package util
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/elastic/cloud-sdk-go/pkg/api/deploymentapi/deploymentsize"
 	"github.com/elastic/cloud-sdk-go/pkg/models"
 	"github.com/elastic/cloud-sdk-go/pkg/util/ec"
 )
 
-const defaultSizeResource = "memory"
+const (
+	defaultSizeResource = "memory"
+
+	// mbPerTb is the number of megabytes in a terabyte.
+	mbPerTb = 1024 * 1024
+)
 
 // MemoryToState parses a megabyte int notation to a gigabyte notation.
 func MemoryToState(mem int32) string {
@@ -35,11 +43,60 @@ func MemoryToState(mem int32) string {
 	return fmt.Sprintf("%dg", mem/1024)
 }
 
+// SizeToState parses a megabyte int notation to a terabyte notation when the
+// size is a whole number of terabytes (e.g. "2t"), or to the MemoryToState
+// gigabyte notation otherwise (e.g. "8g" or "0.5g").
+func SizeToState(mem int32) string {
+	if mem > 0 && mem%mbPerTb == 0 {
+		return fmt.Sprintf("%dt", mem/mbPerTb)
+	}
+	return MemoryToState(mem)
+}
+
+// ParseSize parses a size in the "<size>t" terabyte notation or in the
+// "<size>g" gigabyte notation to its megabyte int notation.
+func ParseSize(size string) (int32, error) {
+	lower := strings.ToLower(size)
+	for _, suffix := range []string{"tb", "t"} {
+		if !strings.HasSuffix(lower, suffix) {
+			continue
+		}
+
+		tb, err := strconv.ParseFloat(strings.TrimSuffix(lower, suffix), 32)
+		if err != nil {
+			return 0, fmt.Errorf(`failed to convert "%s" to <size><t>: %w`, size, err)
+		}
+
+		// The size is checked before it's converted, since values which
+		// don't fit in an int32, such as "inft", would overflow.
+		mb := tb * mbPerTb
+		if math.IsNaN(mb) || math.IsInf(mb, 0) {
+			return 0, fmt.Errorf(`size "%s" is invalid: it must be a finite number`, size)
+		}
+
+		if mb < 0 {
+			return 0, fmt.Errorf(`size "%s" is invalid: minimum size is 0.0g`, size)
+		}
+
+		if mb > math.MaxInt32 {
+			return 0, fmt.Errorf(`size "%s" is invalid: maximum size is %dt`, size, math.MaxInt32/mbPerTb)
+		}
+
+		if math.Mod(mb, 512) != 0 {
+			return 0, fmt.Errorf(`size "%s" is invalid: only increments of 0.5g are permitted`, size)
+		}
+
+		return int32(mb), nil
+	}
+
+	return deploymentsize.ParseGb(size)
+}
+
 // ParseTopologySize parses a flattened topology into its model.
 func ParseTopologySize(topology map[string]interface{}) (*models.TopologySize, error) {
 	if mem, ok := topology["size"]; ok {
 		if m := mem.(string); m != "" {
-			val, err := deploymentsize.ParseGb(m)
+			val, err := ParseSize(m)
 			if err != nil {
 				return nil, err
 			}
@@ -54,6 +54,123 @@ func TestMemoryToState(t *testing.T) {
 	}
 }
 
+func TestSizeToState(t *testing.T) {
+	type args struct {
+		mem int32
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "512 megabytes turns into 0.5g",
+			args: args{mem: 512},
+			want: "0.5g",
+		},
+		{
+			name: "gigabytes",
+			args: args{mem: 8192},
+			want: "8g",
+		},
+		{
+			name: "whole terabytes",
+			args: args{mem: 2097152},
+			want: "2t",
+		},
+		{
+			name: "terabytes which aren't whole turn into gigabytes",
+			args: args{mem: 1572864},
+			want: "1536g",
+		},
+		{
+			name: "zero",
+			args: args{mem: 0},
+			want: "0g",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SizeToState(tt.args.mem)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	type args struct {
+		size string
+	}
+	tests := []struct {
+		name string
+		args args
+		want int32
+		err  error
+	}{
+		{
+			name: "parses the gigabyte notation",
+			args: args{size: "0.5g"},
+			want: 512,
+		},
+		{
+			name: "parses the terabyte notation",
+			args: args{size: "2t"},
+			want: 2097152,
+		},
+		{
+			name: "parses the uppercase terabyte notation",
+			args: args{size: "1.5TB"},
+			want: 1572864,
+		},
+		{
+			name: "fails parsing an invalid terabyte notation",
+			args: args{size: "at"},
+			err:  errors.New(`failed to convert "at" to <size><t>: strconv.ParseFloat: parsing "a": invalid syntax`),
+		},
+		{
+			name: "fails parsing a terabyte size which overflows",
+			args: args{size: "3000t"},
+			err:  errors.New(`size "3000t" is invalid: maximum size is 2047t`),
+		},
+		{
+			name: "fails parsing an infinite terabyte size",
+			args: args{size: "inft"},
+			err:  errors.New(`size "inft" is invalid: it must be a finite number`),
+		},
+		{
+			name: "fails parsing a NaN terabyte size",
+			args: args{size: "nant"},
+			err:  errors.New(`size "nant" is invalid: it must be a finite number`),
+		},
+		{
+			name: "fails parsing a negative terabyte size",
+			args: args{size: "-1t"},
+			err:  errors.New(`size "-1t" is invalid: minimum size is 0.0g`),
+		},
+		{
+			name: "fails parsing a terabyte size which isn't an increment of 0.5g",
+			args: args{size: "0.0001t"},
+			err:  errors.New(`size "0.0001t" is invalid: only increments of 0.5g are permitted`),
+		},
+		{
+			name: "fails parsing a size without a unit",
+			args: args{size: "2"},
+			err:  errors.New(`failed to convert "2" to <size><g>`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSize(tt.args.size)
+			if tt.err != nil {
+				assert.EqualError(t, err, tt.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestParseTopologySize(t *testing.T) {
 	type args struct {
 		topology map[string]interface{}
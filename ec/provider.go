@@ -27,6 +27,8 @@ import (
 
 	"github.com/elastic/terraform-provider-ec/ec/ecdatasource/deploymentdatasource"
 	"github.com/elastic/terraform-provider-ec/ec/ecdatasource/deploymentsdatasource"
+	"github.com/elastic/terraform-provider-ec/ec/ecdatasource/deploymentvalidationdatasource"
+	"github.com/elastic/terraform-provider-ec/ec/ecdatasource/instanceconfigurationsdatasource"
 	"github.com/elastic/terraform-provider-ec/ec/ecdatasource/stackdatasource"
 	"github.com/elastic/terraform-provider-ec/ec/ecresource/deploymentresource"
 	"github.com/elastic/terraform-provider-ec/ec/ecresource/elasticsearchkeystoreresource"
@@ -44,6 +46,9 @@ const (
 	timeoutDesc      = "Timeout used for individual HTTP calls. Defaults to \"1m\"."
 	verboseDesc      = "When set, a \"request.log\" file will be written with all outgoing HTTP requests. Defaults to \"false\"."
 	verboseCredsDesc = "When set with verbose, the contents of the Authorization header will not be redacted. Defaults to \"false\"."
+	pollIntervalDesc = "Interval between the API calls which track the pending deployment changes. Defaults to \"2s\"."
+	regionDesc       = "Default region of the deployments which don't set any, such as \"us-east-1\"."
+	prereleaseDesc   = "When set, pre-release and snapshot Elastic Stack versions, such as \"8.3.0-SNAPSHOT\", are accepted as the deployment version. Only meant to test unreleased builds. Defaults to \"false\"."
 )
 
 var (
@@ -57,20 +62,28 @@ var (
 	// since any requests which timeout due to network factors are retried
 	// automatically by the SDK 2 times.
 	defaultTimeout = 40 * time.Second
+
+	// defaultPollInterval used to track the pending deployment changes.
+	defaultPollInterval = 2 * time.Second
 )
 
 // Provider returns a schema.Provider.
 func Provider() *schema.Provider {
+	var versions deploymentresource.VersionSettings
+	var tracking deploymentresource.TrackingSettings
+	var regions deploymentresource.RegionSettings
 	return &schema.Provider{
-		ConfigureContextFunc: configureAPI,
+		ConfigureContextFunc: configureProvider(&versions, &tracking, &regions),
 		Schema:               newSchema(),
 		DataSourcesMap: map[string]*schema.Resource{
-			"ec_deployment":  deploymentdatasource.DataSource(),
-			"ec_deployments": deploymentsdatasource.DataSource(),
-			"ec_stack":       stackdatasource.DataSource(),
+			"ec_deployment":              deploymentdatasource.DataSource(),
+			"ec_deployment_validation":   deploymentvalidationdatasource.DataSource(),
+			"ec_deployments":             deploymentsdatasource.DataSource(),
+			"ec_instance_configurations": instanceconfigurationsdatasource.DataSource(),
+			"ec_stack":                   stackdatasource.DataSource(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"ec_deployment":                            deploymentresource.Resource(),
+			"ec_deployment":                            deploymentresource.Resource(&versions, &tracking, &regions),
 			"ec_deployment_elasticsearch_keystore":     elasticsearchkeystoreresource.Resource(),
 			"ec_deployment_traffic_filter":             trafficfilterresource.Resource(),
 			"ec_deployment_traffic_filter_association": trafficfilterassocresource.Resource(),
@@ -151,6 +164,31 @@ func newSchema() map[string]*schema.Schema {
 				"EC_VERBOSE_CREDENTIALS", false,
 			),
 		},
+		"allow_prerelease_versions": {
+			Description: prereleaseDesc,
+			Type:        schema.TypeBool,
+			Optional:    true,
+			DefaultFunc: schema.EnvDefaultFunc(
+				"EC_ALLOW_PRERELEASE_VERSIONS", false,
+			),
+		},
+		"region": {
+			Description: regionDesc,
+			Type:        schema.TypeString,
+			Optional:    true,
+			DefaultFunc: schema.EnvDefaultFunc(
+				"EC_REGION", "",
+			),
+		},
+		"poll_interval": {
+			Description:  pollIntervalDesc,
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: deploymentresource.ValidatePollInterval,
+			DefaultFunc: schema.EnvDefaultFunc(
+				"EC_POLL_INTERVAL", defaultPollInterval.String(),
+			),
+		},
 		"verbose_file": {
 			Description: timeoutDesc,
 			Type:        schema.TypeString,
@@ -28,6 +28,8 @@ import (
 	"github.com/elastic/cloud-sdk-go/pkg/auth"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/elastic/terraform-provider-ec/ec/ecresource/deploymentresource"
 )
 
 const (
@@ -39,6 +41,24 @@ var (
 	DefaultHTTPRetries = 2
 )
 
+// configureProvider returns a schema.ConfigureContextFunc which configures the
+// API client and populates the deployment version, tracking and region
+// settings.
+func configureProvider(versions *deploymentresource.VersionSettings, tracking *deploymentresource.TrackingSettings, regions *deploymentresource.RegionSettings) schema.ConfigureContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		versions.AllowPrerelease = d.Get("allow_prerelease_versions").(bool)
+		regions.Region = d.Get("region").(string)
+
+		interval, err := time.ParseDuration(d.Get("poll_interval").(string))
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		tracking.PollInterval = interval
+
+		return configureAPI(ctx, d)
+	}
+}
+
 // configureAPI implements schema.ConfigureContextFunc
 func configureAPI(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	cfg, err := newAPIConfig(d)
@@ -9,4 +9,5 @@ require (
 	github.com/go-openapi/strfmt v0.21.2
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.15.0
 	github.com/stretchr/testify v1.7.1
+	gopkg.in/yaml.v2 v2.4.0
 )